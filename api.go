@@ -0,0 +1,51 @@
+package yacspin
+
+// SpinnerAPI is the interface satisfied by *Spinner, covering the methods for
+// controlling the lifecycle of the spinner and updating what it displays.
+// Libraries can accept a SpinnerAPI instead of a *Spinner, so that their
+// callers can provide a different implementation, like a fake one in tests.
+type SpinnerAPI interface {
+	// Start starts the spinner. See (*Spinner).Start().
+	Start() error
+
+	// Stop stops the spinner, printing the StopMessage. See (*Spinner).Stop().
+	Stop() error
+
+	// StopFail stops the spinner, printing the StopFailMessage. See
+	// (*Spinner).StopFail().
+	StopFail() error
+
+	// Pause pauses the spinner. See (*Spinner).Pause().
+	Pause() error
+
+	// Unpause unpauses the spinner. See (*Spinner).Unpause().
+	Unpause() error
+
+	// Status returns the status of the spinner. See (*Spinner).Status().
+	Status() SpinnerStatus
+
+	// Message updates the Message. See (*Spinner).Message().
+	Message(message string)
+
+	// Prefix updates the Prefix. See (*Spinner).Prefix().
+	Prefix(prefix string)
+
+	// Suffix updates the Suffix. See (*Spinner).Suffix().
+	Suffix(suffix string)
+
+	// Percent updates the progress percentage. See (*Spinner).Percent().
+	Percent(percent float64) error
+
+	// LogMessage prints a message above the spinner. See
+	// (*Spinner).LogMessage().
+	LogMessage(message string) error
+
+	// StopMessage updates the StopMessage. See (*Spinner).StopMessage().
+	StopMessage(message string)
+
+	// StopFailMessage updates the StopFailMessage. See
+	// (*Spinner).StopFailMessage().
+	StopFailMessage(message string)
+}
+
+var _ SpinnerAPI = (*Spinner)(nil)
@@ -0,0 +1,130 @@
+package yacspin
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeSpinner is a SpinnerAPI implementation recording the calls made to it
+type fakeSpinner struct {
+	calls  []string
+	status SpinnerStatus
+}
+
+func (f *fakeSpinner) record(call string) { f.calls = append(f.calls, call) }
+
+func (f *fakeSpinner) Start() error {
+	f.record("Start")
+	f.status = SpinnerRunning
+	return nil
+}
+
+func (f *fakeSpinner) Stop() error {
+	f.record("Stop")
+	f.status = SpinnerStopped
+	return nil
+}
+
+func (f *fakeSpinner) StopFail() error {
+	f.record("StopFail")
+	f.status = SpinnerStopped
+	return nil
+}
+
+func (f *fakeSpinner) Pause() error {
+	f.record("Pause")
+	f.status = SpinnerPaused
+	return nil
+}
+
+func (f *fakeSpinner) Unpause() error {
+	f.record("Unpause")
+	f.status = SpinnerRunning
+	return nil
+}
+
+func (f *fakeSpinner) Status() SpinnerStatus           { return f.status }
+func (f *fakeSpinner) Message(message string)          { f.record("Message " + message) }
+func (f *fakeSpinner) Prefix(prefix string)            { f.record("Prefix " + prefix) }
+func (f *fakeSpinner) Suffix(suffix string)            { f.record("Suffix " + suffix) }
+func (f *fakeSpinner) Percent(float64) error           { f.record("Percent"); return nil }
+func (f *fakeSpinner) LogMessage(message string) error { f.record("LogMessage " + message); return nil }
+func (f *fakeSpinner) StopMessage(message string)      { f.record("StopMessage " + message) }
+func (f *fakeSpinner) StopFailMessage(message string)  { f.record("StopFailMessage " + message) }
+
+var _ SpinnerAPI = (*fakeSpinner)(nil)
+
+// runSteps is an example of library code accepting a SpinnerAPI
+func runSteps(sp SpinnerAPI, steps []string, fail error) error {
+	if err := sp.Start(); err != nil {
+		return err
+	}
+
+	for _, step := range steps {
+		sp.Message(step)
+	}
+
+	if fail != nil {
+		sp.StopFailMessage(fail.Error())
+		return sp.StopFail()
+	}
+
+	sp.StopMessage("done")
+
+	return sp.Stop()
+}
+
+func TestSpinnerAPI(t *testing.T) {
+	t.Run("fake", func(t *testing.T) {
+		tests := []struct {
+			name string
+			fail error
+			want []string
+		}{
+			{
+				name: "success",
+				want: []string{"Start", "Message one", "Message two", "StopMessage done", "Stop"},
+			},
+			{
+				name: "failure",
+				fail: errors.New("oops"),
+				want: []string{"Start", "Message one", "Message two", "StopFailMessage oops", "StopFail"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				fake := &fakeSpinner{}
+
+				testErrCheck(t, "runSteps()", "", runSteps(fake, []string{"one", "two"}, tt.fail))
+
+				if diff := cmp.Diff(tt.want, fake.calls); diff != "" {
+					t.Fatalf("calls differ: (-want / +got)\n%s", diff)
+				}
+
+				if fake.Status() != SpinnerStopped {
+					t.Fatalf("fake.Status() = %s, want %s", fake.Status(), SpinnerStopped)
+				}
+			})
+		}
+	})
+
+	t.Run("spinner", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		cfg := testConfig(buf, ForceNoTTYMode|ForceDumbTerminalMode)
+		cfg.Message = ""
+		cfg.StopMessage = ""
+
+		spinner := newTestSpinner(t, cfg)
+
+		testErrCheck(t, "runSteps()", "", runSteps(spinner, nil, nil))
+
+		if want := "v done\n"; !bytes.HasSuffix(buf.Bytes(), []byte(want)) {
+			t.Fatalf("output = %q, want it to end with %q", buf.String(), want)
+		}
+	})
+}
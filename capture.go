@@ -0,0 +1,81 @@
+package yacspin
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimedFrame is a single frame written by a *Spinner, as recorded by a
+// FrameCapturer.
+type TimedFrame struct {
+	// Text is the plain text of the frame, without any escape sequences,
+	// line erasure, or trailing newline.
+	Text string
+
+	// Offset is the amount of time between the first frame being captured
+	// and this one.
+	Offset time.Duration
+}
+
+// FrameCapturer is an io.Writer that records each frame written to it by a
+// *Spinner, along with when it was written. Set it as the Writer of the Config
+// to capture the frames of a spinner, for example to build your own recordings
+// or animated images of it. The zero value is ready to use, and it's safe for
+// concurrent use.
+type FrameCapturer struct {
+	mu     sync.Mutex
+	start  time.Time
+	frames []TimedFrame
+
+	now func() time.Time // for tests; time.Now if nil
+}
+
+// Write records p as a frame. It never returns an error.
+func (fc *FrameCapturer) Write(p []byte) (int, error) {
+	now := time.Now
+	if fc.now != nil {
+		now = fc.now
+	}
+
+	t := now()
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if len(fc.frames) == 0 {
+		fc.start = t
+	}
+
+	fc.frames = append(fc.frames, TimedFrame{
+		Text:   frameText(string(p)),
+		Offset: t.Sub(fc.start),
+	})
+
+	return len(p), nil
+}
+
+// Frames returns the frames captured so far.
+func (fc *FrameCapturer) Frames() []TimedFrame {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	frames := make([]TimedFrame, len(fc.frames))
+	copy(frames, fc.frames)
+
+	return frames
+}
+
+// frameText returns the plain text of the frame, which is whatever follows the
+// last carriage return once escape sequences are removed
+func frameText(frame string) string {
+	frame = escapeRe.ReplaceAllString(frame, "")
+	frame = strings.ReplaceAll(frame, "\r\n", "\n")
+	frame = strings.TrimRight(frame, "\n")
+
+	if i := strings.LastIndexByte(frame, '\r'); i >= 0 {
+		frame = frame[i+1:]
+	}
+
+	return frame
+}
@@ -0,0 +1,164 @@
+package yacspin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_frameText(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		want  string
+	}{
+		{
+			name:  "smart_terminal",
+			frame: "\r\033[K\ry msg",
+			want:  "y msg",
+		},
+		{
+			name:  "smart_terminal_hide_cursor",
+			frame: "\r\033[K\r\033[?25l\ry msg",
+			want:  "y msg",
+		},
+		{
+			name:  "dumb_terminal",
+			frame: "\r     \ry msg",
+			want:  "y msg",
+		},
+		{
+			name:  "no_tty",
+			frame: "y msg\n",
+			want:  "y msg",
+		},
+		{
+			name:  "crlf",
+			frame: "\r\033[K\rv stop\r\n",
+			want:  "v stop",
+		},
+		{
+			name:  "colors",
+			frame: "\r\033[K\r\033[32my\033[0m msg",
+			want:  "y msg",
+		},
+		{
+			name:  "hyperlink",
+			frame: "\r\033[K\rv \033]8;;https://example.org\033\\stop\033]8;;\033\\",
+			want:  "v stop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := frameText(tt.frame); got != tt.want {
+				t.Fatalf("frameText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrameCapturer(t *testing.T) {
+	const tick = 100 * time.Millisecond
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fc := &FrameCapturer{
+		now: func() time.Time {
+			t := now
+			now = now.Add(tick)
+			return t
+		},
+	}
+
+	spinner, err := New(Config{
+		Writer:       fc,
+		CharSet:      []string{"a", "b"},
+		Suffix:       " ",
+		Message:      "msg",
+		StopMessage:  "stop",
+		ShowCursor:   true,
+		TerminalMode: termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	spinner.Render()
+	spinner.Render()
+	spinner.Render()
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	want := []TimedFrame{
+		{Text: "a msg", Offset: 0},
+		{Text: "b msg", Offset: tick},
+		{Text: "a msg", Offset: 2 * tick},
+		{Text: "stop", Offset: 3 * tick},
+	}
+
+	frames := fc.Frames()
+
+	if diff := cmp.Diff(want, frames); diff != "" {
+		t.Fatalf("frames differ: (-want / +got)\n%s", diff)
+	}
+
+	// the returned frames should be a copy
+	frames[0].Text = "changed"
+
+	if got := fc.Frames()[0].Text; got != "a msg" {
+		t.Fatalf("fc.Frames()[0].Text = %q, want %q", got, "a msg")
+	}
+}
+
+func TestFrameCapturer_painter(t *testing.T) {
+	const frequency = 20 * time.Millisecond
+
+	fc := &FrameCapturer{}
+
+	spinner, err := New(Config{
+		Writer:       fc,
+		Frequency:    frequency,
+		CharSet:      []string{"a"},
+		Suffix:       " ",
+		Message:      "msg",
+		ShowCursor:   true,
+		TerminalMode: termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
+
+	testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+	time.Sleep(10 * frequency)
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	frames := fc.Frames()
+
+	if len(frames) < 3 {
+		t.Fatalf("len(frames) = %d, want at least 3", len(frames))
+	}
+
+	// frames are painted on every tick, so bucketing the offsets by the
+	// frequency should never place two frames in the same bucket
+	buckets := make(map[int64]int)
+
+	for i, f := range frames[:len(frames)-1] {
+		if f.Text != "a msg" {
+			t.Fatalf("frames[%d].Text = %q, want %q", i, f.Text, "a msg")
+		}
+
+		if i > 0 && f.Offset <= frames[i-1].Offset {
+			t.Fatalf("frames[%d].Offset = %s, want greater than %s", i, f.Offset, frames[i-1].Offset)
+		}
+
+		buckets[int64((f.Offset+frequency/2)/frequency)]++
+	}
+
+	for b, n := range buckets {
+		if n > 1 {
+			t.Fatalf("bucket %d has %d frames, want 1", b, n)
+		}
+	}
+}
@@ -21,9 +21,13 @@
 //
 // - removed runtime generation of CharSets 37 and 38; made them literals
 // - fixed pipe spinner (32) animation, by adding missing frame
+// - added CharSetPreview type and CharSetPreviews() function
+// - added DefaultCharSetIndex constant and DefaultCharSet variable
 
 package yacspin
 
+import "sort"
+
 // CharSets contains the default character sets from
 // https://github.com/briandowns/spinner.
 var CharSets = map[int][]string{
@@ -119,3 +123,46 @@ var CharSets = map[int][]string{
 	89: {"½", "⅓", "⅔", "¼", "¾", "⅛", "⅜", "⅝", "⅞"},
 	90: {"↞", "↟", "↠", "↡"},
 }
+
+// DefaultCharSetIndex is the key of the character set within CharSets used by
+// New() when the CharSet field of the Config isn't set.
+const DefaultCharSetIndex = 9
+
+// DefaultCharSet is the character set used by New() when the CharSet field of
+// the Config isn't set.
+var DefaultCharSet = CharSets[DefaultCharSetIndex]
+
+// CharSetPreview describes one of the character sets in the CharSets variable.
+type CharSetPreview struct {
+	// Index is the key of the character set within CharSets.
+	Index int
+
+	// Frames are the characters of the character set, in animation order.
+	Frames []string
+
+	// FrameCount is the number of frames in the character set.
+	FrameCount int
+}
+
+// CharSetPreviews returns a preview of each character set in the CharSets
+// variable, sorted by index. This is useful for letting users choose a
+// spinner. The returned frames are copies, so modifying them does not change
+// the CharSets variable.
+func CharSetPreviews() []CharSetPreview {
+	previews := make([]CharSetPreview, 0, len(CharSets))
+
+	for i, cs := range CharSets {
+		frames := make([]string, len(cs))
+		copy(frames, cs)
+
+		previews = append(previews, CharSetPreview{
+			Index:      i,
+			Frames:     frames,
+			FrameCount: len(frames),
+		})
+	}
+
+	sort.Slice(previews, func(i, j int) bool { return previews[i].Index < previews[j].Index })
+
+	return previews
+}
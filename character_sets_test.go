@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestCharSets(t *testing.T) {
@@ -17,3 +19,44 @@ func TestCharSets(t *testing.T) {
 		testErrCheck(t, name, "", err)
 	}
 }
+
+func TestCharSetPreviews(t *testing.T) {
+	previews := CharSetPreviews()
+
+	if len(previews) != len(CharSets) {
+		t.Fatalf("len(previews) = %d, want %d", len(previews), len(CharSets))
+	}
+
+	for i, p := range previews {
+		if p.Index != i {
+			t.Fatalf("previews[%d].Index = %d, want %d", i, p.Index, i)
+		}
+
+		if p.FrameCount != len(CharSets[i]) {
+			t.Errorf("previews[%d].FrameCount = %d, want %d", i, p.FrameCount, len(CharSets[i]))
+		}
+
+		if diff := cmp.Diff(CharSets[i], p.Frames); diff != "" {
+			t.Errorf("previews[%d].Frames differs: (-want +got)\n%s", i, diff)
+		}
+	}
+
+	previews[0].Frames[0] = "modified"
+
+	if CharSets[0][0] == "modified" {
+		t.Fatal("modifying preview frames modified CharSets")
+	}
+}
+
+func TestDefaultCharSet(t *testing.T) {
+	if diff := cmp.Diff(CharSets[DefaultCharSetIndex], DefaultCharSet); diff != "" {
+		t.Fatalf("DefaultCharSet differs: (-want / +got)\n%s", diff)
+	}
+
+	spinner, err := New(Config{Frequency: time.Second})
+	testErrCheck(t, "New()", "", err)
+
+	if diff := cmp.Diff(DefaultCharSet, spinner.Config().CharSet); diff != "" {
+		t.Fatalf("spinner.Config().CharSet differs: (-want / +got)\n%s", diff)
+	}
+}
@@ -7,15 +7,58 @@
 //
 // - made validColors set map more idiomatic with an empty struct value
 // - added a function for creating color functions from color list
+// - exported the color function builder as ColorFunc
+// - added hex colors, downsampled to the depth of a ColorProfile
 
 package yacspin
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/fatih/color"
 )
 
+// ColorProfile is the color depth supported by the terminal, which colors are
+// downsampled to. See the package constants for the list of all profiles.
+type ColorProfile uint8
+
+const (
+	// ColorProfileAuto configures the New() function to detect the color
+	// profile from the COLORTERM and TERM environment variables, when the
+	// TerminalMode is AutomaticMode. Otherwise, it's treated as
+	// ColorProfileTrueColor.
+	ColorProfileAuto ColorProfile = iota
+
+	// ColorProfileTrueColor prints hex colors using 24-bit color codes.
+	ColorProfileTrueColor
+
+	// ColorProfileANSI256 downsamples hex colors to the closest of the 256
+	// color palette.
+	ColorProfileANSI256
+
+	// ColorProfileANSI16 downsamples hex colors to the closest of the 16
+	// basic colors.
+	ColorProfileANSI16
+
+	// ColorProfileNoColor prints everything without colors.
+	ColorProfileNoColor
+)
+
+// detectColorProfile returns the ColorProfile supported by the terminal, based
+// on the values of the COLORTERM and TERM environment variables
+func detectColorProfile(colorTerm, term string) ColorProfile {
+	switch {
+	case colorTerm == "truecolor" || colorTerm == "24bit":
+		return ColorProfileTrueColor
+	case strings.Contains(term, "256color"):
+		return ColorProfileANSI256
+	default:
+		return ColorProfileANSI16
+	}
+}
+
 // ValidColors holds the list of the strings that are mapped to
 // github.com/fatih/color color attributes. Any of these colors / attributes can
 // be used with the *Spinner type, and it should be reflected in the output.
@@ -155,20 +198,212 @@ func validColor(c string) bool {
 	return ok
 }
 
-func colorFunc(colors ...string) (func(format string, a ...interface{}) string, error) {
+// ansi16Palette is the RGB value of each of the 16 basic colors, using the
+// xterm defaults
+var ansi16Palette = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// parseHexColor parses a hex color in the form of #rrggbb (foreground) or
+// bg#rrggbb (background)
+func parseHexColor(c string) (r, g, b int, bg, ok bool) {
+	if strings.HasPrefix(c, "bg#") {
+		bg, c = true, c[2:]
+	}
+
+	if len(c) != 7 || c[0] != '#' {
+		return 0, 0, 0, false, false
+	}
+
+	v, err := strconv.ParseUint(c[1:], 16, 32)
+	if err != nil {
+		return 0, 0, 0, false, false
+	}
+
+	return int(v >> 16 & 0xff), int(v >> 8 & 0xff), int(v & 0xff), bg, true
+}
+
+// cubeLevels are the values of each component in the 6x6x6 color cube of the
+// 256 color palette
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// rgbTo256 returns the closest color of the 256 color palette, being either a
+// color of the 6x6x6 cube or of the grayscale ramp
+func rgbTo256(r, g, b int) int {
+	// the cube levels aren't evenly spaced, so the closest of each component
+	// is found using the midpoints between them
+	cube := func(v int) int {
+		switch {
+		case v < 48:
+			return 0
+		case v < 115:
+			return 1
+		default:
+			return (v - 35) / 40
+		}
+	}
+
+	cr, cg, cb := cube(r), cube(g), cube(b)
+
+	// the grayscale ramp goes from 8 to 238, in steps of 10
+	gray := ((r+g+b)/3 - 3) / 10
+	if gray < 0 {
+		gray = 0
+	} else if gray > 23 {
+		gray = 23
+	}
+
+	dist := func(pr, pg, pb int) int {
+		dr, dg, db := r-pr, g-pg, b-pb
+		return dr*dr + dg*dg + db*db
+	}
+
+	gv := 8 + 10*gray
+
+	if dist(gv, gv, gv) < dist(cubeLevels[cr], cubeLevels[cg], cubeLevels[cb]) {
+		return 232 + gray
+	}
+
+	return 16 + 36*cr + 6*cg + cb
+}
+
+// rgbTo16 returns the index of the closest of the 16 basic colors
+func rgbTo16(r, g, b int) int {
+	var idx, best int
+
+	for i, p := range ansi16Palette {
+		dr, dg, db := r-p[0], g-p[1], b-p[2]
+
+		if d := dr*dr + dg*dg + db*db; i == 0 || d < best {
+			idx, best = i, d
+		}
+	}
+
+	return idx
+}
+
+// colorAttributes returns the github.com/fatih/color attributes for the color,
+// downsampling hex colors to the profile
+func colorAttributes(c string, profile ColorProfile) ([]color.Attribute, error) {
+	if validColor(c) {
+		return []color.Attribute{colorAttributeMap[c]}, nil
+	}
+
+	r, g, b, bg, ok := parseHexColor(c)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a valid color", c)
+	}
+
+	switch profile {
+	case ColorProfileANSI256:
+		base := color.Attribute(38)
+		if bg {
+			base = 48
+		}
+
+		return []color.Attribute{base, 5, color.Attribute(rgbTo256(r, g, b))}, nil
+
+	case ColorProfileANSI16, ColorProfileNoColor:
+		idx := rgbTo16(r, g, b)
+
+		code := color.FgBlack + color.Attribute(idx)
+		if idx >= 8 {
+			code = color.FgHiBlack + color.Attribute(idx-8)
+		}
+
+		if bg {
+			code += color.BgBlack - color.FgBlack
+		}
+
+		return []color.Attribute{code}, nil
+
+	default:
+		base := color.Attribute(38)
+		if bg {
+			base = 48
+		}
+
+		return []color.Attribute{base, 2, color.Attribute(r), color.Attribute(g), color.Attribute(b)}, nil
+	}
+}
+
+func colorFunc(profile ColorProfile, colors ...string) (func(format string, a ...interface{}) string, error) {
 	if len(colors) == 0 {
 		return fmt.Sprintf, nil
 	}
 
-	attrib := make([]color.Attribute, len(colors))
+	attrib := make([]color.Attribute, 0, len(colors))
 
-	for i, color := range colors {
-		if !validColor(color) {
-			return nil, fmt.Errorf("%s is not a valid color", color)
+	for _, color := range colors {
+		a, err := colorAttributes(color, profile)
+		if err != nil {
+			return nil, err
 		}
 
-		attrib[i] = colorAttributeMap[color]
+		attrib = append(attrib, a...)
+	}
+
+	if profile == ColorProfileNoColor {
+		return fmt.Sprintf, nil
 	}
 
 	return color.New(attrib...).SprintfFunc(), nil
 }
+
+// ColorFunc returns a function that formats its arguments like fmt.Sprintf,
+// and then colors the result using the provided colors. These are the same
+// color functions the *Spinner uses, so callers can colorize their own output
+// identically. The colors must be present in ValidColors, or be hex colors in
+// the form of #rrggbb (foreground) or bg#rrggbb (background), otherwise an
+// error is returned. Hex colors are printed using 24-bit color codes. If no
+// colors are provided, fmt.Sprintf is returned.
+func ColorFunc(colors ...string) (func(format string, a ...interface{}) string, error) {
+	return colorFunc(ColorProfileTrueColor, colors...)
+}
+
+// ColorsEnabled returns whether the spinner emits colors, which is useful for
+// deciding whether to add formatting of your own to the output. That's the case
+// if any colors are configured, like the Colors or StopColors, or the Message
+// was set using MessageSeverity(), and the output supports them. The output
+// doesn't support colors when writing to a dumb terminal, or not to a TTY
+// unless ForceColorInNoTTY is set, when the ColorProfile is
+// ColorProfileNoColor, or when colors are disabled globally using
+// color.NoColor from the github.com/fatih/color package (e.g., because the
+// NO_COLOR environment variable is set).
+func (s *Spinner) ColorsEnabled() bool {
+	if color.NoColor || s.colorProfile == ColorProfileNoColor {
+		return false
+	}
+
+	if !termModeForceSmart(s.termMode) && !s.forceColor {
+		return false
+	}
+
+	if s.loadMessage().colorFn != nil {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.colors) > 0 || s.suffixColorFn != nil || len(s.stopColors) > 0 || len(s.stopFailColors) > 0 {
+		return true
+	}
+
+	for _, o := range s.outcomes {
+		if len(o.colors) > 0 {
+			return true
+		}
+	}
+
+	for _, t := range s.percentThresholds {
+		if len(t.colors) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,10 +1,13 @@
 package yacspin
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
 )
 
 func Test_validColor(t *testing.T) {
@@ -94,7 +97,7 @@ func Test_colorFunc(t *testing.T) {
 				tfn = color.New(a...).SprintfFunc()
 			}
 
-			fn, err := colorFunc(tt.colors...)
+			fn, err := colorFunc(ColorProfileTrueColor, tt.colors...)
 
 			if cont := testErrCheck(t, "colorFunc()", tt.err, err); !cont {
 				return
@@ -112,3 +115,354 @@ func Test_colorFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestColorFunc(t *testing.T) {
+	tests := []struct {
+		name   string
+		colors []string
+		err    string
+	}{
+		{
+			name: "no_color",
+		},
+		{
+			name:   "colors",
+			colors: []string{"fgHiGreen", "bgRed"},
+		},
+		{
+			name:   "invalid_color",
+			colors: []string{"fgHiGreen", "invalid"},
+			err:    "invalid is not a valid color",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := ColorFunc(tt.colors...)
+
+			if cont := testErrCheck(t, "ColorFunc()", tt.err, err); !cont {
+				return
+			}
+
+			ifn, err := colorFunc(ColorProfileTrueColor, tt.colors...)
+			testErrCheck(t, "colorFunc()", "", err)
+
+			got, want := fn("%s: %d", "test value", 42), ifn("%s: %d", "test value", 42)
+
+			if got != want {
+				t.Fatalf(`fn("%%s: %%d", "test value", 42) = %q, want %q`, got, want)
+			}
+		})
+	}
+}
+
+func Test_colorAttributes(t *testing.T) {
+	tests := []struct {
+		name    string
+		color   string
+		profile ColorProfile
+		want    []color.Attribute
+		err     string
+	}{
+		{
+			name:    "named",
+			color:   "fgRed",
+			profile: ColorProfileANSI16,
+			want:    []color.Attribute{color.FgRed},
+		},
+		{
+			name:    "truecolor",
+			color:   "#ff8000",
+			profile: ColorProfileTrueColor,
+			want:    []color.Attribute{38, 2, 255, 128, 0},
+		},
+		{
+			name:    "truecolor_background",
+			color:   "bg#ff8000",
+			profile: ColorProfileTrueColor,
+			want:    []color.Attribute{48, 2, 255, 128, 0},
+		},
+		{
+			name:    "auto_is_truecolor",
+			color:   "#ff8000",
+			profile: ColorProfileAuto,
+			want:    []color.Attribute{38, 2, 255, 128, 0},
+		},
+		{
+			name:    "ansi256",
+			color:   "#ff0000",
+			profile: ColorProfileANSI256,
+			want:    []color.Attribute{38, 5, 196},
+		},
+		{
+			name:    "ansi256_gray",
+			color:   "bg#808080",
+			profile: ColorProfileANSI256,
+			want:    []color.Attribute{48, 5, 244},
+		},
+		{
+			name:    "ansi16",
+			color:   "#ff0000",
+			profile: ColorProfileANSI16,
+			want:    []color.Attribute{color.FgHiRed},
+		},
+		{
+			name:    "ansi16_dark",
+			color:   "#0a00c8",
+			profile: ColorProfileANSI16,
+			want:    []color.Attribute{color.FgBlue},
+		},
+		{
+			name:    "ansi16_background",
+			color:   "bg#00c800",
+			profile: ColorProfileANSI16,
+			want:    []color.Attribute{color.BgGreen},
+		},
+		{
+			name:    "invalid_hex",
+			color:   "#ff00zz",
+			profile: ColorProfileTrueColor,
+			err:     "#ff00zz is not a valid color",
+		},
+		{
+			name:    "short_hex",
+			color:   "#fff",
+			profile: ColorProfileTrueColor,
+			err:     "#fff is not a valid color",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := colorAttributes(tt.color, tt.profile)
+
+			if cont := testErrCheck(t, "colorAttributes()", tt.err, err); !cont {
+				return
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("colorAttributes() differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_rgbTo256(t *testing.T) {
+	tests := []struct {
+		name    string
+		r, g, b int
+		want    int
+	}{
+		{name: "black", want: 16},
+		{name: "white", r: 255, g: 255, b: 255, want: 231},
+		{name: "red", r: 255, want: 196},
+		{name: "cube_5f0000", r: 0x5f, want: 52},
+		{name: "cube_878700", r: 0x87, g: 0x87, want: 100},
+		{name: "cube_d7afff", r: 0xd7, g: 0xaf, b: 0xff, want: 183},
+		{name: "near_cube_level", r: 0x60, g: 0x01, b: 0x02, want: 52},
+		{name: "gray_ramp", r: 0x80, g: 0x80, b: 0x80, want: 244},
+		{name: "gray_ramp_first", r: 0x08, g: 0x08, b: 0x08, want: 232},
+		{name: "gray_ramp_last", r: 0xee, g: 0xee, b: 0xee, want: 255},
+		{name: "near_gray", r: 0x76, g: 0x78, b: 0x7a, want: 243},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rgbTo256(tt.r, tt.g, tt.b); got != tt.want {
+				t.Fatalf("rgbTo256(%d, %d, %d) = %d, want %d", tt.r, tt.g, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_colorFunc_profile(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	tests := []struct {
+		name    string
+		profile ColorProfile
+		want    string
+	}{
+		{
+			name:    "truecolor",
+			profile: ColorProfileTrueColor,
+			want:    "\x1b[38;2;255;0;0;1mtest\x1b[0m",
+		},
+		{
+			name:    "ansi256",
+			profile: ColorProfileANSI256,
+			want:    "\x1b[38;5;196;1mtest\x1b[0m",
+		},
+		{
+			name:    "ansi16",
+			profile: ColorProfileANSI16,
+			want:    "\x1b[91;1mtest\x1b[0m",
+		},
+		{
+			name:    "no_color",
+			profile: ColorProfileNoColor,
+			want:    "test",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := colorFunc(tt.profile, "#ff0000", "bold")
+			testErrCheck(t, "colorFunc()", "", err)
+
+			if got := fn("%s", "test"); got != tt.want {
+				t.Fatalf(`fn("%%s", "test") = %q, want %q`, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_detectColorProfile(t *testing.T) {
+	tests := []struct {
+		name      string
+		colorTerm string
+		term      string
+		want      ColorProfile
+	}{
+		{
+			name:      "truecolor",
+			colorTerm: "truecolor",
+			term:      "xterm-256color",
+			want:      ColorProfileTrueColor,
+		},
+		{
+			name:      "24bit",
+			colorTerm: "24bit",
+			term:      "xterm",
+			want:      ColorProfileTrueColor,
+		},
+		{
+			name: "256color",
+			term: "screen-256color",
+			want: ColorProfileANSI256,
+		},
+		{
+			name: "basic",
+			term: "xterm",
+			want: ColorProfileANSI16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectColorProfile(tt.colorTerm, tt.term); got != tt.want {
+				t.Fatalf("detectColorProfile(%q, %q) = %d, want %d", tt.colorTerm, tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpinner_ColorsEnabled(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		suffixColors []string
+		noColor      bool
+		severity     bool
+		want         bool
+	}{
+		{
+			name: "colors",
+			cfg:  Config{Colors: []string{"fgRed"}},
+			want: true,
+		},
+		{
+			name:         "suffix_colors",
+			suffixColors: []string{"fgRed"},
+			want:         true,
+		},
+		{
+			name: "stop_colors",
+			cfg:  Config{StopColors: []string{"fgGreen"}},
+			want: true,
+		},
+		{
+			name: "outcome_colors",
+			cfg: Config{
+				OutcomeCharacters: map[string]string{"skipped": "-"},
+				OutcomeColors:     map[string][]string{"skipped": {"fgYellow"}},
+			},
+			want: true,
+		},
+		{
+			name: "percent_color_thresholds",
+			cfg:  Config{PercentColorThresholds: []PercentColorThreshold{{Min: 0, Colors: []string{"fgRed"}}}},
+			want: true,
+		},
+		{
+			name:     "message_severity",
+			severity: true,
+			want:     true,
+		},
+		{
+			name: "no_colors_configured",
+		},
+		{
+			name:    "no_color",
+			cfg:     Config{Colors: []string{"fgRed"}},
+			noColor: true,
+		},
+		{
+			name: "no_color_profile",
+			cfg:  Config{Colors: []string{"fgRed"}, ColorProfile: ColorProfileNoColor},
+		},
+		{
+			name: "dumb_term",
+			cfg:  Config{Colors: []string{"fgRed"}, TerminalMode: ForceTTYMode | ForceDumbTerminalMode},
+		},
+		{
+			name: "not_tty",
+			cfg:  Config{Colors: []string{"fgRed"}, TerminalMode: ForceNoTTYMode | ForceDumbTerminalMode},
+		},
+		{
+			name: "not_tty_force_color",
+			cfg:  Config{Colors: []string{"fgRed"}, TerminalMode: ForceNoTTYMode | ForceDumbTerminalMode, ForceColorInNoTTY: true},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+			color.NoColor = tt.noColor
+
+			cfg := tt.cfg
+			cfg.Frequency = time.Second
+			cfg.Writer = &bytes.Buffer{}
+
+			if cfg.TerminalMode == 0 {
+				cfg.TerminalMode = termModeTTY
+			}
+
+			spinner, err := New(cfg)
+			testErrCheck(t, "New()", "", err)
+
+			if len(tt.suffixColors) > 0 {
+				testErrCheck(t, "spinner.SetSuffix()", "", spinner.SetSuffix(" ", tt.suffixColors...))
+			}
+
+			if tt.severity {
+				spinner.MessageSeverity(SeverityWarn, "msg")
+			}
+
+			if got := spinner.ColorsEnabled(); got != tt.want {
+				t.Fatalf("spinner.ColorsEnabled() = %t, want %t", got, tt.want)
+			}
+
+			if tt.severity {
+				// the colors no longer apply once the message is replaced
+				spinner.Message("msg")
+
+				if spinner.ColorsEnabled() {
+					t.Fatal("spinner.ColorsEnabled() = true after Message(), want false")
+				}
+			}
+		})
+	}
+}
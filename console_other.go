@@ -0,0 +1,8 @@
+//go:build !windows
+// +build !windows
+
+package yacspin
+
+// enableVirtualTerminal is a no-op on non-Windows platforms, as terminals
+// there process ANSI escape sequences natively.
+func enableVirtualTerminal(fd uintptr) error { return nil }
@@ -0,0 +1,17 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package yacspin
+
+import "golang.org/x/sys/unix"
+
+// consoleWidth returns the number of columns of the terminal referenced by
+// the file descriptor.
+func consoleWidth(fd uintptr) (int, error) {
+	ws, err := unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(ws.Col), nil
+}
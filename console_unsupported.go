@@ -0,0 +1,12 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package yacspin
+
+import "errors"
+
+// consoleWidth isn't supported on this platform, so it always returns an
+// error.
+func consoleWidth(fd uintptr) (int, error) {
+	return 0, errors.New("terminal width is not supported on this platform")
+}
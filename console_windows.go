@@ -0,0 +1,39 @@
+//go:build windows
+// +build windows
+
+package yacspin
+
+import "golang.org/x/sys/windows"
+
+// enableVirtualTerminal enables the processing of ANSI escape sequences
+// (ENABLE_VIRTUAL_TERMINAL_PROCESSING) for the console referenced by the file
+// descriptor. Modern Windows 10+ consoles support this, but it's not enabled
+// by default. An error is returned if the mode could not be set, in which case
+// the console should be treated as a dumb terminal.
+func enableVirtualTerminal(fd uintptr) error {
+	h := windows.Handle(fd)
+
+	var mode uint32
+
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return err
+	}
+
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING > 0 {
+		return nil
+	}
+
+	return windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+}
+
+// consoleWidth returns the number of columns of the visible window of the
+// console referenced by the file descriptor.
+func consoleWidth(fd uintptr) (int, error) {
+	var info windows.ConsoleScreenBufferInfo
+
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(fd), &info); err != nil {
+		return 0, err
+	}
+
+	return int(info.Window.Right-info.Window.Left) + 1, nil
+}
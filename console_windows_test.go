@@ -0,0 +1,25 @@
+//go:build windows
+// +build windows
+
+package yacspin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_enableVirtualTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "not_a_console"))
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+
+	defer func() { _ = f.Close() }()
+
+	// a regular file is not a console, so the attempt to get the console mode
+	// should fail and be reported to the caller
+	if err := enableVirtualTerminal(f.Fd()); err == nil {
+		t.Fatal("enableVirtualTerminal() error = <nil>, want non-nil")
+	}
+}
@@ -0,0 +1,100 @@
+package yacspin
+
+import "fmt"
+
+// SpinnerEventType is the type of a SpinnerEvent. See the package constants
+// for the list of all event types.
+type SpinnerEventType uint8
+
+const (
+	// SpinnerEventMessage is sent when the Message is updated
+	SpinnerEventMessage SpinnerEventType = iota
+
+	// SpinnerEventPercent is sent when the progress percentage is updated
+	SpinnerEventPercent
+
+	// SpinnerEventStop is sent when the spinner stops
+	SpinnerEventStop
+)
+
+func (t SpinnerEventType) String() string {
+	switch t {
+	case SpinnerEventMessage:
+		return "message"
+	case SpinnerEventPercent:
+		return "percent"
+	case SpinnerEventStop:
+		return "stop"
+	default:
+		return fmt.Sprintf("unknown (%d)", t)
+	}
+}
+
+// SpinnerEvent is a change to the state of the spinner, sent to the channels
+// returned by Subscribe().
+type SpinnerEvent struct {
+	// Type is the type of the event.
+	Type SpinnerEventType
+
+	// Message is the new Message for SpinnerEventMessage events, and the
+	// message printed when stopping for SpinnerEventStop events.
+	Message string
+
+	// Percent is the new progress percentage for SpinnerEventPercent events.
+	Percent float64
+
+	// Outcome is how the spinner stopped for SpinnerEventStop events, which is
+	// "stopped", "failed", or the name of the stop outcome.
+	Outcome string
+}
+
+// subscriberBuffer is the number of events buffered for each subscriber
+const subscriberBuffer = 16
+
+// Subscribe returns a channel receiving events about changes to the spinner,
+// like its Message being updated, for mirroring its state elsewhere (e.g., in
+// a GUI). Events are sent without blocking, so if the channel's buffer is
+// full the event is dropped rather than slowing down the spinner. The channel
+// is closed once the spinner stops, after the SpinnerEventStop event is sent.
+// Each call returns a new channel, and all of them receive the events.
+func (s *Spinner) Subscribe() <-chan SpinnerEvent {
+	ch := make(chan SpinnerEvent, subscriberBuffer)
+
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.subscribers = append(s.subscribers, ch)
+
+	return ch
+}
+
+// publish sends the event to the subscribers, dropping it for any whose
+// buffer is full.
+func (s *Spinner) publish(ev SpinnerEvent) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.sendEvent(ev)
+}
+
+// sendEvent is like publish(), except that the caller must hold notifyMu.
+func (s *Spinner) sendEvent(ev SpinnerEvent) {
+	for _, ch := range s.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// closeSubscribers closes and removes the channels of the subscribers.
+func (s *Spinner) closeSubscribers() {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	for _, ch := range s.subscribers {
+		close(ch)
+	}
+
+	s.subscribers = nil
+}
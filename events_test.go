@@ -0,0 +1,176 @@
+package yacspin
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSpinnerEventType_String(t *testing.T) {
+	tests := []struct {
+		typ  SpinnerEventType
+		want string
+	}{
+		{SpinnerEventMessage, "message"},
+		{SpinnerEventPercent, "percent"},
+		{SpinnerEventStop, "stop"},
+		{42, "unknown (42)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.typ.String(); got != tt.want {
+			t.Fatalf("SpinnerEventType(%d).String() = %q, want %q", tt.typ, got, tt.want)
+		}
+	}
+}
+
+func collectEvents(ch <-chan SpinnerEvent) []SpinnerEvent {
+	var events []SpinnerEvent
+
+	for ev := range ch {
+		events = append(events, ev)
+	}
+
+	return events
+}
+
+func TestSpinner_Subscribe(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    bool
+		outcome string
+		want    []SpinnerEvent
+	}{
+		{
+			name: "stop",
+			want: []SpinnerEvent{
+				{Type: SpinnerEventMessage, Message: "msg"},
+				{Type: SpinnerEventPercent, Percent: 42},
+				{Type: SpinnerEventMessage, Message: "msg more"},
+				{Type: SpinnerEventStop, Message: "stop", Outcome: "stopped"},
+			},
+		},
+		{
+			name: "stop_fail",
+			fail: true,
+			want: []SpinnerEvent{
+				{Type: SpinnerEventMessage, Message: "msg"},
+				{Type: SpinnerEventPercent, Percent: 42},
+				{Type: SpinnerEventMessage, Message: "msg more"},
+				{Type: SpinnerEventStop, Message: "fail", Outcome: "failed"},
+			},
+		},
+		{
+			name:    "stop_outcome",
+			outcome: "skipped",
+			want: []SpinnerEvent{
+				{Type: SpinnerEventMessage, Message: "msg"},
+				{Type: SpinnerEventPercent, Percent: 42},
+				{Type: SpinnerEventMessage, Message: "msg more"},
+				{Type: SpinnerEventStop, Message: "skip", Outcome: "skipped"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner, err := New(Config{
+				Writer:          &bytes.Buffer{},
+				CharSet:         []string{"y"},
+				StopMessage:     "stop",
+				StopFailMessage: "fail",
+				OutcomeMessages: map[string]string{"skipped": "skip"},
+				ShowCursor:      true,
+				TerminalMode:    termModeTTY,
+			})
+			testErrCheck(t, "New()", "", err)
+
+			sub1, sub2 := spinner.Subscribe(), spinner.Subscribe()
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Message("msg")
+			testErrCheck(t, "spinner.Percent()", "", spinner.Percent(42))
+			spinner.AppendMessage(" more")
+
+			switch {
+			case tt.fail:
+				testErrCheck(t, "spinner.StopFail()", "", spinner.StopFail())
+			case len(tt.outcome) > 0:
+				testErrCheck(t, "spinner.StopOutcome()", "", spinner.StopOutcome(tt.outcome))
+			default:
+				testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+			}
+
+			for i, sub := range []<-chan SpinnerEvent{sub1, sub2} {
+				if diff := cmp.Diff(tt.want, collectEvents(sub)); diff != "" {
+					t.Fatalf("subscriber %d events differ: (-want / +got)\n%s", i, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestSpinner_Subscribe_painter(t *testing.T) {
+	spinner, err := New(Config{
+		Writer:       &safeBuffer{},
+		Frequency:    10 * time.Millisecond,
+		CharSet:      []string{"y"},
+		StopMessage:  "stop",
+		ShowCursor:   true,
+		TerminalMode: termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
+
+	sub := spinner.Subscribe()
+
+	testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+	spinner.Message("msg")
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	want := []SpinnerEvent{
+		{Type: SpinnerEventMessage, Message: "msg"},
+		{Type: SpinnerEventStop, Message: "stop", Outcome: "stopped"},
+	}
+
+	if diff := cmp.Diff(want, collectEvents(sub)); diff != "" {
+		t.Fatalf("events differ: (-want / +got)\n%s", diff)
+	}
+}
+
+func TestSpinner_Subscribe_full(t *testing.T) {
+	spinner, err := New(Config{
+		Writer:       &bytes.Buffer{},
+		CharSet:      []string{"y"},
+		ShowCursor:   true,
+		TerminalMode: termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
+
+	sub := spinner.Subscribe()
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	// nothing is reading the events, so this must not block
+	for i := 0; i < 2*subscriberBuffer; i++ {
+		spinner.Message(fmt.Sprintf("msg %d", i))
+	}
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	events := collectEvents(sub)
+
+	if len(events) != subscriberBuffer {
+		t.Fatalf("len(events) = %d, want %d", len(events), subscriberBuffer)
+	}
+
+	// the oldest events are kept, and the rest dropped
+	if got, want := events[subscriberBuffer-1].Message, fmt.Sprintf("msg %d", subscriberBuffer-1); got != want {
+		t.Fatalf("last event Message = %q, want %q", got, want)
+	}
+}
@@ -69,19 +69,15 @@ func createSpinnerFromMethods() *yacspin.Spinner {
 		exitf("failed to set color: %v", err)
 	}
 
-	if err := s.StopColors("fgGreen"); err != nil {
-		exitf("failed to set stop colors: %v", err)
-	}
-
-	if err := s.StopFailColors("fgRed"); err != nil {
-		exitf("failed to set stop fail colors: %v", err)
+	err = s.SetStop(
+		yacspin.StopConfig{Character: "✓", Message: "done", Colors: []string{"fgGreen"}},
+		yacspin.StopConfig{Character: "✗", Message: "failed", Colors: []string{"fgRed"}},
+	)
+	if err != nil {
+		exitf("failed to set stop styles: %v", err)
 	}
 
 	s.Suffix(" ")
-	s.StopCharacter("✓")
-	s.StopMessage("done")
-	s.StopFailCharacter("✗")
-	s.StopFailMessage("failed")
 
 	return s
 }
@@ -8,9 +8,6 @@ require (
 	github.com/mattn/go-colorable v0.1.12
 	github.com/mattn/go-isatty v0.0.14
 	github.com/mattn/go-runewidth v0.0.13
-)
-
-require (
-	github.com/rivo/uniseg v0.2.0 // indirect
-	golang.org/x/sys v0.0.0-20210927094055-39ccf1dd6fa6 // indirect
+	github.com/rivo/uniseg v0.2.0
+	golang.org/x/sys v0.0.0-20210927094055-39ccf1dd6fa6
 )
@@ -0,0 +1,26 @@
+package yacspin
+
+import "strings"
+
+// Logger is the interface of the Logger Config field, used for printing the
+// output of the spinner when it's not writing to a TTY. It's implemented by
+// the *log.Logger type of the standard library, and by most logging libraries
+// (e.g., logrus, or zap's SugaredLogger).
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// logLines prints each line of b using the logger, without its line
+// terminator. Any trailing line that isn't terminated, like the one printed by
+// StopInline(), is printed as well.
+func logLines(logger Logger, b []byte) {
+	if len(b) == 0 {
+		return
+	}
+
+	lines := strings.TrimSuffix(string(b), "\n")
+
+	for _, line := range strings.Split(lines, "\n") {
+		logger.Printf("%s", strings.TrimSuffix(line, "\r"))
+	}
+}
@@ -0,0 +1,126 @@
+package yacspin
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// captureLogger is a Logger recording the lines it prints
+type captureLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *captureLogger) Printf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *captureLogger) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), l.lines...)
+}
+
+func TestSpinner_logger(t *testing.T) {
+	tests := []struct {
+		name       string
+		termMode   TerminalMode
+		wantLines  []string
+		wantWriter string
+	}{
+		{
+			name:      "not_tty",
+			termMode:  ForceNoTTYMode | ForceDumbTerminalMode,
+			wantLines: []string{"y msg", "z 100%", "log line", "y 100%", "v stop"},
+		},
+		{
+			name:       "tty",
+			termMode:   termModeTTY,
+			wantWriter: "\r\033[K\ry msg\r\033[K\rz 100%\r\033[K\rlog line\n\r\033[K\rz 100%\r\033[K\ry 100%\r\033[K\rv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			logger := &captureLogger{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.Logger = logger
+			cfg.Frequency = time.Hour
+			cfg.CharSet = []string{"y", "z"}
+
+			spinner := newTestSpinner(t, cfg)
+
+			if spinner.Config().Logger != Logger(logger) {
+				t.Fatal("spinner.Config().Logger is not the configured Logger")
+			}
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+			spinner.Message("100%")
+			spinner.Render()
+			testErrCheck(t, "spinner.LogMessage()", "", spinner.LogMessage("log line"))
+			spinner.Render()
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.wantLines, logger.Lines()); diff != "" {
+				t.Fatalf("logged lines differ: (-want / +got)\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.wantWriter, buf.String()); diff != "" {
+				t.Fatalf("writer output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_logLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "single_line",
+			input: "line\n",
+			want:  []string{"line"},
+		},
+		{
+			name:  "multiple_lines",
+			input: "one\ntwo\r\n\nthree\n",
+			want:  []string{"one", "two", "", "three"},
+		},
+		{
+			name:  "unterminated",
+			input: "one\ntwo",
+			want:  []string{"one", "two"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &captureLogger{}
+
+			logLines(logger, []byte(tt.input))
+
+			if diff := cmp.Diff(tt.want, logger.Lines()); diff != "" {
+				t.Fatalf("logged lines differ: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
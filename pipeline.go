@@ -0,0 +1,115 @@
+package yacspin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// PipelineStep is a single labeled step of a Pipeline.
+type PipelineStep struct {
+	// Label is the Message rendered while the step runs, and the message
+	// printed when it stops.
+	Label string
+
+	// Run is the function doing the work of the step. If it returns an error
+	// the spinner stops using StopFail(), and the rest of the steps are
+	// skipped.
+	Run func(ctx context.Context) error
+}
+
+// Pipeline runs a series of steps in order, animating a single *Spinner for
+// each of them. When a step returns, the spinner is stopped using Stop() or
+// StopFail() depending on the error it returned, before starting it again for
+// the next step. This means each step ends up on its own line, with the
+// currently running step animated below them.
+type Pipeline struct {
+	spinner *Spinner
+	steps   []PipelineStep
+}
+
+// NewPipeline returns a Pipeline that runs the steps using the provided
+// *Spinner. The spinner must not be running when Run() is called.
+func NewPipeline(spinner *Spinner, steps ...PipelineStep) *Pipeline {
+	return &Pipeline{
+		spinner: spinner,
+		steps:   steps,
+	}
+}
+
+// Add appends a step to the Pipeline, and returns the Pipeline to allow
+// chaining calls.
+func (p *Pipeline) Add(label string, run func(ctx context.Context) error) *Pipeline {
+	p.steps = append(p.steps, PipelineStep{Label: label, Run: run})
+
+	return p
+}
+
+// Run executes the steps in order. Before each step the spinner's Message and
+// StopMessage are set to the step's Label, and if the step fails the
+// StopFailMessage is set to the Label followed by the error. They're restored
+// to their previous values once Run returns. If a step fails, or the context
+// is done before a step starts, the remaining steps are skipped and the error
+// is returned. If a step panics, the spinner is stopped using StopFail()
+// before the panic continues.
+func (p *Pipeline) Run(ctx context.Context) error {
+	if p.spinner == nil {
+		return errors.New("pipeline spinner cannot be nil")
+	}
+
+	cfg := p.spinner.Config()
+
+	defer func() {
+		p.spinner.Message(cfg.Message)
+		p.spinner.StopMessage(cfg.StopMessage)
+		p.spinner.StopFailMessage(cfg.StopFailMessage)
+	}()
+
+	for _, step := range p.steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		p.spinner.Message(step.Label)
+		p.spinner.StopMessage(step.Label)
+
+		if err := p.spinner.Start(); err != nil {
+			return fmt.Errorf("failed to start spinner for step %q: %w", step.Label, err)
+		}
+
+		if err := p.runStep(ctx, step); err != nil {
+			p.spinner.StopFailMessage(fmt.Sprintf("%s: %s", step.Label, err))
+
+			if serr := p.spinner.StopFail(); serr != nil {
+				return fmt.Errorf("failed to stop spinner for step %q: %w", step.Label, serr)
+			}
+
+			return fmt.Errorf("step %q failed: %w", step.Label, err)
+		}
+
+		if err := p.spinner.Stop(); err != nil {
+			return fmt.Errorf("failed to stop spinner for step %q: %w", step.Label, err)
+		}
+	}
+
+	return nil
+}
+
+// runStep runs the step, stopping the spinner using StopFail() if it panics so
+// that it isn't left running
+func (p *Pipeline) runStep(ctx context.Context, step PipelineStep) error {
+	if step.Run == nil {
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			p.spinner.StopFailMessage(fmt.Sprintf("%s: panic: %v", step.Label, r))
+			_ = p.spinner.StopFail()
+
+			panic(r)
+		}
+	}()
+
+	return step.Run(ctx)
+}
@@ -0,0 +1,175 @@
+package yacspin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPipeline_Run(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name    string
+		failAt  int // step number to fail, 0 means none do
+		cancel  bool
+		err     string
+		wantRan []string
+		want    string
+	}{
+		{
+			name:    "success",
+			wantRan: []string{"one", "two", "three"},
+			want:    "v one\nv two\nv three\n",
+		},
+		{
+			name:    "failing_step",
+			failAt:  2,
+			err:     `step "two" failed: boom`,
+			wantRan: []string{"one", "two"},
+			want:    "v one\nx two: boom\n",
+		},
+		{
+			name:    "canceled",
+			cancel:  true,
+			err:     "context canceled",
+			wantRan: nil,
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &safeBuffer{}
+
+			cfg := testConfig(buf, ForceNoTTYMode|ForceDumbTerminalMode)
+			cfg.Message = ""
+			cfg.StopMessage = ""
+			cfg.Frequency = time.Second
+			cfg.StopFailCharacter = "x"
+
+			spinner := newTestSpinner(t, cfg)
+
+			var ran []string
+
+			step := func(label string, fail bool) func(context.Context) error {
+				return func(context.Context) error {
+					ran = append(ran, label)
+
+					if fail {
+						return errBoom
+					}
+
+					return nil
+				}
+			}
+
+			p := NewPipeline(spinner).
+				Add("one", step("one", tt.failAt == 1)).
+				Add("two", step("two", tt.failAt == 2)).
+				Add("three", step("three", tt.failAt == 3))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tt.cancel {
+				cancel()
+			}
+
+			err := p.Run(ctx)
+
+			if len(tt.err) > 0 {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("p.Run() error = %v, want %q", err, tt.err)
+				}
+
+				if tt.failAt > 0 && !errors.Is(err, errBoom) {
+					t.Fatalf("p.Run() error = %v, want it to wrap %v", err, errBoom)
+				}
+			} else {
+				testErrCheck(t, "p.Run()", "", err)
+			}
+
+			if st := spinner.Status(); st != SpinnerStopped {
+				t.Fatalf("spinner.Status() = %s, want %s", st, SpinnerStopped)
+			}
+
+			if diff := cmp.Diff(tt.wantRan, ran); diff != "" {
+				t.Fatalf("steps run differ: (-want / +got)\n%s", diff)
+			}
+
+			// whether the running line of a step gets painted before it stops
+			// depends on timing, so only compare the stop lines
+			var got string
+
+			for _, line := range strings.SplitAfter(buf.String(), "\n") {
+				if !strings.HasPrefix(line, "y ") {
+					got += line
+				}
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPipeline_Run_nilSpinner(t *testing.T) {
+	err := NewPipeline(nil).Add("one", nil).Run(context.Background())
+	testErrCheck(t, "p.Run()", "pipeline spinner cannot be nil", err)
+}
+
+func TestPipeline_Run_restoresMessages(t *testing.T) {
+	cfg := testConfig(&safeBuffer{}, ForceNoTTYMode|ForceDumbTerminalMode)
+	cfg.Frequency = time.Second
+	cfg.StopFailMessage = "failed"
+
+	spinner := newTestSpinner(t, cfg)
+
+	err := NewPipeline(spinner).
+		Add("one", func(context.Context) error { return nil }).
+		Add("two", func(context.Context) error { return errors.New("boom") }).
+		Run(context.Background())
+	testErrCheck(t, "p.Run()", `step "two" failed: boom`, err)
+
+	got := spinner.Config()
+
+	if got.Message != "msg" || got.StopMessage != "stop" || got.StopFailMessage != "failed" {
+		t.Fatalf("messages = %q, %q, %q, want %q, %q, %q", got.Message, got.StopMessage, got.StopFailMessage, "msg", "stop", "failed")
+	}
+}
+
+func TestPipeline_Run_panic(t *testing.T) {
+	buf := &safeBuffer{}
+
+	cfg := testConfig(buf, ForceNoTTYMode|ForceDumbTerminalMode)
+	cfg.Frequency = time.Second
+	cfg.StopFailCharacter = "x"
+
+	spinner := newTestSpinner(t, cfg)
+
+	p := NewPipeline(spinner).Add("one", func(context.Context) error { panic("boom") })
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Fatalf("recover() = %v, want %q", r, "boom")
+			}
+		}()
+
+		_ = p.Run(context.Background())
+	}()
+
+	if st := spinner.Status(); st != SpinnerStopped {
+		t.Fatalf("spinner.Status() = %s, want %s", st, SpinnerStopped)
+	}
+
+	if got := buf.String(); !strings.HasSuffix(got, "x one: panic: boom\n") {
+		t.Fatalf("output = %q, want the stop fail line", got)
+	}
+}
@@ -0,0 +1,83 @@
+package yacspin
+
+import "fmt"
+
+// Severity is the level of a message set using MessageSeverity(), which
+// determines the colors it's rendered with. See the package constants for the
+// list of all severities.
+type Severity uint8
+
+const (
+	// SeverityInfo is for informational messages, rendered in cyan by
+	// default
+	SeverityInfo Severity = iota
+
+	// SeverityWarn is for warnings, rendered in yellow by default
+	SeverityWarn
+
+	// SeverityError is for errors, rendered in red by default
+	SeverityError
+)
+
+func (l Severity) String() string {
+	switch l {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarn:
+		return "warn"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("unknown (%d)", l)
+	}
+}
+
+// defaultSeverityColors are the colors of each Severity, unless they're
+// overridden using the SeverityColors Config field
+var defaultSeverityColors = map[Severity][]string{
+	SeverityInfo:  {"fgCyan"},
+	SeverityWarn:  {"fgYellow"},
+	SeverityError: {"fgRed"},
+}
+
+// buildSeverityColors builds the color function of each Severity, using the
+// colors provided for it instead of the defaults if there are any
+func buildSeverityColors(colors map[Severity][]string, profile ColorProfile) (map[Severity]func(format string, a ...interface{}) string, error) {
+	for level := range colors {
+		if _, ok := defaultSeverityColors[level]; !ok {
+			return nil, fmt.Errorf("cfg.SeverityColors key %d is not a valid Severity", level)
+		}
+	}
+
+	fns := make(map[Severity]func(format string, a ...interface{}) string, len(defaultSeverityColors))
+
+	for level, c := range defaultSeverityColors {
+		if custom, ok := colors[level]; ok {
+			c = custom
+		}
+
+		colorFn, err := colorFunc(profile, c...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build %s severity color function: %w", level, err)
+		}
+
+		fns[level] = colorFn
+	}
+
+	return fns, nil
+}
+
+// MessageSeverity updates the Message displayed after the suffix, like
+// Message(), and renders it using the colors of the severity level. The
+// colors of each level can be configured using the SeverityColors Config
+// field. The colors are used until the Message is updated again using
+// Message() or MessageReset(), which render it without colors.
+//
+// The severity colors only apply when the ColorAll config parameter is false,
+// as otherwise the whole line is printed using the colors set by Colors().
+func (s *Spinner) MessageSeverity(level Severity, msg string) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.storeMessage(messageState{text: msg, colorFn: s.severityColorFns[level]})
+}
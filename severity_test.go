@@ -0,0 +1,107 @@
+package yacspin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSeverity_String(t *testing.T) {
+	tests := []struct {
+		level Severity
+		want  string
+	}{
+		{SeverityInfo, "info"},
+		{SeverityWarn, "warn"},
+		{SeverityError, "error"},
+		{42, "unknown (42)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.level.String(); got != tt.want {
+			t.Fatalf("Severity(%d).String() = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestNew_severityColors(t *testing.T) {
+	tests := []struct {
+		name   string
+		colors map[Severity][]string
+		err    string
+	}{
+		{
+			name:   "valid",
+			colors: map[Severity][]string{SeverityWarn: {"fgMagenta", "bold"}},
+		},
+		{
+			name:   "invalid_color",
+			colors: map[Severity][]string{SeverityError: {"bogus"}},
+			err:    "failed to build error severity color function: bogus is not a valid color",
+		},
+		{
+			name:   "invalid_severity",
+			colors: map[Severity][]string{42: {"fgRed"}},
+			err:    "cfg.SeverityColors key 42 is not a valid Severity",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(Config{
+				Writer:         &bytes.Buffer{},
+				SeverityColors: tt.colors,
+				TerminalMode:   termModeTTY,
+			})
+			testErrCheck(t, "New()", tt.err, err)
+		})
+	}
+}
+
+func TestSpinner_MessageSeverity(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	buf := &bytes.Buffer{}
+
+	spinner, err := New(Config{
+		Writer:         buf,
+		CharSet:        []string{"y"},
+		Suffix:         " ",
+		SeverityColors: map[Severity][]string{SeverityWarn: {"fgMagenta"}},
+		ShowCursor:     true,
+		TerminalMode:   termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	spinner.MessageSeverity(SeverityInfo, "info")
+	spinner.Render()
+	spinner.MessageSeverity(SeverityWarn, "warn")
+	spinner.Render()
+	spinner.MessageSeverity(SeverityError, "error")
+	spinner.Render()
+	spinner.Message("plain")
+	spinner.Render()
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	want := "\r\033[K\ry " + color.New(color.FgCyan).Sprint("info") +
+		"\r\033[K\ry " + color.New(color.FgMagenta).Sprint("warn") +
+		"\r\033[K\ry " + color.New(color.FgRed).Sprint("error") +
+		"\r\033[K\ry plain" +
+		"\r\033[K\r"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
+	}
+
+	wantColors := map[Severity][]string{SeverityWarn: {"fgMagenta"}}
+
+	if diff := cmp.Diff(wantColors, spinner.Config().SeverityColors); diff != "" {
+		t.Fatalf("Config().SeverityColors differs: (-want / +got)\n%s", diff)
+	}
+}
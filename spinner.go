@@ -45,20 +45,29 @@
 package yacspin
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/mattn/go-colorable"
 	"github.com/mattn/go-isatty"
 	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
 )
 
 type character struct {
@@ -66,7 +75,82 @@ type character struct {
 	Size  int
 }
 
-func setToCharSlice(ss []string) ([]character, int) {
+// stringWidth returns the width of str in terminal columns, measured using the
+// condition or the runewidth defaults if it's nil
+func stringWidth(cond *runewidth.Condition, str string) int {
+	if cond == nil {
+		return runewidth.StringWidth(str)
+	}
+
+	return cond.StringWidth(str)
+}
+
+// runeWidth returns the width of r in terminal columns, measured using the
+// condition or the runewidth defaults if it's nil
+func runeWidth(cond *runewidth.Condition, r rune) int {
+	if cond == nil {
+		return runewidth.RuneWidth(r)
+	}
+
+	return cond.RuneWidth(r)
+}
+
+// charWidth returns the width of the spinner character str in terminal columns,
+// measured like stringWidth() except that each grapheme cluster is at least one
+// column wide. This keeps frames containing combining marks without a base
+// character, which have no width of their own, from breaking the padding.
+func charWidth(cond *runewidth.Condition, str string) int {
+	var n int
+
+	g := uniseg.NewGraphemes(str)
+
+	for g.Next() {
+		w := stringWidth(cond, g.Str())
+		if w < 1 {
+			w = 1
+		}
+
+		n += w
+	}
+
+	return n
+}
+
+// AmbiguousWidth is how characters with an ambiguous East Asian width are
+// measured. See the package constants for the list of all values.
+type AmbiguousWidth uint8
+
+const (
+	// AmbiguousWidthAuto measures ambiguous-width characters using the
+	// runewidth package defaults, which are detected from the
+	// RUNEWIDTH_EASTASIAN and locale environment variables.
+	AmbiguousWidthAuto AmbiguousWidth = iota
+
+	// AmbiguousWidthNarrow measures ambiguous-width characters as being one
+	// column wide.
+	AmbiguousWidthNarrow
+
+	// AmbiguousWidthWide measures ambiguous-width characters as being two
+	// columns wide.
+	AmbiguousWidthWide
+)
+
+// eastAsianCondition returns the runewidth.Condition used for measuring the
+// width of characters, with ambiguous-width characters being measured as wide
+// if w is AmbiguousWidthWide. It returns nil for AmbiguousWidthAuto, so that
+// the runewidth defaults are used.
+func eastAsianCondition(w AmbiguousWidth) *runewidth.Condition {
+	if w == AmbiguousWidthAuto {
+		return nil
+	}
+
+	cond := runewidth.NewCondition()
+	cond.EastAsianWidth = w == AmbiguousWidthWide
+
+	return cond
+}
+
+func setToCharSlice(ss []string, cond *runewidth.Condition) ([]character, int) {
 	if len(ss) == 0 {
 		return nil, 0
 	}
@@ -75,7 +159,7 @@ func setToCharSlice(ss []string) ([]character, int) {
 	c := make([]character, len(ss))
 
 	for i, s := range ss {
-		n := runewidth.StringWidth(s)
+		n := charWidth(cond, s)
 		if n > maxWidth {
 			maxWidth = n
 		}
@@ -123,6 +207,16 @@ const (
 	// This includes printing of stylized text, and more better line erasure to
 	// animate the spinner.
 	ForceSmartTerminalMode
+
+	// ForceTestMode configures the spinner to operate as if it's running within
+	// a dumb terminal in a TTY session, animating at the Frequency, except that
+	// each frame is printed on its own line without any line erasure. The
+	// output contains no ANSI escape sequences or carriage returns, only the
+	// plain text of each frame followed by a newline, which makes it easy to
+	// compare against golden files in tests. This flag implies ForceTTYMode and
+	// ForceDumbTerminalMode, and can't be combined with ForceNoTTYMode or
+	// ForceSmartTerminalMode.
+	ForceTestMode
 )
 
 func termModeAuto(t TerminalMode) bool       { return t&AutomaticMode > 0 }
@@ -130,6 +224,7 @@ func termModeForceTTY(t TerminalMode) bool   { return t&ForceTTYMode > 0 }
 func termModeForceNoTTY(t TerminalMode) bool { return t&ForceNoTTYMode > 0 }
 func termModeForceDumb(t TerminalMode) bool  { return t&ForceDumbTerminalMode > 0 }
 func termModeForceSmart(t TerminalMode) bool { return t&ForceSmartTerminalMode > 0 }
+func termModeForceTest(t TerminalMode) bool  { return t&ForceTestMode > 0 }
 
 // Config is the configuration structure for the Spinner type, which you provide
 // to the New() function. Some of the fields can be updated after the *Spinner
@@ -145,6 +240,51 @@ type Config struct {
 	// defaults to os.Stdout.
 	Writer io.Writer
 
+	// Logger, if set, receives the output of the spinner instead of the Writer
+	// when it's not writing to a TTY (i.e., in ForceNoTTYMode). Each line
+	// written, like the status updates and the line printed when stopping, is
+	// passed to its Printf() method without the line terminator, so the lines
+	// get the formatting of the logger. It's ignored when writing to a TTY, as
+	// the animation relies on escape sequences. This can't be changed after the
+	// *Spinner has been constructed.
+	Logger Logger
+
+	// StickyBottom configures the spinner to render on the bottom line of the
+	// terminal, saving and restoring the cursor position around each frame so
+	// that other output written to the terminal is printed above it. When the
+	// spinner stops the bottom line is cleared, and the final line is printed
+	// at the cursor position. The SubMessage isn't rendered in this mode. This
+	// only has an effect in smart terminal mode, and can't be changed after the
+	// *Spinner has been constructed.
+	StickyBottom bool
+
+	// SingleWrite configures the spinner to serialize its writes with those of
+	// any other *Spinner writing to the same Writer that also has SingleWrite
+	// set, so that the frames of concurrent spinners don't interleave. Each
+	// frame is always written using a single call to Write. Writers that
+	// can't be compared, like a func or a struct holding a slice, aren't
+	// serialized. This can't be changed after the *Spinner has been
+	// constructed.
+	SingleWrite bool
+
+	// FlushAfterWrite configures the spinner to flush the Writer after each
+	// write, so that frames appear promptly when it's buffered (e.g., a
+	// *bufio.Writer) or a file. The Writer is flushed by calling its Flush()
+	// method, or its Sync() method if it doesn't have one, and nothing is done
+	// if it has neither. Errors returned by Flush() are handled like write
+	// errors, while those returned by Sync() are ignored, as it fails for
+	// terminals and pipes. This can't be changed after the *Spinner has been
+	// constructed.
+	FlushAfterWrite bool
+
+	// AltScreen configures the spinner to switch the terminal to its alternate
+	// screen buffer when started, and to switch back when stopped, restoring
+	// the user's scrollback. The final line printed when stopping is rendered
+	// after switching back, so that it remains visible. This only has an
+	// effect in smart terminal mode, and can't be changed after the *Spinner
+	// has been constructed.
+	AltScreen bool
+
 	// ShowCursor specifies that the cursor should be shown by the spinner while
 	// animating. If it is not shown, the cursor will be restored when the
 	// spinner stops. This can't be changed after the *Spinner has been
@@ -162,22 +302,76 @@ type Config struct {
 	// animated spinner at the beginning of the line.
 	SpinnerAtEnd bool
 
-	// ColorAll describes whether to color everything (all) or just the spinner
-	// character(s). This cannot be changed after the *Spinner has been
+	// AutoSpace configures the spinner to make sure there is exactly one space
+	// between the message and the spinner when SpinnerAtEnd is set to true,
+	// regardless of the spaces at the start of the Prefix or the end of the
+	// Message. If there is no message, the spaces at the start of the Prefix
+	// are removed. If SpinnerAtEnd is set to false, this option is ignored.
+	// This can't be changed after the *Spinner has been constructed.
+	AutoSpace bool
+
+	// TrimTrailingSpace configures the spinner to remove the whitespace at the
+	// end of each printed line, like the one left by a Suffix of " " when there
+	// is no message, which some tools flag. The spacing within the line is
+	// preserved, including the padding of the spinner character when it's not
+	// at the end of the line. This can't be changed after the *Spinner has been
 	// constructed.
+	TrimTrailingSpace bool
+
+	// ColorAll describes whether to color everything (all) or just the spinner
+	// character(s). See the SetColorAll() method for changing it after the
+	// *Spinner has been constructed.
 	ColorAll bool
 
 	// Colors are the colors used for the different printed messages. This
-	// respects the ColorAll field.
+	// respects the ColorAll field. Besides the ValidColors, hex colors in the
+	// form of #rrggbb (foreground) or bg#rrggbb (background) can be used,
+	// which are downsampled to the ColorProfile.
 	Colors []string
 
+	// ColorProfile is the color depth supported by the terminal, which hex
+	// colors are downsampled to. If not set, it's detected from the COLORTERM
+	// and TERM environment variables when the TerminalMode is AutomaticMode,
+	// otherwise hex colors are printed using 24-bit color codes. This can't be
+	// changed after the *Spinner has been constructed.
+	ColorProfile ColorProfile
+
 	// CharSet is the list of characters to iterate through to draw the spinner.
+	// If not set, it defaults to DefaultCharSet.
 	CharSet []string
 
+	// StartIndex is the index of the character within the CharSet to render
+	// first, which is useful for continuing the animation of a previous
+	// spinner. It defaults to 0, and must be less than the length of the
+	// CharSet.
+	StartIndex int
+
+	// ParallelChars is the number of spinner characters rendered side by side,
+	// with each one a frame further along in the CharSet than the one before
+	// it, producing a wave effect. It defaults to 1, can't be negative, and
+	// can't be changed after the *Spinner has been constructed.
+	ParallelChars int
+
+	// PadCharacter is the string used to pad the spinner characters narrower
+	// than the widest one, so that the rest of the line doesn't shift as the
+	// spinner animates. It must be a single column wide, and defaults to a
+	// space (` `). This can't be changed after the *Spinner has been
+	// constructed.
+	PadCharacter string
+
+	// Label is the string printed at the start of the line, before the
+	// Prefix, or before the Message if SpinnerAtEnd is set to true. Unlike the
+	// Message, which describes the current status of the work, the Label is
+	// meant for the name of the task, so it's shown on every frame and on the
+	// line printed when stopping. When using a Template, it's only rendered
+	// using the {label} placeholder. This can't be changed after the *Spinner
+	// has been constructed.
+	Label string
+
 	// Prefix is the string printed immediately before the spinner.
 	//
 	// If SpinnerAtEnd is set to true, it's recommended that this string start
-	// with a space character (` `).
+	// with a space character (` `), or that AutoSpace is set to true.
 	Prefix string
 
 	// Suffix is the string printed immediately after the spinner and before the
@@ -209,13 +403,116 @@ type Config struct {
 	// In this case, it may be preferred to set the Prefix to empty space (` `).
 	Message string
 
+	// SubMessage is rendered on a second line below the spinner, indented by
+	// two spaces, for showing details about the Message. It's not rendered on
+	// dumb terminals within a TTY, as they can't erase more than one line, or
+	// when the spinner stops.
+	SubMessage string
+
+	// Template overrides the default layout of the printed line, when not
+	// empty. It supports the following placeholders, which are replaced with
+	// their respective values: {label}, {spinner}, {message}, {prefix},
+	// {suffix}, {fraction}, {percent}, {elapsed}, and {remaining}. For
+	// example:
+	//
+	//    [{spinner}] {message}
+	//
+	// The {percent}, {elapsed}, and {remaining} placeholders are only
+	// populated if the ShowPercent, ShowElapsed, and ShowRemaining fields are
+	// set to true, and the {fraction} placeholder once a total has been set
+	// using the SetTotal() method. The SpinnerAtEnd and
+	// SuffixAutoColon fields are ignored when using a template. New() returns
+	// an error if the template contains an unknown placeholder.
+	Template string
+
+	// ShowPercent configures the spinner to render the completion percentage,
+	// as set by the Percent() method, after the message. This can't be changed
+	// after the *Spinner has been constructed.
+	ShowPercent bool
+
+	// PercentColorThresholds colors the completion percentage rendered when
+	// ShowPercent is set, based on how far along the progress is. The colors
+	// of the threshold with the highest Min reached by the percentage are used
+	// (e.g., red from 0, yellow from 30, and green from 70), and the
+	// percentage isn't colored while it's below all of them. The thresholds
+	// don't need to be sorted. Like the suffix colors, they only apply when
+	// the ColorAll field is false. This can't be changed after the *Spinner
+	// has been constructed.
+	PercentColorThresholds []PercentColorThreshold
+
+	// PercentColorAll configures the PercentColorThresholds to color the
+	// whole line, as if ColorAll were set to true, instead of only the
+	// percentage. It applies while a threshold has been reached, and can't be
+	// changed after the *Spinner has been constructed.
+	PercentColorAll bool
+
+	// ShowElapsed configures the spinner to render the amount of time elapsed
+	// since the spinner was started after the message (and percentage, if
+	// shown). This can't be changed after the *Spinner has been constructed.
+	ShowElapsed bool
+
+	// ShowRemaining configures the spinner to render the amount of time left
+	// until the deadline set by the Deadline() method after the message (and
+	// percentage and elapsed time, if shown). Once the deadline has passed,
+	// this renders as 0s. This can't be changed after the *Spinner has been
+	// constructed.
+	ShowRemaining bool
+
+	// ProgressUnit is the unit rendered after the current and total amounts,
+	// as set by the SetCurrent() and SetTotal() methods, like 12MB/100MB for
+	// the "MB" unit. If set to "bytes", the amounts are instead humanized
+	// using binary prefixes, like 1.5KB/2MB. The amounts are only rendered
+	// once a total has been set. This can't be changed after the *Spinner has
+	// been constructed.
+	ProgressUnit string
+
+	// Timestamp configures the spinner to prefix each rendered line, including
+	// the final line printed when stopping, with the current time. This is
+	// useful for log-like output. This can't be changed after the *Spinner has
+	// been constructed.
+	Timestamp bool
+
+	// TimestampFormat is the layout, as understood by time.Time.Format(), used
+	// to render the timestamp when the Timestamp field is set to true. Defaults
+	// to "15:04:05" if empty.
+	TimestampFormat string
+
+	// EmitOSCProgress configures the spinner to emit OSC 9;4 escape sequences
+	// reflecting the percentage set by the Percent() method, which some
+	// terminals (e.g., Windows Terminal and ConEmu) use to drive a taskbar
+	// progress indicator. The indicator is cleared when the spinner stops. This
+	// only has an effect in smart terminal mode, and can't be changed after the
+	// *Spinner has been constructed.
+	EmitOSCProgress bool
+
 	// StopMessage is the message used when Stop() is called.
 	StopMessage string
 
+	// StopMessageURL is a URL the StopMessage links to, using an OSC 8
+	// hyperlink escape sequence, which supporting terminals render as a
+	// clickable link (e.g., to a build log). This only has an effect in smart
+	// terminal mode, and can't be changed after the *Spinner has been
+	// constructed.
+	StopMessageURL string
+
+	// StopLogger is called with the plain text of the final line printed when
+	// the spinner stops, without any colors or escape sequences, for recording
+	// the outcome elsewhere (e.g., to a structured logger). It's called for
+	// Stop(), StopFail(), and their variants, after the line has been
+	// printed and before they return. This can't be changed after the
+	// *Spinner has been constructed.
+	StopLogger func(line string)
+
 	// StopCharacter is spinner character used when Stop() is called.
 	// Recommended character is ✓, and can be more than just one character.
 	StopCharacter string
 
+	// StopCharacterFallback is used instead of the StopCharacter on dumb
+	// terminals, which may not be able to render it (e.g., "OK" instead of
+	// ✓). If empty, the StopCharacter is always used. This can't be changed
+	// after the *Spinner has been constructed.
+	StopCharacterFallback string
+
 	// StopColors are the colors used for the Stop() printed line. This respects
 	// the ColorAll field.
 	StopColors []string
@@ -228,10 +525,51 @@ type Config struct {
 	// character.
 	StopFailCharacter string
 
+	// StopFailCharacterFallback is used instead of the StopFailCharacter on
+	// dumb terminals, which may not be able to render it (e.g., "FAIL" instead
+	// of ✗). If empty, the StopFailCharacter is always used. This can't be
+	// changed after the *Spinner has been constructed.
+	StopFailCharacterFallback string
+
 	// StopFailColors are the colors used for the StopFail() printed line. This
 	// respects the ColorAll field.
 	StopFailColors []string
 
+	// BellOnStop configures the spinner to ring the terminal bell (\a) when
+	// Stop() is called, as an audible cue that the work finished. This has no
+	// effect when not running within a TTY, and can't be changed after the
+	// *Spinner has been constructed.
+	BellOnStop bool
+
+	// BellOnStopFail configures the spinner to ring the terminal bell (\a)
+	// when StopFail() is called. This has no effect when not running within a
+	// TTY, and can't be changed after the *Spinner has been constructed.
+	BellOnStopFail bool
+
+	// StopFailBlink configures the StopFailCharacter to blink when StopFail()
+	// is called, regardless of the StopFailColors. Only the character blinks,
+	// not the rest of the line. This has no effect on dumb terminals, and
+	// can't be changed after the *Spinner has been constructed.
+	StopFailBlink bool
+
+	// OutcomeCharacters, OutcomeMessages, and OutcomeColors define additional
+	// named stop outcomes, beyond success and failure, used when the
+	// StopOutcome() method is called. Each map is keyed by the outcome's name
+	// (e.g., "warn"), and an outcome is defined if its name is present in any
+	// of them. These are the equivalents of the StopCharacter, StopMessage,
+	// and StopColors fields, and can't be changed after the *Spinner has been
+	// constructed.
+	OutcomeCharacters map[string]string
+	OutcomeMessages   map[string]string
+	OutcomeColors     map[string][]string
+
+	// SeverityColors overrides the colors the Message is rendered with when
+	// it's set using MessageSeverity(), for each of the severity levels. By
+	// default SeverityInfo is cyan, SeverityWarn is yellow, and SeverityError
+	// is red. Levels not present in the map keep their default colors. This
+	// can't be changed after the *Spinner has been constructed.
+	SeverityColors map[Severity][]string
+
 	// TerminalMode is a bitflag field to control how the internal TTY / "dumb
 	// terminal" detection works, to allow consumers to override the internal
 	// behaviors. To set this value, it's recommended to use the TerminalMode
@@ -244,8 +582,10 @@ type Config struct {
 	//
 	// When in AutomaticMode, the New() function attempts to determine if the
 	// current application is running within an interactive (teletype [TTY])
-	// session. If it does not appear to be within a TTY, it sets this field
-	// value to ForceNoTTYMode | ForceDumbTerminalMode.
+	// session, by checking whether the Writer is a terminal. If it does not
+	// appear to be within a TTY, it sets this field value to ForceNoTTYMode |
+	// ForceDumbTerminalMode. Writers without a file descriptor (an Fd()
+	// method, like *os.File has) are never considered to be a TTY.
 	//
 	// If this does appear to be a TTY, the ForceTTYMode bitflag will bet set.
 	// Similarly, if it's a TTY and the TERM environment variable isn't set to
@@ -253,6 +593,177 @@ type Config struct {
 	//
 	// This field replaced the now removed NotTTY field.
 	TerminalMode TerminalMode
+
+	// TermEnv overrides the value of the TERM environment variable used when
+	// determining whether this is a dumb terminal in AutomaticMode. This allows
+	// forcing the detection without modifying the process environment. If
+	// empty, the TERM environment variable is used.
+	TermEnv string
+
+	// TTYDetector overrides how the spinner determines whether it's running
+	// within a TTY in AutomaticMode, which by default checks whether the
+	// file descriptor of the Writer is a terminal. This is useful in
+	// environments where that check is wrong, like with some pseudo-terminals
+	// or remote agents. It's called once by New(). If it returns true, the
+	// TermEnv or TERM environment variable still determines whether it's a
+	// dumb terminal.
+	TTYDetector func() bool
+
+	// DumbEraseNewline configures the spinner to not erase the previous frame
+	// on dumb terminals within a TTY, by overwriting it with spaces between
+	// carriage returns, and to instead print each frame on a new line. This
+	// avoids odd carriage return behaviors in some logs, at the cost of
+	// leaving every frame in the output. This can't be changed after the
+	// *Spinner has been constructed.
+	DumbEraseNewline bool
+
+	// DataUpdateBuffer is the number of data update notifications (e.g., from
+	// calling Message()) that can be queued for the spinner to render. If an
+	// update happens while the queue is full, it's not rendered immediately
+	// and instead shows up in the next animation frame. Increasing this can
+	// help callers that update data very frequently. If not set, this
+	// defaults to 1. It can't be negative, and can't be changed after the
+	// *Spinner has been constructed.
+	DataUpdateBuffer int
+
+	// SilentWhenNotTTY configures the spinner to not render any animation or
+	// data updates when it's not running within a TTY (ForceNoTTYMode), only
+	// printing the final line when Stop() or StopFail() is called. This can't
+	// be changed after the *Spinner has been constructed.
+	SilentWhenNotTTY bool
+
+	// HeartbeatInterval configures the spinner to print a line periodically
+	// when it's not running within a TTY (ForceNoTTYMode), if nothing else was
+	// printed during the interval, so that the logs of long running
+	// operations (e.g., in CI) show they're still making progress. The line
+	// is the current frame, or the HeartbeatMessage if it's set. If not set,
+	// no heartbeat is printed. It can't be negative, and can't be changed
+	// after the *Spinner has been constructed.
+	HeartbeatInterval time.Duration
+
+	// HeartbeatMessage is printed on its own line for each heartbeat, like a
+	// message printed with LogMessage(), instead of the current frame. This
+	// can't be changed after the *Spinner has been constructed.
+	HeartbeatMessage string
+
+	// MaxLineLength is the maximum width, in terminal columns, of the printed
+	// line. Lines exceeding it are truncated, with the end replaced by an
+	// ellipsis (…), which prevents long lines from wrapping and breaking the
+	// erasure of the previous line. Colors are preserved when truncating. If
+	// not set, lines aren't truncated. It can't be negative, and can't be
+	// changed after the *Spinner has been constructed.
+	MaxLineLength int
+
+	// MessageWidth is the width, in terminal columns, of the message column of
+	// the animation. Shorter messages are padded with spaces, and longer ones
+	// truncated with an ellipsis (…), so anything rendered after the message
+	// (e.g., the spinner when SpinnerAtEnd is set) stays aligned. If not set,
+	// the message is rendered as-is. It can't be negative. See the
+	// SetMessageWidth() and SetMessageWidthFromTerm() methods for changing it
+	// later.
+	MessageWidth int
+
+	// EastAsianWidth configures how the spinner measures characters with an
+	// ambiguous East Asian width (e.g., some box-drawing characters and
+	// symbols), being either one or two columns wide. This is used for all
+	// width calculations, like the padding of the spinner characters and the
+	// truncation of lines, and should match how the terminal renders them to
+	// keep the output aligned. The default, AmbiguousWidthAuto, uses the
+	// runewidth package detection the same as RenderFrame(). This can't be
+	// changed after the *Spinner has been constructed.
+	EastAsianWidth AmbiguousWidth
+
+	// JSONMode configures the spinner to write a JSON object per line, instead
+	// of the rendered text, when it's not running within a TTY
+	// (ForceNoTTYMode). This is useful when the output is consumed by another
+	// process. Each object looks like:
+	//
+	//    {"status":"running","message":"msg","elapsed_ms":1234}
+	//
+	// When stopping, the status is "stopped", "failed", or the name of the
+	// stop outcome, messages printed with LogMessage() have the status "log",
+	// and lines printed with Complete() have the status "completed". This
+	// can't be changed after the *Spinner has been constructed.
+	JSONMode bool
+
+	// ForceColorInNoTTY configures the spinner to keep printing colors when
+	// it's not running within a TTY (ForceNoTTYMode), which are otherwise
+	// left out. This is useful in CI systems, like GitHub Actions, that
+	// aren't TTYs but do render ANSI colors in their logs. It has no effect on
+	// dumb terminals, and can't be changed after the *Spinner has been
+	// constructed.
+	ForceColorInNoTTY bool
+
+	// NoTTYFormat formats each line printed when the spinner is not running
+	// within a TTY (ForceNoTTYMode), including log messages, heartbeats,
+	// completed steps, and the final line printed when stopping. It's called
+	// with the rendered line, without the newline, and returns the line to
+	// print instead, which is useful for adding a log level or other
+	// decoration. If nil, the rendered line is printed as is. It's not used
+	// with JSONMode. This can't be changed after the *Spinner has been
+	// constructed.
+	NoTTYFormat func(message string) string
+
+	// PreserveIndexOnStop configures the spinner to not reset its animation
+	// to the first character when stopped, so that starting it again continues
+	// the animation where it left off. This can't be changed after the
+	// *Spinner has been constructed.
+	PreserveIndexOnStop bool
+
+	// CycleOnce configures the spinner to stop itself, as if Stop() was
+	// called, once it has animated one full cycle through its CharSet. This is
+	// useful for brief transitions. This can't be changed after the *Spinner
+	// has been constructed.
+	CycleOnce bool
+
+	// IdempotentStart configures the Start() and StartManual() methods to
+	// return nil, instead of an error, if the spinner is already running or
+	// paused. This can't be changed after the *Spinner has been constructed.
+	IdempotentStart bool
+
+	// Newline is the line terminator written after the final line when the
+	// spinner stops, and after each line when not running within a TTY. Use
+	// "\r\n" for viewers that expect Windows-style line endings. If empty, it
+	// defaults to "\n". This can't be changed after the *Spinner has been
+	// constructed.
+	Newline string
+
+	// OnFrame, if set, is called after each frame of the spinner is rendered
+	// with the index of the character within the CharSet that was rendered.
+	// It's called from the goroutine rendering the spinner, so it should
+	// return quickly. It must not call methods of the *Spinner that stop it.
+	// This can't be changed after the *Spinner has been constructed.
+	OnFrame func(index int)
+
+	// MaxRedrawRate caps how often the spinner writes frames to the Writer,
+	// regardless of the Frequency or how often data is updated, which can
+	// help on slow connections (e.g., SSH). Frames rendered more often are
+	// coalesced, with only the latest one being written. The last frame is
+	// always written before the spinner stops. If not set, there is no cap.
+	// This can't be changed after the *Spinner has been constructed.
+	MaxRedrawRate time.Duration
+
+	// InitialDelay is how long the spinner waits after being started before
+	// painting the first frame, including frames for data updates, so that
+	// operations finishing sooner don't flash the spinner on the screen. The
+	// line printed when stopping is always printed. It's not used when the
+	// spinner is started using StartManual(). It can't be negative, and can't
+	// be changed after the *Spinner has been constructed.
+	InitialDelay time.Duration
+
+	// PostStopDelay is how long the methods stopping the spinner, like Stop()
+	// and StopFail(), wait after printing the final line before returning, so
+	// that the user has a chance to read it before the program moves on (e.g.,
+	// by exiting and clearing the screen). It can't be negative, and can't be
+	// changed after the *Spinner has been constructed.
+	PostStopDelay time.Duration
+
+	// MaxWriteErrors is the number of consecutive failed writes to the Writer
+	// after which the spinner stops itself. When set, write failures no longer
+	// cause a panic, and the last error is available from the LastError()
+	// method. If not set, a failed write panics. It can't be negative, and
+	// can't be changed after the *Spinner has been constructed.
+	MaxWriteErrors int
 }
 
 // Spinner is a type representing an animated CLi terminal spinner. The Spinner
@@ -267,40 +778,276 @@ type Config struct {
 // the terminal. Otherwise, after the program exits the cursor will be hidden
 // and the user will need to `reset` their terminal.
 type Spinner struct {
+	// counters accessed atomically; they must be at the start of the struct
+	// to guarantee 64-bit alignment on 32-bit platforms
+	framesRendered     uint64
+	dataUpdates        uint64
+	dataUpdatesDropped uint64
+	dataVersion        uint64 // incremented by notifyDataChange(), invalidating the segments
+	pausedAt           int64  // UnixNano when the spinner was paused, zero if not paused
+	pausedFor          int64  // total nanoseconds paused, not including pausedAt
+
 	writer          io.Writer
 	buffer          *bytes.Buffer
-	colorAll        bool
 	cursorHidden    bool
 	suffixAutoColon bool
+	autoSpace       bool
+	trimTrailing    bool
 	termMode        TerminalMode
-	spinnerAtEnd    bool
+	showPercent     bool
+	percentColorAll bool
+	showElapsed     bool
+	showRemaining   bool
+	progressUnit    string
+	emitOSCProgress bool
+	dataUpdateBuf   int
+	silent          bool // not a TTY, and only the final line should be printed
+	heartbeatEvery  time.Duration
+	heartbeatMsg    string
+	jsonMode        bool // not a TTY, and lines should be written as JSON
+	forceColor      bool // not a TTY, and colors should be printed anyway
+	noTTYFormat     func(message string) string
+	maxRedrawRate   time.Duration
+	initialDelay    time.Duration
+	postStopDelay   time.Duration
+	altScreen       bool
+	timestampFormat string // empty if timestamps aren't shown
+	maxWriteErrors  int
+	flushAfterWrite bool
+	parallelChars   int
+	padChar         string // empty means " "
+	label           string // printed at the start of the line, if not empty
+	maxLineLength   int
+	width           *runewidth.Condition // measures character widths; nil uses the runewidth defaults
+	fd              uintptr              // file descriptor of the writer, if hasFd
+	hasFd           bool                 // the writer has a file descriptor
+	colorProfile    ColorProfile         // ColorProfileAuto is treated as ColorProfileTrueColor
+	stopFailBlink   bool
+	stopMessageURL  string
+	stopLogger      func(line string)
+	logger          Logger // replaces the writer in no-TTY mode, if set
+	idempotentStart bool
+	cycleOnce       bool
+	preserveIndex   bool
+	bellOnStop      bool
+	bellOnStopFail  bool
+	newline         string // empty means "\n"
+	eraseNewline    bool   // dumb terminals print frames on new lines instead of erasing
+	onFrame         func(index int)
+	stickyBottom    bool
+	singleWrite     bool
+	writeKey        interface{}      // keys the lock shared with other spinners; nil if not shared
+	logCh           chan logEntry    // log messages for the painter to print
+	snapshotCh      chan chan []byte // snapshot requests for the painter
+
+	stopCharFallback     character // used on dumb terminals, if not empty
+	stopFailCharFallback character // used on dumb terminals, if not empty
 
 	status       *uint32
 	lastPrintLen int
+	lastSubLine  bool          // the last frame written included the sub message line
 	cancelCh     chan struct{} // send: Stop(), close: StopFail(); both stop painter
 	doneCh       chan struct{}
+	abandonedCh  chan struct{} // doneCh of a painter TryStop() gave up on; guarded by the mutex
+	renderedCh   chan struct{} // closed once the first frame is written
+	rendered     bool          // renderedCh has been closed
 	pauseCh      chan struct{}
 	unpauseCh    chan struct{}
 	unpausedCh   chan struct{}
+	erasedCh     chan struct{} // closed once the painter erased the frame for PauseRendering()
+	manual       bool          // started with StartManual(); no painter goroutine
+	renderPaused bool          // paused using PauseRendering(); set before informing the painter
+	writeErrors  int           // consecutive failed writes
+	cycleFrames  int           // frames animated, for CycleOnce
+
+	// frame held back by the painter due to maxRedrawRate
+	lastWrite       time.Time
+	pending         []byte
+	pendingPrintLen int
+	pendingSubLine  bool
+
+	// colored segments of the previous frames, reused by the painter while
+	// the data of the spinner doesn't change
+	segments segmentCache
+
+	// writers added using AddWriter() or AddPlainWriter(), which have their
+	// own mutex as write() may be called while holding the mutex below
+	writersMu sync.Mutex
+	writers   []addedWriter
+
+	// the message is swapped by its setters and read by the painter without
+	// the mutex below, so that updating it never waits for a frame to be built
+	message atomic.Value // *messageState
+
+	// notifying the painter and the subscribers of changes has its own mutex,
+	// as the message setters do it without holding the mutex below; it also
+	// serializes the message setters, so their events are sent in the order
+	// the updates are applied
+	notifyMu     sync.Mutex
+	subscribers  []chan SpinnerEvent
+	dataUpdateCh chan struct{}
 
 	// mutex hat and the fields wearing it
 	mu                *sync.Mutex
 	frequency         time.Duration
+	speed             float64 // multiplier of the animation speed; 0 means 1
 	chars             []character
 	maxWidth          int
 	index             int
+	backward          bool
+	frozen            bool
+	hidden            bool // render only the message; see HideSpinner()
 	prefix            string
 	suffix            string
-	message           string
+	subMessage        string
+	percent           float64
+	percentSet        bool
+	current           int64
+	total             int64 // current/total isn't rendered if 0
+	startTime         time.Time
+	deadline          time.Time
+	colorAll          bool
+	spinnerAtEnd      bool
+	colors            []string // used to build colorFn, for Config()
 	colorFn           func(format string, a ...interface{}) string
+	suffixColorFn     func(format string, a ...interface{}) string
+	template          string
 	stopMsg           string
 	stopChar          character
+	stopColors        []string // used to build stopColorFn, for Config()
 	stopColorFn       func(format string, a ...interface{}) string
 	stopFailMsg       string
 	stopFailChar      character
+	stopFailColors    []string // used to build stopFailColorFn, for Config()
 	stopFailColorFn   func(format string, a ...interface{}) string
+	outcomes          map[string]stopOutcome
+	severityColors    map[Severity][]string // as configured, for Config()
+	percentThresholds []percentThreshold    // sorted by min
+	severityColorFns  map[Severity]func(format string, a ...interface{}) string
+	stopOutcome       string  // name of the outcome being stopped with, if any
+	stopPrint         *string // printed verbatim instead of the stop line, if set
+	stopStyle         stopStyle
+	lastErr           error
+	msgFrames         []string // rendered instead of the message, if set
+	msgFrequency      time.Duration
+	msgIndex          int
+	messageWidth      int // pad or truncate the message to this width, if not 0
 	frequencyUpdateCh chan time.Duration
-	dataUpdateCh      chan struct{}
+	msgFrequencyCh    chan time.Duration
+}
+
+// messageState is the Message, along with the function used to color it
+type messageState struct {
+	text    string
+	colorFn func(format string, a ...interface{}) string // set by MessageSeverity(); nil if not colored
+}
+
+// stopOutcome is the character, message, and color function used for a named
+// stop outcome
+type stopOutcome struct {
+	char    character
+	msg     string
+	colors  []string // used to build colorFn, for Config()
+	colorFn func(format string, a ...interface{}) string
+}
+
+func buildOutcomes(chars, msgs map[string]string, colors map[string][]string, cond *runewidth.Condition, profile ColorProfile) (map[string]stopOutcome, error) {
+	outcomes := make(map[string]stopOutcome)
+
+	for name, char := range chars {
+		o := outcomes[name]
+		o.char = character{Value: char, Size: charWidth(cond, char)}
+		outcomes[name] = o
+	}
+
+	for name, msg := range msgs {
+		o := outcomes[name]
+		o.msg = msg
+		outcomes[name] = o
+	}
+
+	for name, c := range colors {
+		colorFn, err := colorFunc(profile, c...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build %q outcome color function: %w", name, err)
+		}
+
+		o := outcomes[name]
+		o.colors = c
+		o.colorFn = colorFn
+		outcomes[name] = o
+	}
+
+	for name, o := range outcomes {
+		if len(name) == 0 {
+			return nil, errors.New("outcome names must not be empty")
+		}
+
+		if o.colorFn == nil {
+			o.colorFn = fmt.Sprintf
+			outcomes[name] = o
+		}
+	}
+
+	return outcomes, nil
+}
+
+// PercentColorThreshold is the colors used for the completion percentage once
+// it reaches Min. It's used with the PercentColorThresholds Config field.
+type PercentColorThreshold struct {
+	Min    float64
+	Colors []string
+}
+
+// percentThreshold is a PercentColorThreshold, and its color function
+type percentThreshold struct {
+	min     float64
+	colors  []string
+	colorFn func(format string, a ...interface{}) string
+}
+
+// buildPercentThresholds builds the color function of each threshold, and
+// returns them sorted by their Min
+func buildPercentThresholds(thresholds []PercentColorThreshold, profile ColorProfile) ([]percentThreshold, error) {
+	if len(thresholds) == 0 {
+		return nil, nil
+	}
+
+	pts := make([]percentThreshold, 0, len(thresholds))
+
+	for i, t := range thresholds {
+		if math.IsNaN(t.Min) {
+			return nil, fmt.Errorf("cfg.PercentColorThresholds[%d].Min must be a number", i)
+		}
+
+		colorFn, err := colorFunc(profile, t.Colors...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build percent color threshold %d color function: %w", i, err)
+		}
+
+		pts = append(pts, percentThreshold{min: t.Min, colors: copyStrings(t.Colors), colorFn: colorFn})
+	}
+
+	sort.SliceStable(pts, func(i, j int) bool { return pts[i].min < pts[j].min })
+
+	return pts, nil
+}
+
+// percentColorFn returns the color function of the highest threshold reached
+// by the percentage, or nil if none were reached. The caller must hold the
+// mutex.
+func (s *Spinner) percentColorFn() func(format string, a ...interface{}) string {
+	var colorFn func(format string, a ...interface{}) string
+
+	for _, t := range s.percentThresholds {
+		if s.percent < t.min {
+			break
+		}
+
+		colorFn = t.colorFn
+	}
+
+	return colorFn
 }
 
 const (
@@ -313,8 +1060,13 @@ const (
 	statusUnpausing
 )
 
-// New creates a new unstarted spinner. If stdout does not appear to be a TTY,
-// this constructor implicitly sets cfg.NotTTY to true.
+// New creates a new unstarted spinner. If cfg.TerminalMode is AutomaticMode, or
+// unset, this detects whether the Writer is a TTY, using its file descriptor or
+// the TTYDetector, and whether it's a dumb terminal, using the TermEnv or TERM
+// environment variable. It then sets the matching TerminalMode flags, which
+// are returned by the Config() method: ForceNoTTYMode and ForceDumbTerminalMode
+// if it's not a TTY, otherwise ForceTTYMode and either ForceDumbTerminalMode or
+// ForceSmartTerminalMode.
 func New(cfg Config) (*Spinner, error) {
 	if cfg.TerminalMode == 0 {
 		cfg.TerminalMode = AutomaticMode
@@ -333,14 +1085,98 @@ func New(cfg Config) (*Spinner, error) {
 		return nil, errors.New("cfg.TerminalMode cannot have both ForceDumbTerminalMode and ForceSmartTerminalMode flags set")
 	}
 
+	if termModeForceTest(cfg.TerminalMode) {
+		if termModeForceNoTTY(cfg.TerminalMode) || termModeForceSmart(cfg.TerminalMode) {
+			return nil, errors.New("cfg.TerminalMode cannot have ForceTestMode flag set with ForceNoTTYMode or ForceSmartTerminalMode")
+		}
+
+		cfg.TerminalMode |= ForceTTYMode | ForceDumbTerminalMode
+	}
+
+	if err := validateTemplate(cfg.Template); err != nil {
+		return nil, fmt.Errorf("cfg.Template is invalid: %w", err)
+	}
+
+	if cfg.DataUpdateBuffer < 0 {
+		return nil, errors.New("cfg.DataUpdateBuffer cannot be negative")
+	}
+
+	if cfg.ParallelChars < 0 {
+		return nil, errors.New("cfg.ParallelChars cannot be negative")
+	}
+
+	if cfg.EastAsianWidth > AmbiguousWidthWide {
+		return nil, fmt.Errorf("cfg.EastAsianWidth %d is not a valid AmbiguousWidth", cfg.EastAsianWidth)
+	}
+
+	width := eastAsianCondition(cfg.EastAsianWidth)
+
+	if len(cfg.PadCharacter) > 0 && stringWidth(width, cfg.PadCharacter) != 1 {
+		return nil, errors.New("cfg.PadCharacter must be a single column wide")
+	}
+
+	if cfg.MaxLineLength < 0 {
+		return nil, errors.New("cfg.MaxLineLength cannot be negative")
+	}
+
+	if cfg.MessageWidth < 0 {
+		return nil, errors.New("cfg.MessageWidth cannot be negative")
+	}
+
+	if cfg.MaxWriteErrors < 0 {
+		return nil, errors.New("cfg.MaxWriteErrors cannot be negative")
+	}
+
+	if cfg.InitialDelay < 0 {
+		return nil, errors.New("cfg.InitialDelay cannot be negative")
+	}
+
+	if cfg.PostStopDelay < 0 {
+		return nil, errors.New("cfg.PostStopDelay cannot be negative")
+	}
+
+	if cfg.HeartbeatInterval < 0 {
+		return nil, errors.New("cfg.HeartbeatInterval cannot be negative")
+	}
+
+	if cfg.ColorProfile > ColorProfileNoColor {
+		return nil, fmt.Errorf("cfg.ColorProfile %d is not a valid ColorProfile", cfg.ColorProfile)
+	}
+
+	if cfg.DataUpdateBuffer == 0 {
+		cfg.DataUpdateBuffer = 1
+	}
+
+	// the TTY detection uses the file descriptor of the Writer, if it has one
+	fd, hasFd := writerFd(cfg.Writer)
+
 	// is this a dumb terminal / not a TTY?
-	if cfg.TerminalMode == AutomaticMode && !isatty.IsTerminal(os.Stdout.Fd()) && !isatty.IsCygwinTerminal(os.Stdout.Fd()) {
-		cfg.TerminalMode = ForceNoTTYMode | ForceDumbTerminalMode
+	if cfg.TerminalMode == AutomaticMode {
+		tty := hasFd && isTerminal(fd)
+		if cfg.TTYDetector != nil {
+			tty = cfg.TTYDetector()
+		}
+
+		if !tty {
+			cfg.TerminalMode = ForceNoTTYMode | ForceDumbTerminalMode
+		}
 	}
 
 	// if cfg.TerminalMode is still equal to AutomaticMode, this is a TTY
 	if cfg.TerminalMode == AutomaticMode {
-		if os.Getenv("TERM") == "dumb" {
+		term := cfg.TermEnv
+		if len(term) == 0 {
+			term = os.Getenv("TERM")
+		}
+
+		if cfg.ColorProfile == ColorProfileAuto {
+			cfg.ColorProfile = detectColorProfile(os.Getenv("COLORTERM"), term)
+		}
+
+		if term == "dumb" {
+			cfg.TerminalMode = ForceDumbTerminalMode
+		} else if hasFd && isatty.IsTerminal(fd) && enableVirtualTerminal(fd) != nil {
+			// console can't process ANSI escape sequences (older Windows)
 			cfg.TerminalMode = ForceDumbTerminalMode
 		} else {
 			cfg.TerminalMode = ForceSmartTerminalMode
@@ -349,27 +1185,92 @@ func New(cfg Config) (*Spinner, error) {
 		cfg.TerminalMode |= ForceTTYMode
 	}
 
+	if cfg.ColorProfile == ColorProfileAuto {
+		cfg.ColorProfile = ColorProfileTrueColor
+	}
+
 	buf := bytes.NewBuffer(make([]byte, 2048))
 	buf.Reset()
 
+	var timestampFormat string
+
+	if cfg.Timestamp {
+		timestampFormat = cfg.TimestampFormat
+
+		if len(timestampFormat) == 0 {
+			timestampFormat = "15:04:05"
+		}
+	}
+
 	s := &Spinner{
 		buffer:            buf,
 		mu:                &sync.Mutex{},
 		frequency:         cfg.Frequency,
 		status:            uint32Ptr(0),
 		frequencyUpdateCh: make(chan time.Duration), // use unbuffered for now to avoid .Frequency() panic
+		msgFrequencyCh:    make(chan time.Duration),
 		dataUpdateCh:      make(chan struct{}),
+		logCh:             make(chan logEntry),
+		snapshotCh:        make(chan chan []byte),
 
-		colorAll:        cfg.ColorAll,
 		cursorHidden:    !cfg.ShowCursor,
-		spinnerAtEnd:    cfg.SpinnerAtEnd,
+		showPercent:     cfg.ShowPercent,
+		percentColorAll: cfg.PercentColorAll,
+		showElapsed:     cfg.ShowElapsed,
+		showRemaining:   cfg.ShowRemaining,
+		progressUnit:    cfg.ProgressUnit,
+		emitOSCProgress: cfg.EmitOSCProgress,
+		dataUpdateBuf:   cfg.DataUpdateBuffer,
+		silent:          cfg.SilentWhenNotTTY && termModeForceNoTTY(cfg.TerminalMode),
+		heartbeatEvery:  cfg.HeartbeatInterval,
+		heartbeatMsg:    cfg.HeartbeatMessage,
+		jsonMode:        cfg.JSONMode && termModeForceNoTTY(cfg.TerminalMode),
+		forceColor:      cfg.ForceColorInNoTTY && termModeForceNoTTY(cfg.TerminalMode),
+		noTTYFormat:     cfg.NoTTYFormat,
+		maxRedrawRate:   cfg.MaxRedrawRate,
+		initialDelay:    cfg.InitialDelay,
+		postStopDelay:   cfg.PostStopDelay,
+		altScreen:       cfg.AltScreen,
+		timestampFormat: timestampFormat,
+		maxWriteErrors:  cfg.MaxWriteErrors,
+		flushAfterWrite: cfg.FlushAfterWrite,
+		parallelChars:   cfg.ParallelChars,
+		maxLineLength:   cfg.MaxLineLength,
+		width:           width,
+		fd:              fd,
+		hasFd:           hasFd,
+		colorProfile:    cfg.ColorProfile,
+		padChar:         cfg.PadCharacter,
+		label:           cfg.Label,
+		stopFailBlink:   cfg.StopFailBlink,
+		stopMessageURL:  cfg.StopMessageURL,
+		stopLogger:      cfg.StopLogger,
+		logger:          cfg.Logger,
+		idempotentStart: cfg.IdempotentStart,
+		cycleOnce:       cfg.CycleOnce,
+		preserveIndex:   cfg.PreserveIndexOnStop,
+		bellOnStop:      cfg.BellOnStop,
+		bellOnStopFail:  cfg.BellOnStopFail,
+		newline:         cfg.Newline,
+		eraseNewline:    cfg.DumbEraseNewline,
+		onFrame:         cfg.OnFrame,
+		stickyBottom:    cfg.StickyBottom,
+		template:        cfg.Template,
 		suffixAutoColon: cfg.SuffixAutoColon,
+		autoSpace:       cfg.AutoSpace,
+		trimTrailing:    cfg.TrimTrailingSpace,
 		termMode:        cfg.TerminalMode,
+		colorAll:        cfg.ColorAll,
+		spinnerAtEnd:    cfg.SpinnerAtEnd,
+		messageWidth:    cfg.MessageWidth,
 		colorFn:         fmt.Sprintf,
 		stopColorFn:     fmt.Sprintf,
 		stopFailColorFn: fmt.Sprintf,
 	}
 
+	s.stopCharFallback = character{Value: cfg.StopCharacterFallback, Size: charWidth(s.width, cfg.StopCharacterFallback)}
+	s.stopFailCharFallback = character{Value: cfg.StopFailCharacterFallback, Size: charWidth(s.width, cfg.StopFailCharacterFallback)}
+
 	if err := s.Colors(cfg.Colors...); err != nil {
 		return nil, err
 	}
@@ -382,18 +1283,62 @@ func New(cfg Config) (*Spinner, error) {
 		return nil, err
 	}
 
+	outcomes, err := buildOutcomes(cfg.OutcomeCharacters, cfg.OutcomeMessages, cfg.OutcomeColors, s.width, s.colorProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	s.outcomes = outcomes
+
+	severityColorFns, err := buildSeverityColors(cfg.SeverityColors, s.colorProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	s.severityColors = make(map[Severity][]string, len(cfg.SeverityColors))
+	for level, c := range cfg.SeverityColors {
+		s.severityColors[level] = copyStrings(c)
+	}
+
+	s.severityColorFns = severityColorFns
+
+	percentThresholds, err := buildPercentThresholds(cfg.PercentColorThresholds, s.colorProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	s.percentThresholds = percentThresholds
+
 	if len(cfg.CharSet) == 0 {
-		cfg.CharSet = CharSets[9]
+		cfg.CharSet = DefaultCharSet
 	}
 
 	// can only error if the charset is empty, and we prevent that above
 	_ = s.CharSet(cfg.CharSet)
 
+	if cfg.StartIndex < 0 || cfg.StartIndex >= len(cfg.CharSet) {
+		return nil, fmt.Errorf("cfg.StartIndex must be within the CharSet (0-%d)", len(cfg.CharSet)-1)
+	}
+
+	s.index = cfg.StartIndex
+
 	if termModeForceNoTTY(s.termMode) {
 		// hack to prevent the animation from running if not a TTY
 		s.frequency = time.Duration(math.MaxInt64)
 	}
 
+	if cfg.SingleWrite {
+		// key the lock on the unwrapped Writer, as the colorable one may be
+		// different for each spinner
+		key := cfg.Writer
+		if key == nil {
+			key = os.Stdout
+		}
+
+		s.singleWrite = true
+		s.writeKey = writerKey(key)
+	}
+
 	if cfg.Writer == nil {
 		cfg.Writer = colorable.NewColorableStdout()
 	}
@@ -412,6 +1357,10 @@ func New(cfg Config) (*Spinner, error) {
 		s.Message(cfg.Message)
 	}
 
+	if len(cfg.SubMessage) > 0 {
+		s.SubMessage(cfg.SubMessage)
+	}
+
 	if len(cfg.StopMessage) > 0 {
 		s.StopMessage(cfg.StopMessage)
 	}
@@ -431,12 +1380,261 @@ func New(cfg Config) (*Spinner, error) {
 	return s, nil
 }
 
+// writerFd returns the file descriptor of the Writer, which is os.Stdout if
+// it's nil, and whether it has one
+func writerFd(w io.Writer) (uintptr, bool) {
+	if w == nil {
+		return os.Stdout.Fd(), true
+	}
+
+	if f, ok := w.(interface{ Fd() uintptr }); ok {
+		return f.Fd(), true
+	}
+
+	return 0, false
+}
+
+// isTerminal returns whether the file descriptor is a terminal. It's a
+// variable so tests can fake a terminal.
+var isTerminal = func(fd uintptr) bool {
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// terminalWidth returns the width, in columns, of the terminal referenced by
+// the file descriptor. It's a variable so tests can fake the width.
+var terminalWidth = consoleWidth
+
+// NewProgress creates a new unstarted spinner, preconfigured for rendering the
+// progress of an operation like a download. It sets the SpinnerAtEnd,
+// ShowPercent, and ShowElapsed fields of the Config to true, and provides
+// defaults for the Frequency, CharSet, and Prefix fields if they're unset. The
+// printed line will look like:
+//
+//	<message> <percent> <elapsed><prefix><spinner><suffix>
+//
+// Use the Percent() method to update the rendered percentage, or the
+// SetCurrent() and SetTotal() methods to also render the amounts.
+func NewProgress(cfg Config) (*Spinner, error) {
+	cfg.SpinnerAtEnd = true
+	cfg.ShowPercent = true
+	cfg.ShowElapsed = true
+
+	if cfg.Frequency == 0 {
+		cfg.Frequency = 100 * time.Millisecond
+	}
+
+	if len(cfg.CharSet) == 0 {
+		cfg.CharSet = CharSets[14]
+	}
+
+	if len(cfg.Prefix) == 0 {
+		cfg.Prefix = " "
+	}
+
+	return New(cfg)
+}
+
+// notifyDataChange tells the painter that the data changed, so it renders an
+// update. The caller doesn't need to hold the mutex.
 func (s *Spinner) notifyDataChange() {
-	// non-blocking notification
+	atomic.AddUint64(&s.dataVersion, 1)
+
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.sendDataUpdate()
+}
+
+// sendDataUpdate notifies the painter without blocking. The caller must hold
+// notifyMu.
+func (s *Spinner) sendDataUpdate() {
 	select {
 	case s.dataUpdateCh <- struct{}{}:
 	default:
+		// the channel is only buffered while the painter is running
+		if cap(s.dataUpdateCh) > 0 {
+			atomic.AddUint64(&s.dataUpdatesDropped, 1)
+		}
+	}
+}
+
+// SpinnerStats are counters describing the rendering of the spinner, returned
+// by the Stats() method. They're useful for tuning the Frequency and
+// DataUpdateBuffer Config fields.
+type SpinnerStats struct {
+	// FramesRendered is the number of frames written to the Writer, not
+	// including the final line printed when stopping.
+	FramesRendered uint64
+
+	// DataUpdates is the number of data update notifications (e.g., from
+	// calling Message()) received by the spinner's painting goroutine.
+	DataUpdates uint64
+
+	// DataUpdatesDropped is the number of data update notifications that
+	// were dropped while the spinner was running, because the queue of
+	// updates was full. Dropped updates are rendered in the next frame.
+	DataUpdatesDropped uint64
+}
+
+// Stats returns the rendering counters of the spinner. The counters are
+// cumulative over the lifetime of the *Spinner.
+func (s *Spinner) Stats() SpinnerStats {
+	return SpinnerStats{
+		FramesRendered:     atomic.LoadUint64(&s.framesRendered),
+		DataUpdates:        atomic.LoadUint64(&s.dataUpdates),
+		DataUpdatesDropped: atomic.LoadUint64(&s.dataUpdatesDropped),
+	}
+}
+
+// Config returns the effective configuration of the spinner, reflecting any
+// changes made after it was constructed (e.g., by calling Message()). Values
+// resolved by New() are returned as resolved, for example the TerminalMode
+// and the DataUpdateBuffer, and so TermEnv and TTYDetector are always empty.
+// The StartIndex is the index of the current frame of the animation. When not
+// running within a TTY, the Frequency is the one used to not animate the
+// spinner.
+func (s *Spinner) Config() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	charSet := make([]string, len(s.chars))
+	for i, c := range s.chars {
+		charSet[i] = c.Value
+	}
+
+	cfg := Config{
+		Frequency:                 s.frequency,
+		Writer:                    s.writer,
+		Logger:                    s.logger,
+		StickyBottom:              s.stickyBottom,
+		SingleWrite:               s.singleWrite,
+		FlushAfterWrite:           s.flushAfterWrite,
+		AltScreen:                 s.altScreen,
+		ShowCursor:                !s.cursorHidden,
+		SpinnerAtEnd:              s.spinnerAtEnd,
+		ColorAll:                  s.colorAll,
+		Colors:                    copyStrings(s.colors),
+		ColorProfile:              s.colorProfile,
+		CharSet:                   charSet,
+		StartIndex:                s.index,
+		ParallelChars:             s.parallelChars,
+		PadCharacter:              s.padChar,
+		Label:                     s.label,
+		Prefix:                    s.prefix,
+		Suffix:                    s.suffix,
+		SuffixAutoColon:           s.suffixAutoColon,
+		AutoSpace:                 s.autoSpace,
+		TrimTrailingSpace:         s.trimTrailing,
+		Message:                   s.loadMessage().text,
+		SubMessage:                s.subMessage,
+		Template:                  s.template,
+		ShowPercent:               s.showPercent,
+		PercentColorAll:           s.percentColorAll,
+		ShowElapsed:               s.showElapsed,
+		ShowRemaining:             s.showRemaining,
+		ProgressUnit:              s.progressUnit,
+		Timestamp:                 len(s.timestampFormat) > 0,
+		TimestampFormat:           s.timestampFormat,
+		EmitOSCProgress:           s.emitOSCProgress,
+		StopMessage:               s.stopMsg,
+		StopMessageURL:            s.stopMessageURL,
+		StopLogger:                s.stopLogger,
+		StopCharacter:             s.stopChar.Value,
+		StopCharacterFallback:     s.stopCharFallback.Value,
+		StopColors:                copyStrings(s.stopColors),
+		StopFailMessage:           s.stopFailMsg,
+		StopFailCharacter:         s.stopFailChar.Value,
+		StopFailCharacterFallback: s.stopFailCharFallback.Value,
+		StopFailColors:            copyStrings(s.stopFailColors),
+		BellOnStop:                s.bellOnStop,
+		BellOnStopFail:            s.bellOnStopFail,
+		StopFailBlink:             s.stopFailBlink,
+		TerminalMode:              s.termMode,
+		DumbEraseNewline:          s.eraseNewline,
+		DataUpdateBuffer:          s.dataUpdateBuf,
+		SilentWhenNotTTY:          s.silent,
+		HeartbeatInterval:         s.heartbeatEvery,
+		HeartbeatMessage:          s.heartbeatMsg,
+		MaxLineLength:             s.maxLineLength,
+		MessageWidth:              s.messageWidth,
+		EastAsianWidth:            s.ambiguousWidth(),
+		JSONMode:                  s.jsonMode,
+		ForceColorInNoTTY:         s.forceColor,
+		NoTTYFormat:               s.noTTYFormat,
+		PreserveIndexOnStop:       s.preserveIndex,
+		CycleOnce:                 s.cycleOnce,
+		IdempotentStart:           s.idempotentStart,
+		Newline:                   s.newline,
+		OnFrame:                   s.onFrame,
+		MaxRedrawRate:             s.maxRedrawRate,
+		InitialDelay:              s.initialDelay,
+		PostStopDelay:             s.postStopDelay,
+		MaxWriteErrors:            s.maxWriteErrors,
+	}
+
+	for _, t := range s.percentThresholds {
+		cfg.PercentColorThresholds = append(cfg.PercentColorThresholds, PercentColorThreshold{Min: t.min, Colors: copyStrings(t.colors)})
+	}
+
+	if len(s.severityColors) > 0 {
+		cfg.SeverityColors = make(map[Severity][]string, len(s.severityColors))
+
+		for level, c := range s.severityColors {
+			cfg.SeverityColors[level] = copyStrings(c)
+		}
+	}
+
+	for name, o := range s.outcomes {
+		if len(o.char.Value) > 0 {
+			if cfg.OutcomeCharacters == nil {
+				cfg.OutcomeCharacters = make(map[string]string)
+			}
+
+			cfg.OutcomeCharacters[name] = o.char.Value
+		}
+
+		if len(o.msg) > 0 {
+			if cfg.OutcomeMessages == nil {
+				cfg.OutcomeMessages = make(map[string]string)
+			}
+
+			cfg.OutcomeMessages[name] = o.msg
+		}
+
+		if len(o.colors) > 0 {
+			if cfg.OutcomeColors == nil {
+				cfg.OutcomeColors = make(map[string][]string)
+			}
+
+			cfg.OutcomeColors[name] = copyStrings(o.colors)
+		}
+	}
+
+	return cfg
+}
+
+// ambiguousWidth returns the AmbiguousWidth the spinner was constructed with
+func (s *Spinner) ambiguousWidth() AmbiguousWidth {
+	switch {
+	case s.width == nil:
+		return AmbiguousWidthAuto
+	case s.width.EastAsianWidth:
+		return AmbiguousWidthWide
+	default:
+		return AmbiguousWidthNarrow
+	}
+}
+
+// copyStrings returns a copy of ss, or nil if it's empty
+func copyStrings(ss []string) []string {
+	if len(ss) == 0 {
+		return nil
 	}
+
+	c := make([]string, len(ss))
+	copy(c, ss)
+
+	return c
 }
 
 // SpinnerStatus describes the status of the spinner. See the package constants
@@ -494,19 +1692,60 @@ func (s *Spinner) Status() SpinnerStatus {
 	return SpinnerStatus(atomic.LoadUint32(s.status))
 }
 
+// Paused returns whether the spinner is paused. Unlike Status(), this only
+// reports the settled SpinnerPaused state, so it returns false while the
+// spinner is still transitioning into (SpinnerPausing) or out of
+// (SpinnerUnpausing) being paused.
+func (s *Spinner) Paused() bool {
+	return s.Status() == SpinnerPaused
+}
+
 // Start begins the spinner on the Writer in the Config provided to New(). Only
-// possible error is if the spinner is already runninng.
+// possible error is if the spinner is already runninng, unless the
+// IdempotentStart Config field is set.
 func (s *Spinner) Start() error {
+	return s.start(false)
+}
+
+// StartManual moves the spinner to a running state, like Start(), but without
+// starting the internal painting goroutine. Instead, each call to the Render()
+// method paints a single frame of the animation. This is meant for embedding
+// the spinner within an existing render loop, like one in a TUI library. The
+// Stop() and StopFail() methods still print the final line.
+//
+// In this mode the Frequency is not used, and data updates are only rendered
+// on the next call to Render().
+func (s *Spinner) StartManual() error {
+	return s.start(true)
+}
+
+func (s *Spinner) start(manual bool) error {
 	// move us to the starting state
 	if !atomic.CompareAndSwapUint32(s.status, statusStopped, statusStarting) {
+		if st := s.Status(); s.idempotentStart && (st == SpinnerRunning || st == SpinnerPaused) {
+			return nil
+		}
+
 		return errors.New("spinner already running or shutting down")
 	}
 
 	// we now have atomic guarantees of no other goroutines starting or running
 
+	s.mu.Lock()
+	abandoned := s.abandonedCh
+	s.abandonedCh = nil
+	s.mu.Unlock()
+
+	// wait for a painter TryStop() gave up on to exit, as it still uses the
+	// buffer and the fields reset below
+	if abandoned != nil {
+		<-abandoned
+		s.resetPainterState()
+	}
+
 	s.mu.Lock()
 
-	if s.frequency < 1 && termModeForceTTY(s.termMode) {
+	if !manual && s.frequency < 1 && termModeForceTTY(s.termMode) {
 		return errors.New("spinner Frequency duration must be greater than 0 when used within a TTY")
 	}
 
@@ -521,17 +1760,71 @@ func (s *Spinner) Start() error {
 		return errors.New("before starting the spinner a CharSet must be set")
 	}
 
+	if s.altScreen && termModeForceSmart(s.termMode) {
+		if err := enterAltScreen(writerFunc(s.write)); err != nil {
+			s.mu.Unlock()
+
+			// move us to the stopped state
+			if !atomic.CompareAndSwapUint32(s.status, statusStarting, statusStopped) {
+				panic("atomic invariant encountered")
+			}
+
+			return fmt.Errorf("failed to enter alternate screen: %w", err)
+		}
+	}
+
+	s.startTime = time.Now()
+	atomic.StoreInt64(&s.pausedAt, 0)
+	atomic.StoreInt64(&s.pausedFor, 0)
+	s.lastErr = nil
+	s.renderedCh = make(chan struct{}) // read by WaitRendered() under the mutex
+
+	if manual {
+		s.manual = true // read by LogMessage() under the mutex
+
+		s.mu.Unlock()
+
+		// because of the atomic swap above, we know it's safe to mutate these
+		// values outside of mutex
+		s.writeErrors = 0
+		s.cycleFrames = 0
+		s.rendered = false
+
+		// move us to the running state
+		if !atomic.CompareAndSwapUint32(s.status, statusStarting, statusRunning) {
+			panic("atomic invariant encountered")
+		}
+
+		return nil
+	}
+
 	s.frequencyUpdateCh = make(chan time.Duration, 4)
-	s.dataUpdateCh, s.cancelCh = make(chan struct{}, 1), make(chan struct{}, 1)
+	s.msgFrequencyCh = make(chan time.Duration, 4)
+
+	dataUpdateBuf := s.dataUpdateBuf
+	if dataUpdateBuf < 1 {
+		dataUpdateBuf = 1
+	}
+
+	dataUpdateCh := make(chan struct{}, dataUpdateBuf)
+
+	s.notifyMu.Lock()
+	s.dataUpdateCh = dataUpdateCh
+	s.notifyMu.Unlock()
+
+	s.cancelCh = make(chan struct{}, 1)
+	s.doneCh = make(chan struct{}) // read by LogMessage() under the mutex
 
 	s.mu.Unlock()
 
 	// because of the atomic swap above, we know it's safe to mutate these
 	// values outside of mutex
-	s.doneCh = make(chan struct{})
 	s.pauseCh = make(chan struct{}) // unbuffered since we want this to be synchronous
+	s.writeErrors = 0
+	s.cycleFrames = 0
+	s.rendered = false
 
-	go s.painter(s.cancelCh, s.dataUpdateCh, s.pauseCh, s.doneCh, s.frequencyUpdateCh)
+	go s.painter(s.cancelCh, dataUpdateCh, s.pauseCh, s.doneCh, s.frequencyUpdateCh, s.msgFrequencyCh, s.logCh, s.snapshotCh)
 
 	// move us to the running state
 	if !atomic.CompareAndSwapUint32(s.status, statusStarting, statusRunning) {
@@ -550,17 +1843,82 @@ func (s *Spinner) Start() error {
 // the spinner first and then Unpause() after making the changes.
 //
 // If the spinner is not running (stopped, paused, or in transition to another
-// state) this returns an error.
+// state) this returns an error, which is ErrNotRunning if the spinner is
+// stopped or being stopped. If the painting goroutine stops while Pause() is
+// waiting for it, ErrNotRunning is also returned instead of blocking forever.
 func (s *Spinner) Pause() error {
+	return s.pause(false)
+}
+
+// PauseRendering is like Pause(), except that the current frame is erased and
+// nothing is written to the Writer until Unpause() is called. Updates to the
+// data of the spinner, like from Message() or Suffix(), are still accepted
+// while paused, and once unpaused a single frame with the latest state is
+// rendered immediately. This is useful for temporarily yielding the terminal to
+// something else, like a subprocess.
+//
+// Messages from LogMessage() are still printed while paused, but the frame
+// isn't rendered again below them until Unpause() is called.
+func (s *Spinner) PauseRendering() error {
+	return s.pause(true)
+}
+
+func (s *Spinner) pause(rendering bool) error {
 	if !atomic.CompareAndSwapUint32(s.status, statusRunning, statusPausing) {
+		// losing the race against Stop() is reported the same as the painter
+		// stopping while we wait for it
+		if st := atomic.LoadUint32(s.status); st == statusStopping || st == statusStopped {
+			return ErrNotRunning
+		}
+
 		return errors.New("spinner not running")
 	}
 
-	// set up the channels the painter will use
-	s.unpauseCh, s.unpausedCh = make(chan struct{}), make(chan struct{})
+	s.renderPaused = rendering
+
+	if s.manual {
+		// there is no painter to inform when being rendered manually
+		if rendering {
+			s.eraseFrame()
+		}
+	} else {
+		s.mu.Lock()
+		done := s.doneCh
+		s.mu.Unlock()
+
+		// set up the channels the painter will use
+		s.unpauseCh, s.unpausedCh = make(chan struct{}), make(chan struct{})
 
-	// inform the painter to pause as a blocking send
-	s.pauseCh <- struct{}{}
+		if rendering {
+			s.erasedCh = make(chan struct{})
+		}
+
+		// inform the painter to pause as a blocking send, unless it has
+		// already stopped
+		select {
+		case s.pauseCh <- struct{}{}:
+		case <-done:
+			s.unpauseCh, s.unpausedCh, s.erasedCh = nil, nil, nil
+			s.renderPaused = false
+
+			// move back to the running state, so that Stop() can finish
+			// stopping the spinner
+			if !atomic.CompareAndSwapUint32(s.status, statusPausing, statusRunning) {
+				panic("atomic invariant encountered")
+			}
+
+			return ErrNotRunning
+		}
+
+		// wait for the frame to be erased, so the terminal can be used as
+		// soon as this returns
+		if rendering {
+			<-s.erasedCh
+			s.erasedCh = nil
+		}
+	}
+
+	atomic.StoreInt64(&s.pausedAt, time.Now().UnixNano())
 
 	if !atomic.CompareAndSwapUint32(s.status, statusPausing, statusPaused) {
 		panic("atomic invariant encountered")
@@ -579,7 +1937,19 @@ func (s *Spinner) Unpause() error {
 		return errors.New("spinner not paused")
 	}
 
-	s.unpause()
+	if !s.manual {
+		s.unpause()
+	} else if s.renderPaused {
+		s.lastWrite = time.Time{}
+		s.paintUpdate(nil, false)
+	}
+
+	s.renderPaused = false
+
+	// the status CAS serializes Pause() and Unpause(), so pausedAt can't change
+	// under us here
+	atomic.AddInt64(&s.pausedFor, time.Now().UnixNano()-atomic.LoadInt64(&s.pausedAt))
+	atomic.StoreInt64(&s.pausedAt, 0)
 
 	if !atomic.CompareAndSwapUint32(s.status, statusUnpausing, statusRunning) {
 		panic("atomic invariant encountered")
@@ -588,6 +1958,27 @@ func (s *Spinner) Unpause() error {
 	return nil
 }
 
+// PauseAndLog pauses the spinner using PauseRendering(), erasing the current
+// frame, and calls fn with a Writer for printing arbitrary output, like a
+// multi-line summary. Writes are made to the Writer of the spinner the same way
+// frames are, so they also go to the writers added using AddWriter(). Once fn
+// returns, the spinner is unpaused and renders a frame with its latest state
+// below the output. The error returned by fn is returned after unpausing. If
+// the spinner isn't running, an error is returned without calling fn.
+func (s *Spinner) PauseAndLog(fn func(w io.Writer) error) error {
+	if err := s.PauseRendering(); err != nil {
+		return err
+	}
+
+	ferr := fn(writerFunc(s.write))
+
+	if err := s.Unpause(); err != nil {
+		return err
+	}
+
+	return ferr
+}
+
 func (s *Spinner) unpause() {
 	// tell the painter to unpause
 	close(s.unpauseCh)
@@ -600,66 +1991,331 @@ func (s *Spinner) unpause() {
 	s.unpausedCh = nil
 }
 
+// ErrNotRunning is the error returned by methods requiring the spinner to be
+// running or paused, like Stop(), when it isn't.
+var ErrNotRunning = errors.New("spinner not running or paused")
+
 // Stop disables the spinner, and prints the StopCharacter with the StopMessage
 // using the StopColors. This blocks until the stopped message is printed. Only
 // possible error is if the spinner is not running.
 func (s *Spinner) Stop() error {
-	return s.stop(false)
+	return s.stop(false, "")
 }
 
 // StopFail disables the spinner, and prints the StopFailCharacter with the
 // StopFailMessage using the StopFailColors. This blocks until the stopped
 // message is printed. Only possible error is if the spinner is not running.
 func (s *Spinner) StopFail() error {
-	return s.stop(true)
+	return s.stop(true, "")
+}
+
+// StopOutcome disables the spinner, and prints the character and message of
+// the named outcome using its colors. Outcomes are defined using the
+// OutcomeCharacters, OutcomeMessages, and OutcomeColors Config fields. This
+// blocks until the stopped message is printed. An error is returned if the
+// outcome is not defined, or if the spinner is not running.
+func (s *Spinner) StopOutcome(name string) error {
+	s.mu.Lock()
+	_, ok := s.outcomes[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("stop outcome %q is not defined", name)
+	}
+
+	return s.stop(false, name)
+}
+
+// StopAndPrint disables the spinner, erases the animation, and prints str
+// verbatim instead of the usual stop line. This is useful for printing
+// multi-line output, such as a summary, as the spinner stops. No newline is
+// appended to str. This blocks until str is printed. Only possible error is if
+// the spinner is not running.
+func (s *Spinner) StopAndPrint(str string) error {
+	return s.stopWith(false, "", &str, stopStyleDefault, 0)
+}
+
+// StopInline is like Stop(), except that the stop line is printed without a
+// newline, leaving the cursor at the end of it. This is useful in interactive
+// REPLs, so that the prompt follows the stop line instead of being printed on
+// the line below it. When the JSONMode Config field applies the line is still
+// terminated, as each status must be on its own line. Only possible error is
+// if the spinner is not running.
+func (s *Spinner) StopInline() error {
+	return s.stopWith(false, "", nil, stopStyleInline, 0)
+}
+
+// StopPreserve is like Stop(), except that the last animated frame isn't
+// erased, and the stop line is printed on the line below it instead. This is
+// useful for keeping the final progress of the spinner visible. When using the
+// StickyBottom Config field, or an alternate screen, the frame is erased
+// regardless. Only possible error is if the spinner is not running.
+func (s *Spinner) StopPreserve() error {
+	return s.stopWith(false, "", nil, stopStylePreserve, 0)
+}
+
+// TryStop is like Stop(), except that it only waits up to timeout for the stop
+// line to be printed, which makes it suitable for signal handlers that need to
+// return quickly even if the Writer is blocked. If the timeout is reached, the
+// spinner is moved to the stopped state anyway and an error is returned. In
+// that case the stop line may still be printed once the Writer unblocks, and
+// starting the spinner again blocks until it has been. When the spinner was
+// started with StartManual() the stop line is printed synchronously, and the
+// timeout isn't used.
+func (s *Spinner) TryStop(timeout time.Duration) error {
+	if timeout < 1 {
+		return errors.New("timeout must be greater than 0")
+	}
+
+	return s.stopWith(false, "", nil, stopStyleDefault, timeout)
+}
+
+// StopTimed is like Stop(), except that it also returns how long the spinner
+// was running for, not including any time it was paused.
+func (s *Spinner) StopTimed() (time.Duration, error) {
+	return s.stopTimed(false)
+}
+
+// StopFailTimed is like StopFail(), except that it also returns how long the
+// spinner was running for, not including any time it was paused.
+func (s *Spinner) StopFailTimed() (time.Duration, error) {
+	return s.stopTimed(true)
+}
+
+func (s *Spinner) stopTimed(fail bool) (time.Duration, error) {
+	s.mu.Lock()
+	d := s.runDuration(time.Now())
+	s.mu.Unlock()
+
+	if err := s.stop(fail, ""); err != nil {
+		return 0, err
+	}
+
+	return d, nil
+}
+
+// runDuration returns how long the spinner has been running for at now, not
+// including any time it was paused. The caller must hold the mutex.
+func (s *Spinner) runDuration(now time.Time) time.Duration {
+	d := now.Sub(s.startTime) - time.Duration(atomic.LoadInt64(&s.pausedFor))
+
+	if pausedAt := atomic.LoadInt64(&s.pausedAt); pausedAt != 0 {
+		d -= time.Duration(now.UnixNano() - pausedAt)
+	}
+
+	return d
+}
+
+// StopIfRunning is like Stop(), except that it doesn't return an error if the
+// spinner isn't running or paused, which makes it suitable for deferred
+// cleanup.
+func (s *Spinner) StopIfRunning() error {
+	if err := s.Stop(); err != nil && !errors.Is(err, ErrNotRunning) {
+		return err
+	}
+
+	return nil
 }
 
-func (s *Spinner) stop(fail bool) error {
+func (s *Spinner) stop(fail bool, outcome string) error {
+	return s.stopWith(fail, outcome, nil, stopStyleDefault, 0)
+}
+
+// stopStyle is how the stop line is printed relative to the last frame
+type stopStyle uint8
+
+const (
+	// stopStyleDefault erases the last frame, and prints the stop line with a
+	// newline in its place
+	stopStyleDefault stopStyle = iota
+
+	// stopStyleInline is like stopStyleDefault, but without the newline; see
+	// StopInline()
+	stopStyleInline
+
+	// stopStylePreserve keeps the last frame, and prints the stop line below
+	// it; see StopPreserve()
+	stopStylePreserve
+)
+
+// stopWith stops the spinner, printing the custom output instead of the stop
+// line if it's not nil, in the provided style. If timeout is greater than 0,
+// and the painter doesn't stop within it, the spinner is moved to the stopped
+// state regardless and an error is returned.
+func (s *Spinner) stopWith(fail bool, outcome string, custom *string, style stopStyle, timeout time.Duration) error {
 	// move us to a stopping state to protect against concurrent Stop() calls
 	wasRunning := atomic.CompareAndSwapUint32(s.status, statusRunning, statusStopping)
 	wasPaused := atomic.CompareAndSwapUint32(s.status, statusPaused, statusStopping)
 
 	if !wasRunning && !wasPaused {
-		return errors.New("spinner not running or paused")
+		return ErrNotRunning
 	}
 
 	// we now have an atomic guarantees of no other threads invoking state changes
 
-	if !fail {
-		// this tells the painter to print the StopMessage and not the
-		// StopFailMessage
-		s.cancelCh <- struct{}{}
+	if len(outcome) > 0 || custom != nil || style != stopStyleDefault {
+		s.mu.Lock()
+		s.stopOutcome = outcome
+		s.stopPrint = custom
+		s.stopStyle = style
+		s.mu.Unlock()
 	}
 
-	close(s.cancelCh)
+	if s.manual {
+		// there is no painter, so print the stop line ourselves
+		s.flushPending()
+		s.paintStop(!fail)
+	} else {
+		if !fail {
+			// this tells the painter to print the StopMessage and not the
+			// StopFailMessage
+			s.cancelCh <- struct{}{}
+		}
 
-	if wasPaused {
-		s.unpause()
+		close(s.cancelCh)
+
+		if wasPaused {
+			s.unpause()
+		}
+
+		// wait for the painter to stop
+		if timeout > 0 {
+			t := time.NewTimer(timeout)
+			defer t.Stop()
+
+			select {
+			case <-s.doneCh:
+			case <-t.C:
+				// the painter is left to exit on its own, so the state it
+				// uses is reset by start() once it has
+				s.mu.Lock()
+				s.abandonedCh = s.doneCh
+				s.mu.Unlock()
+
+				s.finishStop()
+
+				return fmt.Errorf("timed out after %s waiting for the spinner to stop", timeout)
+			}
+		} else {
+			<-s.doneCh
+		}
+	}
+
+	s.finishStop()
+
+	// give the user a chance to read the final line
+	if s.postStopDelay > 0 {
+		time.Sleep(s.postStopDelay)
 	}
 
-	// wait for the painter to stop
-	<-s.doneCh
+	return nil
+}
+
+// finishStop resets the state of the spinner after it has stopped, and moves
+// it to the stopped state. The caller must have moved the spinner to the
+// stopping state, and the painter must no longer be running unless it was
+// abandoned by TryStop().
+func (s *Spinner) finishStop() {
+	s.notifyMu.Lock()
+	s.dataUpdateCh = make(chan struct{}) // prevent panic() in various setter methods
+	s.notifyMu.Unlock()
 
 	s.mu.Lock()
 
-	s.dataUpdateCh = make(chan struct{})           // prevent panic() in various setter methods
 	s.frequencyUpdateCh = make(chan time.Duration) // prevent panic() in .Frequency()
+	s.msgFrequencyCh = make(chan time.Duration)
+	s.stopOutcome = ""
+	s.stopPrint = nil
+	s.stopStyle = stopStyleDefault
+	s.doneCh = nil // read by LogMessage() under the mutex
+	s.renderedCh = nil
+	s.manual = false
+	s.closeSubscribers()
+	abandoned := s.abandonedCh != nil
 
 	s.mu.Unlock()
 
-	// because of atomic swaps and channel receive above we know it's
-	// safe to mutate these fields outside of the mutex
-	s.index = 0
-	s.cancelCh = nil
-	s.doneCh = nil
-	s.pauseCh = nil
+	if !abandoned {
+		s.resetPainterState()
+	}
 
 	// move us to the stopped state
 	if !atomic.CompareAndSwapUint32(s.status, statusStopping, statusStopped) {
 		panic("atomic invariant encountered")
 	}
+}
 
-	return nil
+// resetPainterState resets the fields used by the painter without the mutex.
+// Because of the atomic swaps, and the painter having exited, we know it's safe
+// to mutate these fields outside of the mutex.
+func (s *Spinner) resetPainterState() {
+	if !s.preserveIndex {
+		s.index = 0
+	}
+
+	s.cancelCh = nil
+	s.pauseCh = nil
+	s.renderPaused = false
+}
+
+// LastError returns the last error encountered writing to the Writer, if the
+// MaxWriteErrors Config field is set. It's reset when the spinner is started.
+func (s *Spinner) LastError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastErr
+}
+
+// stopOnWriteErrors stops the spinner if the number of consecutive failed
+// writes has reached MaxWriteErrors, returning true if it did. This must only
+// be called by the painter, or by Render() when rendering manually.
+func (s *Spinner) stopOnWriteErrors() bool {
+	if s.maxWriteErrors == 0 || s.writeErrors < s.maxWriteErrors {
+		return false
+	}
+
+	// if this fails the spinner is being paused or stopped, and the painter
+	// needs to handle that first
+	if !atomic.CompareAndSwapUint32(s.status, statusRunning, statusStopping) {
+		return false
+	}
+
+	s.finishStop()
+
+	return true
+}
+
+// stopAfterCycle counts an animated frame, and if the CycleOnce Config field is
+// set and a full cycle of the characters has been animated, stops the spinner
+// and prints the stop line. It returns true if the spinner was stopped. This
+// must only be called by the painter, or by Render() when rendering manually.
+func (s *Spinner) stopAfterCycle() bool {
+	if !s.cycleOnce {
+		return false
+	}
+
+	s.mu.Lock()
+	n := len(s.chars)
+	s.mu.Unlock()
+
+	s.cycleFrames++
+
+	if s.cycleFrames < n {
+		return false
+	}
+
+	// if this fails the spinner is being paused or stopped, and the painter
+	// needs to handle that first
+	if !atomic.CompareAndSwapUint32(s.status, statusRunning, statusStopping) {
+		return false
+	}
+
+	s.flushPending()
+	s.paintStop(true)
+	s.finishStop()
+
+	return true
 }
 
 // handleFrequencyUpdate is for when the frequency was changed. This tries to
@@ -689,182 +2345,1440 @@ func handleFrequencyUpdate(newFrequency time.Duration, timer *time.Timer, lastTi
 	timer.Reset(newFrequency - timeSince)
 }
 
-func (s *Spinner) painter(cancel, dataUpdate, pause <-chan struct{}, done chan<- struct{}, frequencyUpdate <-chan time.Duration) {
-	timer := time.NewTimer(0)
+func (s *Spinner) painter(cancel, dataUpdate, pause <-chan struct{}, done chan<- struct{}, frequencyUpdate, msgFrequencyUpdate <-chan time.Duration, log <-chan logEntry, snapshot <-chan chan []byte) {
+	timer := time.NewTimer(s.initialDelay)
 	var lastTick time.Time
 
+	// whether the InitialDelay has elapsed, and frames can be painted
+	painting := s.initialDelay == 0
+
+	// fires when a frame held back due to the MaxRedrawRate should be written
+	var flushTimer *time.Timer
+	var flush <-chan time.Time
+
+	// fires when the message animation should advance
+	var msgTimer *time.Timer
+	var msgTick <-chan time.Time
+
+	setMsgFrequency := func(d time.Duration) {
+		if msgTimer != nil {
+			msgTimer.Stop()
+		}
+
+		if d < 1 || termModeForceNoTTY(s.termMode) {
+			msgTick = nil
+			return
+		}
+
+		if msgTimer == nil {
+			msgTimer = time.NewTimer(d)
+		} else {
+			msgTimer.Reset(d)
+		}
+
+		msgTick = msgTimer.C
+	}
+
+	// fires when a heartbeat should be printed, if they're enabled
+	var heartbeatTimer *time.Timer
+	var heartbeat <-chan time.Time
+
+	if s.heartbeatEvery > 0 && termModeForceNoTTY(s.termMode) && !s.silent {
+		heartbeatTimer = time.NewTimer(s.heartbeatEvery)
+		heartbeat = heartbeatTimer.C
+	}
+
+	// postpone the heartbeat, as a line was just printed
+	resetHeartbeat := func() {
+		if heartbeatTimer == nil {
+			return
+		}
+
+		if !heartbeatTimer.Stop() {
+			select {
+			case <-heartbeatTimer.C:
+			default:
+			}
+		}
+
+		heartbeatTimer.Reset(s.heartbeatEvery)
+	}
+
+	stopTimers := func() {
+		timer.Stop()
+
+		if heartbeatTimer != nil {
+			heartbeatTimer.Stop()
+		}
+
+		if flushTimer != nil {
+			flushTimer.Stop()
+		}
+
+		if msgTimer != nil {
+			msgTimer.Stop()
+		}
+	}
+
+	s.mu.Lock()
+	setMsgFrequency(s.msgFrequency)
+	s.mu.Unlock()
+
 	for {
 		select {
 		case <-timer.C:
 			lastTick = time.Now()
+			painting = true
 
 			s.paintUpdate(timer, true)
 
+			if s.stopAfterCycle() {
+				stopTimers()
+				close(done)
+
+				return
+			}
+
+		case <-msgTick:
+			s.mu.Lock()
+			s.advanceMessage()
+			d := s.msgFrequency
+			s.mu.Unlock()
+
+			if painting {
+				s.paintUpdate(timer, false)
+			}
+
+			setMsgFrequency(d)
+
+		case d := <-msgFrequencyUpdate:
+			setMsgFrequency(d)
+
 		case <-pause:
-			<-s.unpauseCh
+			// when only the rendering is paused, erase the frame and keep
+			// accepting data updates, which are rendered once unpaused
+			var updates <-chan struct{}
+
+			rendering := s.renderPaused
+			if rendering {
+				updates = dataUpdate
+
+				s.eraseFrame()
+				close(s.erasedCh)
+			}
+
+			// keep printing log messages while paused
+		paused:
+			for {
+				select {
+				case <-s.unpauseCh:
+					break paused
+				case e := <-log:
+					s.paintLog(e)
+				case reply := <-snapshot:
+					reply <- s.snapshot()
+				case <-updates:
+					atomic.AddUint64(&s.dataUpdates, 1)
+				}
+			}
+
 			close(s.unpausedCh)
 
+			// render the latest state right away, unless the spinner is
+			// being stopped instead
+			if rendering && painting && atomic.LoadUint32(s.status) == statusUnpausing {
+				s.lastWrite = time.Time{}
+				s.paintUpdate(timer, false)
+			}
+
+		case e := <-log:
+			s.paintLog(e)
+			resetHeartbeat()
+
+		case reply := <-snapshot:
+			reply <- s.snapshot()
+
 		case <-dataUpdate:
+			atomic.AddUint64(&s.dataUpdates, 1)
+
+			// the update is rendered in the first frame instead
+			if !painting {
+				continue
+			}
+
 			// if this is not a TTY: animate the spinner on the data update
 			s.paintUpdate(timer, termModeForceNoTTY(s.termMode))
+			resetHeartbeat()
+
+		case <-heartbeat:
+			if painting {
+				s.paintHeartbeat()
+			}
+
+			heartbeatTimer.Reset(s.heartbeatEvery)
 
 		case frequency := <-frequencyUpdate:
+			// the first frame uses the new frequency once it's painted
+			if !painting {
+				continue
+			}
+
 			handleFrequencyUpdate(frequency, timer, lastTick)
 
+		case <-flush:
+			flush = nil
+
+			s.flushPending()
+
 		case _, ok := <-cancel:
 			defer close(done)
 
-			timer.Stop()
+			stopTimers()
 
+			s.flushPending()
 			s.paintStop(ok)
 
 			return
 		}
+
+		if s.stopOnWriteErrors() {
+			stopTimers()
+			close(done)
+
+			return
+		}
+
+		if len(s.pending) > 0 && flush == nil {
+			wait := s.maxRedrawRate - time.Since(s.lastWrite)
+
+			if flushTimer == nil {
+				flushTimer = time.NewTimer(wait)
+			} else {
+				flushTimer.Reset(wait)
+			}
+
+			flush = flushTimer.C
+		}
 	}
 }
 
 type paintOp struct {
 	writer          io.Writer // output; should be *Spinner.buffer not .writer
 	maxWidth        int       // max width of all spinner frames
+	padChar         string    // pads the frame to maxWidth, " " if empty
 	char            character // current spinner frame
+	label           string
 	prefix          string
 	message         string
 	suffix          string
+	fraction        string // rendered current/total, empty if not shown
+	percent         string // rendered percent, empty if not shown
+	elapsed         string // rendered elapsed time, empty if not shown
+	remaining       string // rendered remaining time, empty if not shown
+	timestamp       string // rendered timestamp, empty if not shown
+	maxLineLength   int    // truncate lines wider than this, if not 0
+	blink           bool   // blink the character
+	newline         string // line terminator, "\n" if empty
 	suffixAutoColon bool
+	autoSpace       bool
+	trimTrailing    bool
 	colorAll        bool
 	spinnerAtEnd    bool
 	finalPaint      bool // is this the final paint [paintStop()]?
+	noLineEnd       bool // don't terminate the final line; see StopInline()
 	notTTY          bool
+	noTTYFormat     func(message string) string // formats lines when notTTY, if set
 	colorFn         func(format string, a ...interface{}) string
+	suffixColorFn   func(format string, a ...interface{}) string // nil if not colored
+	messageColorFn  func(format string, a ...interface{}) string // nil if not colored
+	messageState    *messageState                                // identifies messageColorFn in the segments, if set
+	percentColorFn  func(format string, a ...interface{}) string // nil if not colored
+	segments        *segmentCache                                // reuses the colored segments, if set
+	template        string                                       // overrides the default layout, if set
+	width           *runewidth.Condition                         // measures widths when truncating; nil uses the runewidth defaults
 }
 
-func (s *Spinner) paintUpdate(timer *time.Timer, animate bool) {
-	s.mu.Lock()
-
-	p := s.prefix
-	m := s.message
-	suf := s.suffix
-	mw := s.maxWidth
-	cFn := s.colorFn
-	d := s.frequency
-	index := s.index
-
-	if animate {
-		s.index++
+// formatNoTTY formats the line using the noTTYFormat function, if it's set
+func (op paintOp) formatNoTTY(line string) string {
+	if op.noTTYFormat == nil {
+		return line
+	}
 
-		if s.index == len(s.chars) {
-			s.index = 0
-		}
-	} else {
-		// for data updates use the last spinner char
-		index--
+	return op.noTTYFormat(line)
+}
 
-		if index < 0 {
-			index = len(s.chars) - 1
-		}
+// lineEnd returns the line terminator
+func (op paintOp) lineEnd() string {
+	if len(op.newline) == 0 {
+		return "\n"
 	}
 
-	c := s.chars[index]
+	return op.newline
+}
+
+// dumb returns a copy of the paintOp for rendering on a dumb terminal, which
+// doesn't support printing colors
+func (op paintOp) dumb() paintOp {
+	op.colorAll = false
+	op.colorFn = fmt.Sprintf
+	op.suffixColorFn = nil
+	op.messageColorFn = nil
+	op.percentColorFn = nil
+	op.segments = nil
+	op.blink = false
+
+	return op
+}
+
+// plain returns a copy of the paintOp for rendering on a dumb terminal or a
+// non-TTY output, which doesn't print colors unless the ForceColorInNoTTY
+// Config field applies
+func (s *Spinner) plain(op paintOp) paintOp {
+	if s.forceColor {
+		return op
+	}
+
+	return op.dumb()
+}
+
+// Render paints a single frame of the spinner animation, advancing it to the
+// next character, if the spinner was started using StartManual(). Otherwise,
+// or if the spinner is paused, this does nothing.
+//
+// Render must not be called concurrently with itself, or with the Stop() and
+// StopFail() methods, as it writes to the Writer synchronously.
+func (s *Spinner) Render() {
+	if s.Status() != SpinnerRunning || !s.manual {
+		return
+	}
+
+	s.paintUpdate(nil, true)
+
+	// the message animation has no cadence of its own when rendering
+	// manually
+	s.mu.Lock()
+	s.advanceMessage()
+	s.mu.Unlock()
+
+	if !s.stopOnWriteErrors() {
+		s.stopAfterCycle()
+	}
+}
+
+// AnimateMessage renders the frames in place of the Message, cycling through
+// them every freq on a cadence independent of the spinner's Frequency. This is
+// useful for animating the message itself, like with moving dots, while the
+// spinner character stays static (e.g., using a CharSet with a single
+// character). Calling it with no frames, or a freq that isn't greater than 0,
+// stops the animation and renders the Message again.
+//
+// When not running within a TTY, the first frame is rendered but it isn't
+// animated. When rendering manually, the message advances to the next frame
+// on each call to Render().
+func (s *Spinner) AnimateMessage(frames []string, freq time.Duration) {
+	if len(frames) == 0 || freq < 1 {
+		frames, freq = nil, 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.msgFrames = copyStrings(frames)
+	s.msgFrequency = freq
+	s.msgIndex = 0
+
+	// non-blocking notification
+	select {
+	case s.msgFrequencyCh <- freq:
+	default:
+	}
+
+	s.notifyDataChange()
+}
+
+// Marquee renders a window of the text in place of the Message, scrolling it by
+// one character every freq, like a marquee. This is useful for showing text
+// that's too long for the space available. The window is the number of
+// characters shown at a time, and once the end of the text is reached it wraps
+// around to its start, separated by a space. Text that fits within the window
+// is rendered as-is, without scrolling. This uses AnimateMessage(), so the same
+// details apply. Calling it with empty text, or a window or freq that isn't
+// greater than 0, stops the marquee and renders the Message again.
+func (s *Spinner) Marquee(text string, window int, freq time.Duration) {
+	var frames []string
+
+	if window > 0 {
+		frames = marqueeFrames(text, window)
+	}
+
+	s.AnimateMessage(frames, freq)
+}
+
+// marqueeFrames returns the frames of a marquee scrolling the text through a
+// window of the provided number of characters, which are grapheme clusters so
+// that combining marks stay with their base character
+func marqueeFrames(text string, window int) []string {
+	var chars []string
+
+	g := uniseg.NewGraphemes(text)
+
+	for g.Next() {
+		chars = append(chars, g.Str())
+	}
+
+	if len(chars) == 0 {
+		return nil
+	}
+
+	if len(chars) <= window {
+		return []string{text}
+	}
+
+	// separate the end of the text from its start when wrapping around
+	chars = append(chars, " ")
+
+	frames := make([]string, len(chars))
+
+	for i := range chars {
+		var b strings.Builder
+
+		for j := 0; j < window; j++ {
+			b.WriteString(chars[(i+j)%len(chars)])
+		}
+
+		frames[i] = b.String()
+	}
+
+	return frames
+}
+
+// SetMessageWidth sets the width, in terminal columns, the message is padded or
+// truncated to. A width of 0 renders the message as-is. See the MessageWidth
+// field of the Config for more details.
+func (s *Spinner) SetMessageWidth(width int) error {
+	if width < 0 {
+		return errors.New("width cannot be negative")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messageWidth = width
+
+	s.notifyDataChange()
+
+	return nil
+}
+
+// SetMessageWidthFromTerm sets the MessageWidth to the fraction of the width
+// of the terminal the spinner is writing to, for layouts that adapt to the
+// size of the terminal. For example, a fraction of 0.5 makes the message column
+// half as wide as the terminal. The fraction must be greater than 0 and at most
+// 1. An error is returned if the spinner isn't running within a TTY, or if the
+// width of the terminal can't be determined.
+func (s *Spinner) SetMessageWidthFromTerm(fraction float64) error {
+	if !(fraction > 0 && fraction <= 1) {
+		return errors.New("fraction must be greater than 0 and at most 1")
+	}
+
+	if termModeForceNoTTY(s.termMode) || !s.hasFd {
+		return errors.New("spinner is not writing to a TTY")
+	}
+
+	cols, err := terminalWidth(s.fd)
+	if err != nil {
+		return fmt.Errorf("failed to get terminal width: %w", err)
+	}
+
+	if cols < 1 {
+		return errors.New("terminal width is unknown")
+	}
+
+	width := int(float64(cols) * fraction)
+	if width < 1 {
+		width = 1
+	}
+
+	return s.SetMessageWidth(width)
+}
+
+// loadMessage returns the current message. Unlike most of the state of the
+// spinner, it's safe to call without holding the mutex.
+func (s *Spinner) loadMessage() messageState {
+	return *s.loadMessageState()
+}
+
+// noMessage is the state of a spinner whose message was never set
+var noMessage = &messageState{}
+
+// loadMessageState is like loadMessage(), but returns the stored state. As it's
+// replaced whenever the message is set, the pointer identifies the text and the
+// color function together.
+func (s *Spinner) loadMessageState() *messageState {
+	if m, ok := s.message.Load().(*messageState); ok {
+		return m
+	}
+
+	return noMessage
+}
+
+// renderedMessage returns the message to render, which is the current frame of
+// the message animation if there is one. The caller must hold the mutex.
+func (s *Spinner) renderedMessage(m messageState) string {
+	if len(s.msgFrames) > 0 {
+		return s.msgFrames[s.msgIndex]
+	}
+
+	return m.text
+}
+
+// fitMessage pads or truncates the message to the MessageWidth, if it's set.
+// The caller must hold the mutex.
+func (s *Spinner) fitMessage(message string) string {
+	if s.messageWidth < 1 {
+		return message
+	}
+
+	message = truncateLine(message, s.messageWidth, s.width)
+
+	if w := stringWidth(s.width, escapeRe.ReplaceAllString(message, "")); w < s.messageWidth {
+		message += strings.Repeat(" ", s.messageWidth-w)
+	}
+
+	return message
+}
+
+// advanceMessage moves the message animation to its next frame. The caller
+// must hold the mutex.
+func (s *Spinner) advanceMessage() {
+	if len(s.msgFrames) > 0 {
+		s.msgIndex = (s.msgIndex + 1) % len(s.msgFrames)
+	}
+}
+
+func (s *Spinner) paintUpdate(timer *time.Timer, animate bool) {
+	if s.silent {
+		return
+	}
+
+	s.mu.Lock()
+
+	d := s.interval()
+	f := s.nextFrame(animate)
 
 	s.mu.Unlock()
 
 	defer s.buffer.Reset()
 
+	printLen, subLine := s.renderUpdate(s.buffer, f)
+
+	if s.buffer.Len() > 0 {
+		s.writeFrame(printLen, subLine)
+
+		if s.onFrame != nil {
+			s.onFrame(f.index)
+		}
+	}
+
+	if animate && timer != nil {
+		timer.Reset(d)
+	}
+}
+
+// Snapshot returns the exact bytes that would be written to the Writer for the
+// current frame, including any sequences for erasing the previous frame and
+// hiding the cursor, without writing them or advancing the animation. This is
+// useful for debugging issues with the terminal's handling of the output. If
+// nothing would be written, like when SilentWhenNotTTY applies, it returns nil.
+//
+// If the spinner was started using StartManual(), this must not be called
+// concurrently with Render(), Stop(), or StopFail().
+func (s *Spinner) Snapshot() []byte {
+	s.mu.Lock()
+	manual, done := s.manual, s.doneCh
+	s.mu.Unlock()
+
+	// the painter owns the state of the previous frame while running
+	if !manual && done != nil {
+		reply := make(chan []byte, 1)
+
+		select {
+		case s.snapshotCh <- reply:
+			return <-reply
+		case <-done:
+		}
+	}
+
+	return s.snapshot()
+}
+
+// snapshot renders the current frame, returning the bytes that would be
+// written. This must only be called by the painter, or when it's not running.
+func (s *Spinner) snapshot() []byte {
+	if s.silent {
+		return nil
+	}
+
+	s.mu.Lock()
+	f := s.nextFrame(false)
+	s.mu.Unlock()
+
+	var buf bytes.Buffer
+
+	s.renderUpdate(&buf, f)
+
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	return buf.Bytes()
+}
+
+// frame is the data for rendering a single frame of the animation
+type frame struct {
+	index   int // index of the character, for the OnFrame callback
+	op      paintOp
+	js      jsonStatus
+	sub     string // sub message, empty if not shown
+	oscPct  int
+	emitOSC bool // whether to emit the OSC 9;4 progress sequence
+}
+
+// nextFrame builds the next frame of the animation, advancing to the next
+// character if animate is true. Otherwise, like for data updates, the frame
+// uses the current character. The caller must hold the mutex.
+func (s *Spinner) nextFrame(animate bool) frame {
+	index := s.index
+
+	// if there are somehow no characters, render only the message
+	var c character
+
+	if n := len(s.chars); n > 0 {
+		step := 1
+		if s.backward {
+			step = -1
+		}
+
+		if animate && !s.frozen {
+			s.index = (s.index + step + n) % n
+		} else {
+			// for data updates use the last spinner char
+			index = s.lastIndex()
+		}
+
+		c = parallelChar(s.chars, index, s.parallelChars)
+	}
+
+	if s.hidden {
+		c = character{}
+	}
+
+	// the version must be read before the message, as the setters store the
+	// message before incrementing it; otherwise a stale message could be
+	// cached under the new version
+	version := atomic.LoadUint64(&s.dataVersion)
+	msg := s.loadMessageState()
+
+	op := s.paintOp(c, s.fitMessage(s.renderedMessage(*msg)), s.colorFn, false)
+	op.messageColorFn = msg.colorFn
+	op.messageState = msg
+	op.segments = s.segments.reset(version)
+
+	if colorFn := s.percentColorFn(); colorFn != nil {
+		if s.percentColorAll {
+			op.colorAll = true
+			op.colorFn = colorFn
+		} else {
+			op.percentColorFn = colorFn
+		}
+	}
+
+	return frame{
+		index:   index,
+		op:      op,
+		js:      s.jsonStatus("running", s.renderedMessage(*msg)),
+		sub:     s.subMessage,
+		oscPct:  int(s.percent),
+		emitOSC: s.emitOSCProgress && s.percentSet,
+	}
+}
+
+// lastIndex returns the index of the last painted character, as the index is
+// advanced past it when it's painted. The caller must hold the mutex, and there
+// must be at least one character.
+func (s *Spinner) lastIndex() int {
+	step := 1
+	if s.backward {
+		step = -1
+	}
+
+	n := len(s.chars)
+
+	return (s.index - step + n) % n
+}
+
+// renderUpdate writes the frame to w, preceded by the sequences erasing the
+// previous frame. It returns the length of the line, for dumb terminal
+// erasure, and whether the sub message line was rendered. This must only be
+// called by the painter, by Render() when rendering manually, or when the
+// spinner is stopped, as it uses the state of the previous frame.
+func (s *Spinner) renderUpdate(w io.Writer, f frame) (printLen int, subLine bool) {
+	op, sub := f.op, f.sub
+	op.writer = w
+
 	if termModeForceSmart(s.termMode) {
+		if s.stickyBottom {
+			if err := moveToBottom(w); err != nil {
+				panic(fmt.Sprintf("failed to move cursor: %v", err))
+			}
+		}
+
+		if s.lastSubLine {
+			if err := eraseSubLine(w); err != nil {
+				panic(fmt.Sprintf("failed to erase line: %v", err))
+			}
+		}
+
+		if err := erase(w); err != nil {
+			panic(fmt.Sprintf("failed to erase line: %v", err))
+		}
+
+		if s.cursorHidden {
+			if err := hideCursor(w); err != nil {
+				panic(fmt.Sprintf("failed to hide cursor: %v", err))
+			}
+		}
+
+		if _, err := paint(op); err != nil {
+			panic(fmt.Sprintf("failed to paint line: %v", err))
+		}
+
+		if len(sub) > 0 && !s.stickyBottom {
+			if _, err := fmt.Fprint(w, op.lineEnd()+truncateLine(subMessageIndent+sub, op.maxLineLength, op.width)); err != nil {
+				panic(fmt.Sprintf("failed to paint line: %v", err))
+			}
+
+			subLine = true
+		}
+
+		if f.emitOSC {
+			if err := oscProgress(w, f.oscPct); err != nil {
+				panic(fmt.Sprintf("failed to write progress sequence: %v", err))
+			}
+		}
+
+		if s.stickyBottom {
+			if err := restoreCursor(w); err != nil {
+				panic(fmt.Sprintf("failed to move cursor: %v", err))
+			}
+		}
+	} else {
+		if err := s.eraseDumbTerm(w); err != nil {
+			panic(fmt.Sprintf("failed to erase line: %v", err))
+		}
+
+		if s.jsonMode {
+			if err := paintJSON(w, f.js, op.lineEnd()); err != nil {
+				panic(fmt.Sprintf("failed to paint line: %v", err))
+			}
+		} else {
+			n, err := paint(s.plain(op))
+			if err != nil {
+				panic(fmt.Sprintf("failed to paint line: %v", err))
+			}
+
+			printLen = n
+
+			// non-TTY outputs aren't erased, so the sub message can be
+			// printed on its own line
+			if len(sub) > 0 && op.notTTY {
+				if _, err := fmt.Fprint(w, op.formatNoTTY(truncateLine(subMessageIndent+sub, op.maxLineLength, op.width))+op.lineEnd()); err != nil {
+					panic(fmt.Sprintf("failed to paint line: %v", err))
+				}
+			}
+		}
+	}
+
+	return printLen, subLine
+}
+
+// LogMessage prints message on its own line above the spinner, which then
+// continues to be rendered below it. While the spinner is running or paused,
+// the message is handed to the painting goroutine so that it's printed between
+// two frames, and never in the middle of one, even when called concurrently
+// with other methods like Stop(). If the spinner isn't running, the message is
+// written directly to the Writer, and any error writing it is returned.
+//
+// If the spinner was started using StartManual(), the message is printed
+// synchronously, so like Render() this must not be called concurrently with
+// Render(), Stop(), or StopFail().
+func (s *Spinner) LogMessage(message string) error {
+	s.mu.Lock()
+	manual, done := s.manual, s.doneCh
+	s.mu.Unlock()
+
+	if st := s.Status(); manual && (st == SpinnerRunning || st == SpinnerPaused) {
+		s.paintLog(logEntry{message: message})
+		return nil
+	}
+
+	if done != nil {
+		select {
+		case s.logCh <- logEntry{message: message}:
+			return nil
+		case <-done:
+			// the painter stopped, so write the message ourselves
+		}
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := s.writeLog(buf, message); err != nil {
+		return fmt.Errorf("failed to write log message: %w", err)
+	}
+
+	if _, err := s.write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write log message: %w", err)
+	}
+
+	return nil
+}
+
+// Complete finalizes the current step of a checklist, by printing the
+// StopCharacter with the message using the StopColors on its own line, like
+// Stop() would. Unlike Stop(), the spinner then continues to be rendered below
+// the completed line, for the next step. Like LogMessage(), the line is printed
+// by the painting goroutine between two frames. The only possible error is if
+// the spinner isn't running or paused.
+//
+// If the spinner was started using StartManual(), the line is printed
+// synchronously, so like Render() this must not be called concurrently with
+// Render(), Stop(), or StopFail().
+func (s *Spinner) Complete(message string) error {
+	s.mu.Lock()
+	manual, done := s.manual, s.doneCh
+	s.mu.Unlock()
+
+	if st := s.Status(); st != SpinnerRunning && st != SpinnerPaused {
+		return ErrNotRunning
+	}
+
+	e := logEntry{message: message, complete: true}
+
+	if manual {
+		s.paintLog(e)
+		return nil
+	}
+
+	select {
+	case s.logCh <- e:
+		return nil
+	case <-done:
+		return ErrNotRunning
+	}
+}
+
+// logEntry is a line for the painter to print above the spinner
+type logEntry struct {
+	message  string
+	complete bool // printed like the stop line, using Complete()
+}
+
+// eraseFrame erases the current frame, and drops any frame held back due to
+// the MaxRedrawRate, leaving the terminal as it was before the spinner started
+// rendering.
+func (s *Spinner) eraseFrame() {
+	s.pending = s.pending[:0]
+
+	if termModeForceNoTTY(s.termMode) {
+		return
+	}
+
+	defer s.buffer.Reset()
+
+	if termModeForceSmart(s.termMode) {
+		if s.stickyBottom {
+			if err := moveToBottom(s.buffer); err != nil {
+				panic(fmt.Sprintf("failed to move cursor: %v", err))
+			}
+		}
+
+		if s.lastSubLine {
+			if err := eraseSubLine(s.buffer); err != nil {
+				panic(fmt.Sprintf("failed to erase line: %v", err))
+			}
+		}
+
 		if err := erase(s.buffer); err != nil {
 			panic(fmt.Sprintf("failed to erase line: %v", err))
 		}
 
-		if s.cursorHidden {
-			if err := hideCursor(s.buffer); err != nil {
-				panic(fmt.Sprintf("failed to hide cursor: %v", err))
-			}
-		}
+		if s.stickyBottom {
+			if err := restoreCursor(s.buffer); err != nil {
+				panic(fmt.Sprintf("failed to move cursor: %v", err))
+			}
+		}
+	} else if err := s.eraseDumbTerm(s.buffer); err != nil {
+		panic(fmt.Sprintf("failed to erase line: %v", err))
+	}
+
+	if s.output(s.buffer.Bytes()) {
+		s.lastPrintLen = 0
+		s.lastSubLine = false
+	}
+}
+
+// paintHeartbeat prints the HeartbeatMessage like a log message, or the
+// current frame if it's not set
+func (s *Spinner) paintHeartbeat() {
+	if len(s.heartbeatMsg) > 0 {
+		s.paintLog(logEntry{message: s.heartbeatMsg})
+		return
+	}
+
+	s.paintUpdate(nil, false)
+}
+
+// paintLog erases the current frame, prints the log entry in its place, and
+// then renders the frame again below it. This must only be called by the
+// painter, or by LogMessage() and Complete() when rendering manually.
+func (s *Spinner) paintLog(e logEntry) {
+	// the frame will be rendered again, so drop any held back frame
+	s.pending = s.pending[:0]
+
+	if termModeForceSmart(s.termMode) {
+		if !s.stickyBottom {
+			if s.lastSubLine {
+				if err := eraseSubLine(s.buffer); err != nil {
+					panic(fmt.Sprintf("failed to erase line: %v", err))
+				}
+			}
+
+			if err := erase(s.buffer); err != nil {
+				panic(fmt.Sprintf("failed to erase line: %v", err))
+			}
+		}
+	} else if err := s.eraseDumbTerm(s.buffer); err != nil {
+		panic(fmt.Sprintf("failed to erase line: %v", err))
+	}
+
+	if e.complete {
+		if err := s.writeComplete(s.buffer, e.message); err != nil {
+			panic(fmt.Sprintf("failed to paint line: %v", err))
+		}
+	} else if err := s.writeLog(s.buffer, e.message); err != nil {
+		panic(fmt.Sprintf("failed to write log message: %v", err))
+	}
+
+	ok := s.output(s.buffer.Bytes())
+
+	s.buffer.Reset()
+
+	if !ok {
+		return
+	}
+
+	// the frame was replaced by the message
+	s.lastPrintLen = 0
+	s.lastSubLine = false
+
+	// the write above isn't subject to the MaxRedrawRate, so this one isn't
+	// either
+	s.lastWrite = time.Time{}
+
+	// non-TTY outputs aren't erased, so the frame is still there, and nothing
+	// is rendered while PauseRendering() is in effect
+	if termModeForceNoTTY(s.termMode) || s.renderPaused {
+		return
+	}
+
+	s.paintUpdate(nil, false)
+}
+
+// writeLog writes the log message to w as its own line, which is a JSON object
+// if the JSONMode Config field is set
+func (s *Spinner) writeLog(w io.Writer, message string) error {
+	newline := s.newline
+	if len(newline) == 0 {
+		newline = "\n"
+	}
+
+	if s.jsonMode {
+		s.mu.Lock()
+		js := s.jsonStatus("log", message)
+		s.mu.Unlock()
+
+		return paintJSON(w, js, newline)
+	}
+
+	if s.noTTYFormat != nil && termModeForceNoTTY(s.termMode) {
+		lines := strings.Split(message, "\n")
+
+		for i, line := range lines {
+			lines[i] = s.noTTYFormat(line)
+		}
+
+		message = strings.Join(lines, "\n")
+	}
+
+	_, err := fmt.Fprint(w, message+newline)
+
+	return err
+}
+
+// writeComplete writes the line of a completed step to w, using the
+// StopCharacter and StopColors, which is a JSON object if the JSONMode Config
+// field is set
+func (s *Spinner) writeComplete(w io.Writer, message string) error {
+	s.mu.Lock()
+	op := s.paintOp(s.stopChar, message, s.stopColorFn, true)
+	fallback := s.stopCharFallback
+	js := s.jsonStatus("completed", message)
+	s.mu.Unlock()
+
+	op.writer = w
+
+	if s.jsonMode {
+		return paintJSON(w, js, op.lineEnd())
+	}
+
+	if !termModeForceSmart(s.termMode) {
+		op = s.plain(op)
+
+		if len(fallback.Value) > 0 {
+			op.char = fallback
+
+			if fallback.Size > op.maxWidth {
+				op.maxWidth = fallback.Size
+			}
+		}
+	}
+
+	_, err := paint(op)
+
+	return err
+}
+
+// parallelChar returns the character at index, followed by the next count-1
+// characters of chars, as a single character
+func parallelChar(chars []character, index, count int) character {
+	if count <= 1 {
+		return chars[index]
+	}
+
+	var c character
+
+	for i := 0; i < count; i++ {
+		pc := chars[(index+i)%len(chars)]
+
+		c.Value += pc.Value
+		c.Size += pc.Size
+	}
+
+	return c
+}
+
+// writeFrame writes the frame in the buffer to the writer. If the last frame
+// was written more recently than the MaxRedrawRate allows, the frame is instead
+// held back until flushPending() is called, replacing any frame already held.
+// The printLen is the length of the frame's line, used for erasing it later
+// on dumb terminals, and subLine is whether the frame includes the sub message
+// line, which also needs to be erased later.
+func (s *Spinner) writeFrame(printLen int, subLine bool) {
+	if s.maxRedrawRate > 0 && time.Since(s.lastWrite) < s.maxRedrawRate {
+		s.pending = append(s.pending[:0], s.buffer.Bytes()...)
+		s.pendingPrintLen = printLen
+		s.pendingSubLine = subLine
+		return
+	}
+
+	s.pending = s.pending[:0]
+
+	if !s.output(s.buffer.Bytes()) {
+		return
+	}
+
+	atomic.AddUint64(&s.framesRendered, 1)
+	s.markRendered()
+
+	s.lastPrintLen = printLen
+	s.lastSubLine = subLine
+	s.lastWrite = time.Now()
+}
+
+// markRendered unblocks WaitRendered() once the first frame has been written
+// since the spinner was started.
+func (s *Spinner) markRendered() {
+	if s.rendered {
+		return
+	}
+
+	s.rendered = true
+
+	s.mu.Lock()
+	if s.renderedCh != nil {
+		close(s.renderedCh)
+	}
+	s.mu.Unlock()
+}
+
+// WaitRendered blocks until the first frame has been written to the Writer
+// since the spinner was started, which is useful in tests or when taking
+// screenshots. It returns an error if the spinner isn't running or paused, if
+// it's stopped before rendering a frame, or if the timeout is reached. Nothing
+// is rendered when SilentWhenNotTTY applies, so in that case this always
+// times out. The timeout must be greater than 0.
+func (s *Spinner) WaitRendered(timeout time.Duration) error {
+	if timeout < 1 {
+		return errors.New("timeout must be greater than 0")
+	}
+
+	s.mu.Lock()
+	rendered, done := s.renderedCh, s.doneCh
+	s.mu.Unlock()
+
+	if rendered == nil {
+		return ErrNotRunning
+	}
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case <-rendered:
+		return nil
+	case <-done:
+		// the painter may have rendered a frame right before stopping
+		select {
+		case <-rendered:
+			return nil
+		default:
+			return errors.New("spinner stopped before rendering a frame")
+		}
+	case <-t.C:
+		return fmt.Errorf("timed out after %s waiting for the spinner to render a frame", timeout)
+	}
+}
+
+// flushPending writes the frame held back by writeFrame(), if there is one.
+func (s *Spinner) flushPending() {
+	if len(s.pending) == 0 {
+		return
+	}
+
+	defer func() { s.pending = s.pending[:0] }()
+
+	if !s.output(s.pending) {
+		return
+	}
+
+	atomic.AddUint64(&s.framesRendered, 1)
+	s.markRendered()
+
+	s.lastPrintLen = s.pendingPrintLen
+	s.lastSubLine = s.pendingSubLine
+	s.lastWrite = time.Now()
+}
+
+// writerLocks are the locks shared by spinners with SingleWrite set, by Writer.
+// An entry only exists while a spinner is writing, or waiting to write, so
+// that Writers which are no longer used don't stay in the map.
+var writerLocks = struct {
+	mu    sync.Mutex
+	locks map[interface{}]*writerLockEntry
+}{locks: make(map[interface{}]*writerLockEntry)}
+
+// writerLockEntry is a lock in writerLocks, and the number of spinners using it
+type writerLockEntry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// writerKey returns the key of w in writerLocks, or nil if w can't be used as
+// a map key. A Writer of a comparable type can still hold values that aren't,
+// like a struct with an interface field set to a slice, which panic when
+// hashed, so that's checked too.
+func writerKey(w io.Writer) (key interface{}) {
+	if !reflect.TypeOf(w).Comparable() {
+		return nil
+	}
+
+	defer func() {
+		if recover() != nil {
+			key = nil
+		}
+	}()
+
+	_ = map[interface{}]struct{}{w: {}}
+
+	return w
+}
+
+// lockWriter locks the lock shared by spinners writing to the Writer with the
+// key, and returns the function unlocking it
+func lockWriter(key interface{}) (unlock func()) {
+	writerLocks.mu.Lock()
+
+	l, ok := writerLocks.locks[key]
+	if !ok {
+		l = &writerLockEntry{}
+		writerLocks.locks[key] = l
+	}
+
+	l.refs++
+
+	writerLocks.mu.Unlock()
+
+	l.mu.Lock()
+
+	return func() {
+		l.mu.Unlock()
+
+		writerLocks.mu.Lock()
+		defer writerLocks.mu.Unlock()
+
+		if l.refs--; l.refs == 0 {
+			delete(writerLocks.locks, key)
+		}
+	}
+}
+
+// writerFunc is an adapter to use a function as an io.Writer
+type writerFunc func(b []byte) (int, error)
+
+func (fn writerFunc) Write(b []byte) (int, error) { return fn(b) }
+
+// write writes b to the writer of the spinner in a single call, holding the
+// lock shared with other spinners if SingleWrite is set. The writer is then
+// flushed if FlushAfterWrite is set. If the Logger Config field applies, b is
+// printed using it instead of the writer. Finally, b is written to the writers
+// added using AddWriter() and AddPlainWriter().
+func (s *Spinner) write(b []byte) (int, error) {
+	if s.writeKey != nil {
+		defer lockWriter(s.writeKey)()
+	}
+
+	var n int
+	var err error
+
+	if s.logger != nil && termModeForceNoTTY(s.termMode) {
+		logLines(s.logger, b)
+		n = len(b)
+	} else {
+		n, err = s.writer.Write(b)
+		if err == nil && s.flushAfterWrite {
+			err = flush(s.writer)
+		}
+	}
+
+	s.writersMu.Lock()
+	writers := s.writers
+	s.writersMu.Unlock()
+
+	var plain []byte
+
+	for _, aw := range writers {
+		out := b
+
+		if aw.plain {
+			if plain == nil {
+				plain = plainOutput(b)
+			}
+
+			if len(plain) == 0 {
+				continue
+			}
+
+			out = plain
+		}
+
+		// errors are ignored, so that a failing writer doesn't affect the
+		// others
+		if _, werr := aw.w.Write(out); werr == nil && s.flushAfterWrite {
+			_ = flush(aw.w)
+		}
+	}
+
+	return n, err
+}
+
+// addedWriter is a Writer added using AddWriter() or AddPlainWriter()
+type addedWriter struct {
+	w     io.Writer
+	plain bool // write the output as plain lines of text; see plainOutput()
+}
+
+// AddWriter adds a Writer that everything written to the Writer in the Config
+// is also written to, like a log file recording the output of the spinner.
+// The output is the same for all writers, so it includes the escape sequences
+// for the terminal mode of the spinner; see AddPlainWriter() to leave them
+// out. Errors returned by added writers are ignored, so that a failing writer
+// doesn't affect the others, and only errors returned by the Writer in the
+// Config are handled. This is safe to call while the spinner is running.
+func (s *Spinner) AddWriter(w io.Writer) {
+	s.addWriter(addedWriter{w: w})
+}
+
+// AddPlainWriter is like AddWriter(), except that the escape sequences are
+// removed from the output written to w, and each frame is written on its own
+// line like when not running within a TTY.
+func (s *Spinner) AddPlainWriter(w io.Writer) {
+	s.addWriter(addedWriter{w: w, plain: true})
+}
+
+func (s *Spinner) addWriter(aw addedWriter) {
+	s.writersMu.Lock()
+	defer s.writersMu.Unlock()
+
+	// copy on write, as write() iterates over the slice without the lock
+	writers := make([]addedWriter, len(s.writers), len(s.writers)+1)
+	copy(writers, s.writers)
+
+	s.writers = append(writers, aw)
+}
+
+// plainOutput converts the output written to a terminal into plain lines of
+// text, by removing the escape sequences and writing each of the lines erased
+// using carriage returns on its own line. Lines with only whitespace, like
+// those used to erase frames on dumb terminals, are dropped.
+func plainOutput(b []byte) []byte {
+	text := escapeRe.ReplaceAllString(string(b), "")
 
-		op := paintOp{
-			writer:          s.buffer,
-			maxWidth:        mw,
-			char:            c,
-			prefix:          p,
-			message:         m,
-			suffix:          suf,
-			suffixAutoColon: s.suffixAutoColon,
-			colorAll:        s.colorAll,
-			spinnerAtEnd:    s.spinnerAtEnd,
-			finalPaint:      false,
-			notTTY:          termModeForceNoTTY(s.termMode),
-			colorFn:         cFn,
-		}
+	lines := strings.FieldsFunc(text, func(r rune) bool { return r == '\r' || r == '\n' })
 
-		if _, err := paint(op); err != nil {
-			panic(fmt.Sprintf("failed to paint line: %v", err))
-		}
-	} else {
-		if err := s.eraseDumbTerm(s.buffer); err != nil {
-			panic(fmt.Sprintf("failed to erase line: %v", err))
-		}
+	out := make([]byte, 0, len(text)+1)
 
-		op := paintOp{
-			writer:          s.buffer,
-			maxWidth:        mw,
-			char:            c,
-			prefix:          p,
-			message:         m,
-			suffix:          suf,
-			suffixAutoColon: s.suffixAutoColon,
-			colorAll:        false,
-			spinnerAtEnd:    s.spinnerAtEnd,
-			finalPaint:      false,
-			notTTY:          termModeForceNoTTY(s.termMode),
-			colorFn:         fmt.Sprintf,
+	for _, line := range lines {
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
 		}
 
-		n, err := paint(op)
-		if err != nil {
-			panic(fmt.Sprintf("failed to paint line: %v", err))
-		}
+		out = append(out, line...)
+		out = append(out, '\n')
+	}
+
+	return out
+}
 
-		s.lastPrintLen = n
+// flush flushes w if it has a Flush() or Sync() method, ignoring the errors
+// returned by Sync() as it fails for terminals and pipes
+func flush(w io.Writer) error {
+	switch fw := w.(type) {
+	case interface{ Flush() error }:
+		return fw.Flush()
+	case interface{ Sync() error }:
+		_ = fw.Sync()
 	}
 
-	if s.buffer.Len() > 0 {
-		if _, err := s.writer.Write(s.buffer.Bytes()); err != nil {
+	return nil
+}
+
+// output writes b to the writer, returning whether it succeeded. If the
+// MaxWriteErrors Config field isn't set, a failed write panics. Otherwise, the
+// failure is counted and the error recorded for LastError().
+func (s *Spinner) output(b []byte) bool {
+	if _, err := s.write(b); err != nil {
+		if s.maxWriteErrors == 0 {
 			panic(fmt.Sprintf("failed to output buffer to writer: %v", err))
 		}
-	}
 
-	if animate {
-		timer.Reset(d)
+		s.writeErrors++
+
+		s.mu.Lock()
+		s.lastErr = err
+		s.mu.Unlock()
+
+		return false
 	}
+
+	s.writeErrors = 0
+
+	return true
 }
 
 func (s *Spinner) paintStop(chanOk bool) {
-	var m string
-	var c character
+	var m, status string
+	var c, fallback character
 	var cFn func(format string, a ...interface{}) string
+	var link bool
 
 	s.mu.Lock()
 
-	if chanOk {
+	if o, ok := s.outcomes[s.stopOutcome]; chanOk && ok {
+		c = o.char
+		cFn = o.colorFn
+		m = o.msg
+		status = s.stopOutcome
+	} else if chanOk {
 		c = s.stopChar
+		fallback = s.stopCharFallback
 		cFn = s.stopColorFn
 		m = s.stopMsg
+		status = "stopped"
+		link = len(s.stopMessageURL) > 0 && len(m) > 0 && termModeForceSmart(s.termMode)
 	} else {
 		c = s.stopFailChar
+		fallback = s.stopFailCharFallback
 		cFn = s.stopFailColorFn
 		m = s.stopFailMsg
+		status = "failed"
+	}
+
+	s.publish(SpinnerEvent{Type: SpinnerEventStop, Message: m, Outcome: status})
+
+	if link {
+		m = hyperlink(s.stopMessageURL, m)
 	}
 
-	p := s.prefix
-	suf := s.suffix
-	mw := s.maxWidth
+	op := s.paintOp(c, m, cFn, true)
+	op.blink = !chanOk && s.stopFailBlink
+	op.noLineEnd = s.stopStyle == stopStyleInline
+	preserve := s.stopStyle == stopStylePreserve
+	js := s.jsonStatus(status, m)
+	emitOSC := s.emitOSCProgress && s.percentSet
+	custom := s.stopPrint
 
 	s.mu.Unlock()
 
 	defer s.buffer.Reset()
 
 	if termModeForceSmart(s.termMode) {
-		if err := erase(s.buffer); err != nil {
+		if s.altScreen {
+			if err := leaveAltScreen(s.buffer); err != nil {
+				panic(fmt.Sprintf("failed to leave alternate screen: %v", err))
+			}
+		}
+
+		// when leaving the alternate screen the sub message line is
+		// discarded with it
+		if s.lastSubLine && !s.altScreen {
+			if err := eraseSubLine(s.buffer); err != nil {
+				panic(fmt.Sprintf("failed to erase line: %v", err))
+			}
+		}
+
+		s.lastSubLine = false
+
+		if s.stickyBottom {
+			// clear the bottom line, and print the final line where the
+			// cursor is instead
+			if err := moveToBottom(s.buffer); err != nil {
+				panic(fmt.Sprintf("failed to move cursor: %v", err))
+			}
+
+			if err := erase(s.buffer); err != nil {
+				panic(fmt.Sprintf("failed to erase line: %v", err))
+			}
+
+			if err := restoreCursor(s.buffer); err != nil {
+				panic(fmt.Sprintf("failed to move cursor: %v", err))
+			}
+		} else if preserve && !s.altScreen {
+			// move past the last frame, instead of erasing it
+			if _, err := fmt.Fprint(s.buffer, op.lineEnd()); err != nil {
+				panic(fmt.Sprintf("failed to print line: %v", err))
+			}
+		} else if err := erase(s.buffer); err != nil {
 			panic(fmt.Sprintf("failed to erase line: %v", err))
 		}
 
@@ -874,60 +3788,226 @@ func (s *Spinner) paintStop(chanOk bool) {
 			}
 		}
 
-		if c.Size > 0 || len(m) > 0 {
-			op := paintOp{
-				writer:          s.buffer,
-				maxWidth:        mw,
-				char:            c,
-				prefix:          p,
-				message:         m,
-				suffix:          suf,
-				suffixAutoColon: s.suffixAutoColon,
-				colorAll:        s.colorAll,
-				spinnerAtEnd:    s.spinnerAtEnd,
-				finalPaint:      true,
-				notTTY:          termModeForceNoTTY(s.termMode),
-				colorFn:         cFn,
+		if emitOSC {
+			if err := oscProgressClear(s.buffer); err != nil {
+				panic(fmt.Sprintf("failed to write progress sequence: %v", err))
 			}
+		}
 
+		if custom != nil {
+			if _, err := fmt.Fprint(s.buffer, *custom); err != nil {
+				panic(fmt.Sprintf("failed to print line: %v", err))
+			}
+		} else if c.Size > 0 || len(m) > 0 {
 			if _, err := paint(op); err != nil {
 				panic(fmt.Sprintf("failed to paint line: %v", err))
 			}
 		}
 	} else {
-		if err := s.eraseDumbTerm(s.buffer); err != nil {
+		if preserve && !termModeForceNoTTY(s.termMode) && !termModeForceTest(s.termMode) {
+			// move past the last frame, if there is one, instead of erasing
+			// it; non-TTY and test outputs already end each frame with one
+			if s.lastPrintLen > 0 {
+				if _, err := fmt.Fprint(s.buffer, op.lineEnd()); err != nil {
+					panic(fmt.Sprintf("failed to print line: %v", err))
+				}
+			}
+		} else if err := s.eraseDumbTerm(s.buffer); err != nil {
 			panic(fmt.Sprintf("failed to erase line: %v", err))
 		}
 
-		if c.Size > 0 || len(m) > 0 {
-			op := paintOp{
-				writer:          s.buffer,
-				maxWidth:        mw,
-				char:            c,
-				prefix:          p,
-				message:         m,
-				suffix:          suf,
-				suffixAutoColon: s.suffixAutoColon,
-				colorAll:        false,
-				spinnerAtEnd:    s.spinnerAtEnd,
-				finalPaint:      true,
-				notTTY:          termModeForceNoTTY(s.termMode),
-				colorFn:         fmt.Sprintf,
+		if custom != nil {
+			if _, err := fmt.Fprint(s.buffer, *custom); err != nil {
+				panic(fmt.Sprintf("failed to print line: %v", err))
+			}
+		} else if s.jsonMode {
+			if err := paintJSON(s.buffer, js, op.lineEnd()); err != nil {
+				panic(fmt.Sprintf("failed to paint line: %v", err))
 			}
+		} else if c.Size > 0 || len(m) > 0 || len(fallback.Value) > 0 {
+			dop := s.plain(op)
 
-			if _, err := paint(op); err != nil {
+			if len(fallback.Value) > 0 {
+				dop.char = fallback
+
+				if fallback.Size > dop.maxWidth {
+					dop.maxWidth = fallback.Size
+				}
+			}
+
+			if _, err := paint(dop); err != nil {
 				panic(fmt.Sprintf("failed to paint line: %v", err))
 			}
+
+			op = dop // so the StopLogger gets the printed character
 		}
 
 		s.lastPrintLen = 0
 	}
 
+	if bell := (chanOk && s.bellOnStop) || (!chanOk && s.bellOnStopFail); bell && !termModeForceNoTTY(s.termMode) {
+		if _, err := fmt.Fprint(s.buffer, "\a"); err != nil {
+			panic(fmt.Sprintf("failed to ring bell: %v", err))
+		}
+	}
+
 	if s.buffer.Len() > 0 {
-		if _, err := s.writer.Write(s.buffer.Bytes()); err != nil {
-			panic(fmt.Sprintf("failed to output buffer to writer: %v", err))
+		s.output(s.buffer.Bytes())
+	}
+
+	if s.stopLogger != nil {
+		s.stopLogger(plainStopLine(op, custom))
+	}
+}
+
+// plainStopLine renders the final line printed when stopping as plain text,
+// without colors, escape sequences, or the line terminator
+func plainStopLine(op paintOp, custom *string) string {
+	var line string
+
+	if custom != nil {
+		line = *custom
+	} else {
+		var b strings.Builder
+
+		op = op.dumb()
+		op.writer = &b
+		op.notTTY = false
+		op.finalPaint = false
+
+		_, _ = paint(op)
+
+		line = b.String()
+	}
+
+	return strings.TrimRight(escapeRe.ReplaceAllString(line, ""), "\r\n")
+}
+
+// paintOp builds the paintOp for rendering the line with the provided
+// character, message, and color function. The caller must hold the mutex.
+func (s *Spinner) paintOp(c character, message string, colorFn func(format string, a ...interface{}) string, finalPaint bool) paintOp {
+	fraction, pct, elapsed, remaining := s.progressTokens()
+
+	var timestamp string
+
+	if len(s.timestampFormat) > 0 {
+		timestamp = time.Now().Format(s.timestampFormat)
+	}
+
+	return paintOp{
+		writer:          s.buffer,
+		maxWidth:        s.maxWidth,
+		padChar:         s.padChar,
+		char:            c,
+		label:           s.label,
+		prefix:          s.prefix,
+		message:         message,
+		suffix:          s.suffix,
+		fraction:        fraction,
+		percent:         pct,
+		elapsed:         elapsed,
+		remaining:       remaining,
+		timestamp:       timestamp,
+		maxLineLength:   s.maxLineLength,
+		width:           s.width,
+		newline:         s.newline,
+		suffixAutoColon: s.suffixAutoColon,
+		autoSpace:       s.autoSpace,
+		trimTrailing:    s.trimTrailing,
+		colorAll:        s.colorAll,
+		spinnerAtEnd:    s.spinnerAtEnd,
+		finalPaint:      finalPaint,
+		notTTY:          termModeForceNoTTY(s.termMode) || termModeForceTest(s.termMode),
+		noTTYFormat:     s.noTTYFormat,
+		colorFn:         colorFn,
+		suffixColorFn:   s.suffixColorFn,
+		template:        s.template,
+	}
+}
+
+// jsonStatus is a single line written by the spinner in JSON mode
+type jsonStatus struct {
+	Status    string `json:"status"`
+	Message   string `json:"message"`
+	ElapsedMS int64  `json:"elapsed_ms"`
+}
+
+// jsonStatus builds the jsonStatus for the line with the provided status and
+// message. The caller must hold the mutex.
+func (s *Spinner) jsonStatus(status, message string) jsonStatus {
+	js := jsonStatus{
+		Status:  status,
+		Message: message,
+	}
+
+	if !s.startTime.IsZero() {
+		js.ElapsedMS = time.Since(s.startTime).Milliseconds()
+	}
+
+	return js
+}
+
+// paintJSON writes the jsonStatus to w as a single line, terminated by newline
+func paintJSON(w io.Writer, js jsonStatus, newline string) error {
+	b, err := json.Marshal(js)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(w, string(b)+newline)
+	return err
+}
+
+// progressTokens returns the rendered current/total amounts, percent, elapsed
+// time, and remaining time, if they are configured to be shown. The caller
+// must hold the mutex.
+func (s *Spinner) progressTokens() (fraction, percent, elapsed, remaining string) {
+	if s.total > 0 {
+		fraction = formatAmount(s.current, s.progressUnit) + "/" + formatAmount(s.total, s.progressUnit)
+	}
+
+	if s.showPercent {
+		percent = fmt.Sprintf("%d%%", int(s.percent))
+	}
+
+	if s.showElapsed && !s.startTime.IsZero() {
+		elapsed = time.Since(s.startTime).Truncate(time.Second).String()
+	}
+
+	if s.showRemaining && !s.deadline.IsZero() {
+		d := time.Until(s.deadline).Round(time.Second)
+		if d < 0 {
+			d = 0
 		}
+
+		remaining = d.String()
+	}
+
+	return fraction, percent, elapsed, remaining
+}
+
+// byteUnits are the binary prefixed units used to humanize byte amounts
+var byteUnits = [...]string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
+
+// formatAmount renders the amount followed by the unit, humanizing it with
+// binary prefixes if the unit is "bytes" (e.g., 1536 renders as 1.5KB)
+func formatAmount(n int64, unit string) string {
+	if unit != "bytes" {
+		return strconv.FormatInt(n, 10) + unit
+	}
+
+	if n < 1024 {
+		return strconv.FormatInt(n, 10) + byteUnits[0]
+	}
+
+	v, i := float64(n), 0
+
+	for v >= 1024 && i < len(byteUnits)-1 {
+		v /= 1024
+		i++
 	}
+
+	return strings.TrimSuffix(strconv.FormatFloat(v, 'f', 1, 64), ".0") + byteUnits[i]
 }
 
 // erase clears the line
@@ -936,14 +4016,41 @@ func erase(w io.Writer) error {
 	return err
 }
 
-// eraseDumbTerm clears the line on dumb terminals
+// subMessageIndent is the indentation of the sub message line
+const subMessageIndent = "  "
+
+// eraseSubLine clears the sub message line, and moves the cursor up to the
+// line above it
+func eraseSubLine(w io.Writer) error {
+	_, err := fmt.Fprint(w, "\r\033[K\033[1A")
+	return err
+}
+
+// eraseDumbTerm clears the line on dumb terminals, or moves to a new line if
+// DumbEraseNewline is set
 func (s *Spinner) eraseDumbTerm(w io.Writer) error {
-	if termModeForceNoTTY(s.termMode) {
-		// non-TTY outputs use \n instead of line erasure,
+	if termModeForceNoTTY(s.termMode) || termModeForceTest(s.termMode) {
+		// non-TTY and test outputs use \n instead of line erasure,
 		// so return early
 		return nil
 	}
 
+	if s.eraseNewline {
+		// move past the previous frame, if there is one, instead of
+		// overwriting it
+		if s.lastPrintLen == 0 {
+			return nil
+		}
+
+		newline := s.newline
+		if len(newline) == 0 {
+			newline = "\n"
+		}
+
+		_, err := fmt.Fprint(w, newline)
+		return err
+	}
+
 	clear := "\r" + strings.Repeat(" ", s.lastPrintLen) + "\r"
 
 	_, err := fmt.Fprint(w, clear)
@@ -955,64 +4062,412 @@ func hideCursor(w io.Writer) error {
 	return err
 }
 
-func unhideCursor(w io.Writer) error {
-	_, err := fmt.Fprint(w, "\r\033[?25h\r")
-	return err
+func unhideCursor(w io.Writer) error {
+	_, err := fmt.Fprint(w, "\r\033[?25h\r")
+	return err
+}
+
+// moveToBottom saves the cursor position, and moves it to the start of the
+// bottom line of the terminal
+func moveToBottom(w io.Writer) error {
+	_, err := fmt.Fprint(w, "\033[s\033[9999;1H")
+	return err
+}
+
+// restoreCursor restores the cursor position saved by moveToBottom()
+func restoreCursor(w io.Writer) error {
+	_, err := fmt.Fprint(w, "\033[u")
+	return err
+}
+
+// hyperlinkClose ends an OSC 8 hyperlink
+const hyperlinkClose = "\033]8;;\033\\"
+
+// hyperlink wraps text in an OSC 8 hyperlink to url
+func hyperlink(url, text string) string {
+	return "\033]8;;" + url + "\033\\" + text + hyperlinkClose
+}
+
+func enterAltScreen(w io.Writer) error {
+	_, err := fmt.Fprint(w, "\033[?1049h")
+	return err
+}
+
+func leaveAltScreen(w io.Writer) error {
+	_, err := fmt.Fprint(w, "\033[?1049l")
+	return err
+}
+
+// oscProgress sets the terminal's progress indicator to the percentage
+func oscProgress(w io.Writer, percent int) error {
+	_, err := fmt.Fprintf(w, "\033]9;4;1;%d\007", percent)
+	return err
+}
+
+// oscProgressClear removes the terminal's progress indicator
+func oscProgressClear(w io.Writer) error {
+	_, err := fmt.Fprint(w, "\033]9;4;0;0\007")
+	return err
+}
+
+// paddedChar returns the character of the paintOp padded to its maxWidth, and
+// made to blink if needed
+func (op paintOp) paddedChar() string {
+	c := op.char
+
+	if op.blink {
+		c.Value = "\033[5m" + c.Value + "\033[25m"
+	}
+
+	return padChar(c, op.maxWidth, op.padChar)
+}
+
+// padChar pads the spinner character so suffix / message offset from left is
+// consistent, using pad or a space if it's empty
+func padChar(char character, maxWidth int, pad string) string {
+	if len(pad) == 0 {
+		pad = " "
+	}
+
+	padSize := maxWidth - char.Size
+	return char.Value + strings.Repeat(pad, padSize)
+}
+
+// escapeRe matches the escape sequences the spinner writes: CSI sequences
+// (colors, erasing, cursor movement), OSC sequences (hyperlinks, progress),
+// and saving or restoring the cursor. Only SGR (color) and OSC 8 (hyperlink)
+// sequences may be part of a line.
+var escapeRe = regexp.MustCompile(`\x1b(?:\[[0-9;?]*[A-Za-z]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[78])`)
+
+// trimTrailingSpace removes the whitespace at the end of the line, including
+// any that's followed only by escape sequences (e.g., a colored suffix)
+func trimTrailingSpace(line string) string {
+	line = strings.TrimRightFunc(line, unicode.IsSpace)
+
+	locs := escapeRe.FindAllStringIndex(line, -1)
+
+	if n := len(locs); n > 0 && locs[n-1][1] == len(line) {
+		start := locs[n-1][0]
+		return trimTrailingSpace(line[:start]) + line[start:]
+	}
+
+	return line
+}
+
+// truncateLine truncates the line so that it's at most max columns wide,
+// replacing the end with an ellipsis. Any escape sequences in the line don't
+// count towards its width, and if the line contains any, sequences resetting
+// the color and closing the hyperlink are added after the ellipsis so they
+// don't bleed past it. Widths are measured using cond, or the runewidth
+// defaults if it's nil. If max is 0 the line is returned unmodified.
+func truncateLine(line string, max int, cond *runewidth.Condition) string {
+	if max < 1 || stringWidth(cond, escapeRe.ReplaceAllString(line, "")) <= max {
+		return line
+	}
+
+	var b strings.Builder
+	var width int
+
+	limit := max - 1 // leave room for the ellipsis
+	colored, linked := false, false
+
+	for len(line) > 0 {
+		if line[0] == '\x1b' {
+			if loc := escapeRe.FindStringIndex(line); loc != nil && loc[0] == 0 {
+				if line[1] == ']' {
+					linked = true
+				} else {
+					colored = true
+				}
+
+				b.WriteString(line[:loc[1]])
+				line = line[loc[1]:]
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(line)
+
+		rw := runeWidth(cond, r)
+		if width+rw > limit {
+			break
+		}
+
+		b.WriteRune(r)
+		width += rw
+		line = line[size:]
+	}
+
+	b.WriteString("…")
+
+	if colored {
+		b.WriteString("\x1b[0m")
+	}
+
+	if linked {
+		b.WriteString(hyperlinkClose)
+	}
+
+	return b.String()
+}
+
+// colorSegment colors a segment of the line using the provided color function,
+// returning the segment unmodified if the function is nil or it's empty
+func colorSegment(fn func(format string, a ...interface{}) string, segment string) string {
+	if fn == nil || len(segment) == 0 {
+		return segment
+	}
+
+	return fn("%s", segment)
+}
+
+// maxCachedChars is the maximum number of colored characters kept by a
+// segmentCache, which is only reached with very large character sets
+const maxCachedChars = 256
+
+// segmentCache holds the colored segments of the previous frames, so that
+// they're not colored again for every frame when only the spinner character
+// changes. It's reset whenever the data of the spinner changes, as the color
+// functions may have changed. A nil *segmentCache colors every segment.
+type segmentCache struct {
+	version uint64
+	chars   map[string]string // colored spinner characters; nil until reset
+	suffix  cachedSegment
+	message cachedSegment
+}
+
+// cachedSegment is a segment of the line, and its colored output
+type cachedSegment struct {
+	in, out string
+	key     interface{} // identifies the color function used for out
+	valid   bool
+}
+
+// reset clears the cache if it was built for a different version of the data,
+// and returns it
+func (c *segmentCache) reset(version uint64) *segmentCache {
+	if c.chars == nil || c.version != version || len(c.chars) >= maxCachedChars {
+		*c = segmentCache{
+			version: version,
+			chars:   make(map[string]string),
+		}
+	}
+
+	return c
+}
+
+// colorChar returns the spinner character colored using fn
+func (c *segmentCache) colorChar(fn func(format string, a ...interface{}) string, char string) string {
+	if c == nil {
+		return fn("%s", char)
+	}
+
+	out, ok := c.chars[char]
+	if !ok {
+		out = fn("%s", char)
+		c.chars[char] = out
+	}
+
+	return out
+}
+
+// colorSuffix returns the suffix colored using fn, like colorSegment()
+func (c *segmentCache) colorSuffix(fn func(format string, a ...interface{}) string, suffix string) string {
+	if c == nil {
+		return colorSegment(fn, suffix)
+	}
+
+	// the suffix color function is only changed under the mutex, along with
+	// the version of the data, so it doesn't need a key
+	return c.suffix.color(fn, nil, suffix)
+}
+
+// colorMessage returns the message colored using fn, like colorSegment(). The
+// key identifies fn, as the message color function is changed without the
+// mutex.
+func (c *segmentCache) colorMessage(fn func(format string, a ...interface{}) string, key *messageState, message string) string {
+	if c == nil {
+		return colorSegment(fn, message)
+	}
+
+	return c.message.color(fn, key, message)
+}
+
+// color returns the segment colored using fn, reusing the previous output if
+// the segment and the key identifying fn are unchanged
+func (cs *cachedSegment) color(fn func(format string, a ...interface{}) string, key interface{}, segment string) string {
+	if !cs.valid || cs.in != segment || cs.key != key {
+		cs.in, cs.out, cs.key, cs.valid = segment, colorSegment(fn, segment), key, true
+	}
+
+	return cs.out
+}
+
+// paint writes a single line to the w, using the provided character, message,
+// and color function
+func paint(op paintOp) (int, error) {
+	var output string
+
+	if len(op.template) > 0 {
+		output = renderTemplate(op)
+	} else {
+		output = renderLine(op)
+	}
+
+	if len(op.timestamp) > 0 {
+		output = op.timestamp + " " + output
+	}
+
+	if op.trimTrailing {
+		output = trimTrailingSpace(output)
+	}
+
+	output = truncateLine(output, op.maxLineLength, op.width)
+
+	if op.notTTY {
+		output = op.formatNoTTY(output)
+	}
+
+	if (op.finalPaint || op.notTTY) && !op.noLineEnd {
+		output += op.lineEnd()
+	}
+
+	return io.WriteString(op.writer, output)
+}
+
+// FrameOptions are the options used by RenderFrame() to render a single frame
+// of a spinner. The fields match their counterparts in the Config type.
+type FrameOptions struct {
+	// MaxWidth is the width the Character is padded to, so that the rest of
+	// the line doesn't move between frames. If it's less than the width of
+	// the Character, no padding is added.
+	MaxWidth int
+
+	// Character is the spinner character of the frame.
+	Character string
+
+	Prefix          string
+	Message         string
+	Suffix          string
+	Template        string
+	SuffixAutoColon bool
+	ColorAll        bool
+	SpinnerAtEnd    bool
+
+	// ColorFn is used to color the Character, or the whole line if ColorAll
+	// is true. If nil, the frame isn't colored. See ColorFunc().
+	ColorFn func(format string, a ...interface{}) string
+}
+
+// RenderFrame renders a single frame of a spinner, without a trailing newline,
+// the same way a *Spinner would. This is useful for composing your own output,
+// or benchmarking the rendering of frames.
+func RenderFrame(opts FrameOptions) string {
+	colorFn := opts.ColorFn
+	if colorFn == nil {
+		colorFn = fmt.Sprintf
+	}
+
+	char := character{Value: opts.Character, Size: charWidth(nil, opts.Character)}
+
+	maxWidth := opts.MaxWidth
+	if maxWidth < char.Size {
+		maxWidth = char.Size
+	}
+
+	var b strings.Builder
+
+	// writing to a strings.Builder never fails
+	_, _ = paint(paintOp{
+		writer:          &b,
+		maxWidth:        maxWidth,
+		char:            char,
+		prefix:          opts.Prefix,
+		message:         opts.Message,
+		suffix:          opts.Suffix,
+		template:        opts.Template,
+		suffixAutoColon: opts.SuffixAutoColon,
+		colorAll:        opts.ColorAll,
+		spinnerAtEnd:    opts.SpinnerAtEnd,
+		colorFn:         colorFn,
+	})
+
+	return b.String()
+}
+
+// renderLine renders the line using the default layout, starting with the
+// label
+func renderLine(op paintOp) string {
+	line := renderSegments(op)
+
+	if len(op.label) == 0 {
+		return line
+	}
+
+	if op.colorAll {
+		return op.colorFn("%s", op.label) + line
+	}
+
+	return op.label + line
 }
 
-// padChar pads the spinner character so suffix / message offset from left is
-// consistent
-func padChar(char character, maxWidth int) string {
-	padSize := maxWidth - char.Size
-	return char.Value + strings.Repeat(" ", padSize)
-}
+// renderSegments renders the segments of the line following the label
+func renderSegments(op paintOp) string {
+	pct := op.percent
+	if !op.colorAll {
+		pct = colorSegment(op.percentColorFn, pct)
+	}
 
-// paint writes a single line to the w, using the provided character, message,
-// and color function
-func paint(op paintOp) (int, error) {
-	var output string
+	for _, token := range [...]string{op.fraction, pct, op.elapsed, op.remaining} {
+		if len(token) == 0 {
+			continue
+		}
 
-	switch op.char.Size {
-	case 0:
-		if op.colorAll {
-			output = op.colorFn(op.message)
-			break
+		if len(op.message) > 0 {
+			op.message += " "
 		}
 
-		output = op.message
+		op.message += token
+	}
 
-	default:
-		c := padChar(op.char, op.maxWidth)
+	if op.char.Size == 0 {
+		if op.colorAll {
+			return op.colorFn("%s", op.message)
+		}
 
-		if op.spinnerAtEnd {
-			if op.colorAll {
-				output = op.colorFn("%s%s%s%s", op.message, op.prefix, c, op.suffix)
-				break
-			}
+		return op.message
+	}
 
-			output = fmt.Sprintf("%s%s%s%s", op.message, op.prefix, op.colorFn(c), op.suffix)
-			break
-		}
+	c := op.paddedChar()
 
-		if op.suffixAutoColon { // also implicitly !spinnerAtEnd
-			if len(strings.TrimSpace(op.suffix)) > 0 && len(op.message) > 0 && op.message != "\n" {
-				op.suffix += ": "
+	if op.spinnerAtEnd {
+		if op.autoSpace {
+			op.message = strings.TrimRight(op.message, " ")
+			op.prefix = strings.TrimLeft(op.prefix, " ")
+
+			if len(op.message) > 0 {
+				op.prefix = " " + op.prefix
 			}
 		}
 
 		if op.colorAll {
-			output = op.colorFn("%s%s%s%s", op.prefix, c, op.suffix, op.message)
-			break
+			return op.colorFn("%s%s%s%s", op.message, op.prefix, c, op.suffix)
 		}
 
-		output = fmt.Sprintf("%s%s%s%s", op.prefix, op.colorFn(c), op.suffix, op.message)
+		return op.segments.colorMessage(op.messageColorFn, op.messageState, op.message) + op.prefix + op.segments.colorChar(op.colorFn, c) + op.segments.colorSuffix(op.suffixColorFn, op.suffix)
 	}
 
-	if op.finalPaint || op.notTTY {
-		output += "\n"
+	if op.suffixAutoColon { // also implicitly !spinnerAtEnd
+		if len(strings.TrimSpace(op.suffix)) > 0 && len(op.message) > 0 && op.message != "\n" {
+			op.suffix += ": "
+		}
+	}
+
+	if op.colorAll {
+		return op.colorFn("%s%s%s%s", op.prefix, c, op.suffix, op.message)
 	}
 
-	return fmt.Fprint(op.writer, output)
+	return op.prefix + op.segments.colorChar(op.colorFn, c) + op.segments.colorSuffix(op.suffixColorFn, op.suffix) + op.segments.colorMessage(op.messageColorFn, op.messageState, op.message)
 }
 
 // Frequency updates the frequency of the spinner being animated.
@@ -1034,13 +4489,61 @@ func (s *Spinner) Frequency(d time.Duration) error {
 
 	// non-blocking notification
 	select {
-	case s.frequencyUpdateCh <- d:
+	case s.frequencyUpdateCh <- s.interval():
+	default:
+	}
+
+	return nil
+}
+
+// SetSpeed scales the speed of the animation by the multiplier, relative to
+// the Frequency, without changing the Frequency itself. For example, 2.0
+// animates the spinner twice as fast and 0.5 half as fast, while 1.0 restores
+// the speed of the Frequency. The multiplier must be greater than 0.
+func (s *Spinner) SetSpeed(multiplier float64) error {
+	if !(multiplier > 0) || math.IsInf(multiplier, 1) {
+		return errors.New("speed multiplier must be a finite number greater than 0")
+	}
+
+	if termModeForceNoTTY(s.termMode) {
+		// when output target is not a TTY, we don't animate spinner
+		// so there is no need to update the speed
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.speed = multiplier
+
+	// non-blocking notification
+	select {
+	case s.frequencyUpdateCh <- s.interval():
 	default:
 	}
 
 	return nil
 }
 
+// interval returns the time between frames of the animation, which is the
+// frequency scaled by the speed multiplier. The caller must hold the mutex.
+func (s *Spinner) interval() time.Duration {
+	if s.speed == 0 || s.speed == 1 {
+		return s.frequency
+	}
+
+	d := float64(s.frequency) / s.speed
+
+	switch {
+	case d >= math.MaxInt64:
+		return math.MaxInt64
+	case d < 1:
+		return 1
+	default:
+		return time.Duration(d)
+	}
+}
+
 // Prefix updates the Prefix before the spinner character.
 func (s *Spinner) Prefix(prefix string) {
 	s.mu.Lock()
@@ -1062,14 +4565,180 @@ func (s *Spinner) Suffix(suffix string) {
 	s.notifyDataChange()
 }
 
-// Message updates the Message displayed after the suffix.
+// SetSuffix updates the Suffix printed after the spinner character, and the
+// github.com/fatih/colors used to print it, at the same time. The colors are
+// validated before any changes are made. If no colors are provided the suffix
+// is printed without color.
+//
+// The suffix colors only apply when the ColorAll config parameter is false, as
+// otherwise the whole line is printed using the colors set by Colors().
+func (s *Spinner) SetSuffix(suffix string, colors ...string) error {
+	var colorFn func(format string, a ...interface{}) string
+
+	if len(colors) > 0 {
+		var err error
+
+		if colorFn, err = colorFunc(s.colorProfile, colors...); err != nil {
+			return fmt.Errorf("failed to build suffix color function: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.suffix = suffix
+	s.suffixColorFn = colorFn
+
+	s.notifyDataChange()
+
+	return nil
+}
+
+// SubMessage updates the SubMessage displayed on the line below the spinner.
+// Setting it to an empty string removes the line.
+func (s *Spinner) SubMessage(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subMessage = message
+
+	s.notifyDataChange()
+}
+
+// Message updates the Message displayed after the suffix. Unlike most other
+// methods updating the spinner, it never waits for the painter to finish
+// building a frame, so it's cheap to call at high rates.
 func (s *Spinner) Message(message string) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.storeMessage(messageState{text: message})
+}
+
+// storeMessage swaps the message for m, and notifies the painter and the
+// subscribers. The caller must hold notifyMu, but not necessarily the mutex.
+func (s *Spinner) storeMessage(m messageState) {
+	s.message.Store(&m)
+	s.sendEvent(SpinnerEvent{Type: SpinnerEventMessage, Message: m.text})
+
+	atomic.AddUint64(&s.dataVersion, 1)
+	s.sendDataUpdate()
+}
+
+// AppendMessage appends the provided string to the Message displayed after the
+// suffix. Unlike reading the current message and calling Message() with the
+// new value, this is done atomically so concurrent updates aren't lost.
+func (s *Spinner) AppendMessage(suffix string) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	m := s.loadMessage()
+	m.text += suffix
+
+	s.storeMessage(m)
+}
+
+// MessageReset updates the Message displayed after the suffix, and resets the
+// animation so that it restarts from the first frame of the character set.
+// This is useful when transitioning to a visually distinct phase of work.
+func (s *Spinner) MessageReset(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.index = 0
+	s.storeMessage(messageState{text: message})
+}
+
+// Deadline sets the time the work is expected to be done by, which the spinner
+// counts down to when the ShowRemaining Config field is set to true.
+func (s *Spinner) Deadline(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deadline = t
+
+	s.notifyDataChange()
+}
+
+// Percent updates the completion percentage rendered by the spinner, when the
+// ShowPercent Config field is set to true. The value must be between 0 and 100
+// (inclusive). If the EmitOSCProgress Config field is set to true, this also
+// updates the terminal's progress indicator.
+func (s *Spinner) Percent(percent float64) error {
+	if percent < 0 || percent > 100 {
+		return errors.New("percent must be between 0 and 100")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.percent = percent
+	s.percentSet = true
+	s.publish(SpinnerEvent{Type: SpinnerEventPercent, Percent: percent})
+
+	s.notifyDataChange()
+
+	return nil
+}
+
+// SetTotal updates the total amount of work, which is rendered along with the
+// current amount set by SetCurrent() after the message, using the
+// ProgressUnit Config field. Setting it to 0 stops rendering the amounts. When
+// the total is greater than 0, the completion percentage is also updated to
+// match, like calling Percent(). The total can't be negative.
+func (s *Spinner) SetTotal(total int64) error {
+	if total < 0 {
+		return errors.New("total cannot be negative")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.total = total
+	s.updateFractionPercent()
+
+	s.notifyDataChange()
+
+	return nil
+}
+
+// SetCurrent updates the current amount of work done. See SetTotal() for more
+// details. The current amount can't be negative, but can be greater than the
+// total, in which case the percentage is capped at 100.
+func (s *Spinner) SetCurrent(current int64) error {
+	if current < 0 {
+		return errors.New("current cannot be negative")
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.message = message
+	s.current = current
+	s.updateFractionPercent()
 
 	s.notifyDataChange()
+
+	return nil
+}
+
+// updateFractionPercent sets the percent from the current and total amounts,
+// if there is a total. The caller must hold the mutex.
+func (s *Spinner) updateFractionPercent() {
+	if s.total == 0 {
+		return
+	}
+
+	pct := float64(s.current) / float64(s.total) * 100
+	if pct > 100 {
+		pct = 100
+	}
+
+	s.percent = pct
+	s.percentSet = true
+	s.publish(SpinnerEvent{Type: SpinnerEventPercent, Percent: pct})
 }
 
 // Colors updates the github.com/fatih/colors for printing the spinner line.
@@ -1078,7 +4747,7 @@ func (s *Spinner) Message(message string) {
 //
 // StopColors() is the method to control the colors in the stop message.
 func (s *Spinner) Colors(colors ...string) error {
-	colorFn, err := colorFunc(colors...)
+	colorFn, err := colorFunc(s.colorProfile, colors...)
 	if err != nil {
 		return fmt.Errorf("failed to build color function: %w", err)
 	}
@@ -1086,6 +4755,7 @@ func (s *Spinner) Colors(colors ...string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.colors = colors
 	s.colorFn = colorFn
 
 	s.notifyDataChange()
@@ -1093,6 +4763,55 @@ func (s *Spinner) Colors(colors ...string) error {
 	return nil
 }
 
+// RenderedWidth returns the width, in terminal columns, of the line currently
+// being rendered by the spinner, not including any color escape sequences.
+// This is useful for positioning other content on the same line.
+func (s *Spinner) RenderedWidth() int {
+	s.mu.Lock()
+
+	var c character
+	if len(s.chars) > 0 && !s.hidden {
+		c = parallelChar(s.chars, s.lastIndex(), s.parallelChars)
+	}
+
+	// the colors don't change the width, so they aren't rendered
+	op := s.paintOp(c, s.fitMessage(s.renderedMessage(*s.loadMessageState())), s.colorFn, false).dumb()
+
+	s.mu.Unlock()
+
+	var b strings.Builder
+
+	op.writer = &b
+	op.notTTY = false
+
+	// writing to a strings.Builder never fails
+	_, _ = paint(op)
+
+	return stringWidth(op.width, escapeRe.ReplaceAllString(b.String(), ""))
+}
+
+// SetColorAll updates whether the colors are applied to the whole line, or
+// only to the spinner character. See the ColorAll Config field.
+func (s *Spinner) SetColorAll(colorAll bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.colorAll = colorAll
+
+	s.notifyDataChange()
+}
+
+// SetSpinnerAtEnd updates whether the spinner character is rendered at the end
+// of the line, instead of the start. See the SpinnerAtEnd Config field.
+func (s *Spinner) SetSpinnerAtEnd(atEnd bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.spinnerAtEnd = atEnd
+
+	s.notifyDataChange()
+}
+
 // StopMessage updates the Message used when Stop() is called.
 func (s *Spinner) StopMessage(message string) {
 	s.mu.Lock()
@@ -1109,7 +4828,7 @@ func (s *Spinner) StopMessage(message string) {
 // StopFailColors() is the method to control the colors in the failed stop
 // message.
 func (s *Spinner) StopColors(colors ...string) error {
-	colorFn, err := colorFunc(colors...)
+	colorFn, err := colorFunc(s.colorProfile, colors...)
 	if err != nil {
 		return fmt.Errorf("failed to build stop color function: %w", err)
 	}
@@ -1117,6 +4836,7 @@ func (s *Spinner) StopColors(colors ...string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.stopColors = colors
 	s.stopColorFn = colorFn
 
 	s.notifyDataChange()
@@ -1124,10 +4844,54 @@ func (s *Spinner) StopColors(colors ...string) error {
 	return nil
 }
 
+// StopConfig is the character, message, and colors printed when the spinner
+// stops. It's used with the SetStop() method.
+type StopConfig struct {
+	Character string
+	Message   string
+	Colors    []string
+}
+
+// SetStop updates the character, message, and colors used when Stop() and
+// StopFail() are called, as a single update. This is equivalent to calling the
+// StopCharacter(), StopMessage(), StopColors() methods, and their StopFail
+// counterparts. If either of the Colors are invalid an error is returned, and
+// nothing is updated.
+func (s *Spinner) SetStop(success, fail StopConfig) error {
+	successColorFn, err := colorFunc(s.colorProfile, success.Colors...)
+	if err != nil {
+		return fmt.Errorf("failed to build stop color function: %w", err)
+	}
+
+	failColorFn, err := colorFunc(s.colorProfile, fail.Colors...)
+	if err != nil {
+		return fmt.Errorf("failed to build stop fail color function: %w", err)
+	}
+
+	successChar := character{Value: success.Character, Size: charWidth(s.width, success.Character)}
+	failChar := character{Value: fail.Character, Size: charWidth(s.width, fail.Character)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopChar, s.stopMsg, s.stopColors, s.stopColorFn = successChar, success.Message, success.Colors, successColorFn
+	s.stopFailChar, s.stopFailMsg, s.stopFailColors, s.stopFailColorFn = failChar, fail.Message, fail.Colors, failColorFn
+
+	for _, n := range [...]int{successChar.Size, failChar.Size} {
+		if n > s.maxWidth {
+			s.maxWidth = n
+		}
+	}
+
+	s.notifyDataChange()
+
+	return nil
+}
+
 // StopCharacter sets the single "character" to use for the spinner when
 // stopping. Recommended character is ✓.
 func (s *Spinner) StopCharacter(char string) {
-	n := runewidth.StringWidth(char)
+	n := charWidth(s.width, char)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -1154,7 +4918,7 @@ func (s *Spinner) StopFailMessage(message string) {
 // StopFailColors updates the colors used for the StopFail message. See Colors() method
 // documentation for more context.
 func (s *Spinner) StopFailColors(colors ...string) error {
-	colorFn, err := colorFunc(colors...)
+	colorFn, err := colorFunc(s.colorProfile, colors...)
 	if err != nil {
 		return fmt.Errorf("failed to build stop fail color function: %w", err)
 	}
@@ -1162,6 +4926,7 @@ func (s *Spinner) StopFailColors(colors ...string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.stopFailColors = colors
 	s.stopFailColorFn = colorFn
 
 	s.notifyDataChange()
@@ -1172,7 +4937,7 @@ func (s *Spinner) StopFailColors(colors ...string) error {
 // StopFailCharacter sets the single "character" to use for the spinner when
 // stopping for a failure. Recommended character is ✗.
 func (s *Spinner) StopFailCharacter(char string) {
-	n := runewidth.StringWidth(char)
+	n := charWidth(s.width, char)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -1196,10 +4961,45 @@ func (s *Spinner) CharSet(cs []string) error {
 		return errors.New("failed to set character set:  must provide at least one string")
 	}
 
-	chars, mw := setToCharSlice(cs)
+	chars, mw := setToCharSlice(cs, s.width)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.setChars(chars, mw)
+
+	return nil
+}
+
+// CharSetFromReader updates the set of characters to use for the spinner, like
+// CharSet(), reading them from r with each line being one character (frame).
+// Empty lines are skipped, and "\r\n" line endings are supported. This is
+// useful for loading large or generated animations from files. An error is
+// returned if reading from r fails, or if there are no characters.
+func (s *Spinner) CharSetFromReader(r io.Reader) error {
+	var cs []string
+
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		if frame := strings.TrimSuffix(scanner.Text(), "\r"); len(frame) > 0 {
+			cs = append(cs, frame)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read character set: %w", err)
+	}
+
+	return s.CharSet(cs)
+}
+
+// setChars updates the characters of the spinner, with mw being the max width
+// of them, and resets the animation. The caller must hold the mutex.
+func (s *Spinner) setChars(chars []character, mw int) {
+	if s.parallelChars > 1 {
+		mw *= s.parallelChars
+	}
+
 	if n := s.stopChar.Size; n > mw {
 		mw = s.stopChar.Size
 	}
@@ -1208,13 +5008,132 @@ func (s *Spinner) CharSet(cs []string) error {
 		mw = n
 	}
 
+	for _, o := range s.outcomes {
+		if n := o.char.Size; n > mw {
+			mw = n
+		}
+	}
+
 	s.chars = chars
 	s.maxWidth = mw
 	s.index = 0
+}
+
+// SetAnimation updates the set of characters and the frequency of the spinner
+// together, so that no frame is rendered using the new characters at the old
+// frequency, or vice versa. An error is returned if cs is empty, or if freq is
+// not greater than 0.
+func (s *Spinner) SetAnimation(cs []string, freq time.Duration) error {
+	if len(cs) == 0 {
+		return errors.New("failed to set animation: must provide at least one string")
+	}
+
+	if freq < 1 {
+		return errors.New("failed to set animation: duration must be greater than 0")
+	}
+
+	chars, mw := setToCharSlice(cs, s.width)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setChars(chars, mw)
+
+	// when output target is not a TTY, we don't animate spinner so there is
+	// no need to update the frequency
+	if !termModeForceNoTTY(s.termMode) {
+		s.frequency = freq
+
+		// non-blocking notification
+		select {
+		case s.frequencyUpdateCh <- s.interval():
+		default:
+		}
+	}
+
+	s.notifyDataChange()
+
+	return nil
+}
+
+// SetAnimationStyle updates the set of characters and the direction of the
+// animation together, so that no frame is rendered using the new characters
+// in the old direction, or vice versa. This is useful when switching to a
+// character set that reads better in reverse. See SetDirection() for the
+// meaning of forward. An error is returned if cs is empty.
+func (s *Spinner) SetAnimationStyle(cs []string, forward bool) error {
+	if len(cs) == 0 {
+		return errors.New("failed to set animation style: must provide at least one string")
+	}
+
+	chars, mw := setToCharSlice(cs, s.width)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setChars(chars, mw)
+	s.backward = !forward
+
+	s.notifyDataChange()
 
 	return nil
 }
 
+// SetCharSetByIndex updates the set of characters to use for the spinner, to
+// the one at index i of the yacspin.CharSets variable. An error is returned if
+// there is no character set at that index.
+func (s *Spinner) SetCharSetByIndex(i int) error {
+	cs, ok := CharSets[i]
+	if !ok {
+		return fmt.Errorf("failed to set character set: %d is not a valid CharSets index", i)
+	}
+
+	return s.CharSet(cs)
+}
+
+// Freeze stops the spinner from advancing to the next character, leaving it on
+// the current one, without stopping or pausing it. Unlike Pause(), the
+// spinner is still rendered, so updates to the Message and other data are
+// shown using the same character. Use Thaw() to resume advancing.
+func (s *Spinner) Freeze() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.frozen = true
+}
+
+// Thaw resumes advancing the spinner through its characters after a call to
+// Freeze().
+func (s *Spinner) Thaw() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.frozen = false
+}
+
+// HideSpinner sets whether the spinner character is hidden, which renders only
+// the message, without the Prefix and Suffix, as if the character was empty.
+// The animation keeps advancing while hidden, so the character continues from
+// where it would have been when shown again. This doesn't affect the line
+// printed when stopping.
+func (s *Spinner) HideSpinner(hide bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hidden = hide
+
+	s.notifyDataChange()
+}
+
+// SetDirection sets the direction the spinner animates through its character
+// set, without modifying the character set itself like Reverse() does. If
+// forward is false the animation steps backward through the characters, and
+// wraps around from the first character to the last.
+func (s *Spinner) SetDirection(forward bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.backward = !forward
+}
+
 // Reverse flips the character set order of the spinner characters.
 func (s *Spinner) Reverse() {
 	s.mu.Lock()
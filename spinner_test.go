@@ -2,18 +2,23 @@ package yacspin
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/mattn/go-runewidth"
 )
 
@@ -48,6 +53,40 @@ func testErrCheck(t *testing.T, name string, errContains string, err error) bool
 	return true
 }
 
+// withMessage sets the message of a *Spinner built using a struct literal
+func withMessage(s *Spinner, message string) *Spinner {
+	s.message.Store(&messageState{text: message})
+
+	return s
+}
+
+// testConfig returns the usual test fixture for a spinner writing to w in the
+// given TerminalMode, rendering "y msg" frames and a "v stop" stop line with
+// the cursor shown.
+func testConfig(w io.Writer, mode TerminalMode) Config {
+	return Config{
+		Writer:        w,
+		CharSet:       []string{"y"},
+		Suffix:        " ",
+		Message:       "msg",
+		StopCharacter: "v",
+		StopMessage:   "stop",
+		ShowCursor:    true,
+		TerminalMode:  mode,
+	}
+}
+
+// newTestSpinner returns a new *Spinner built from cfg, failing the test if
+// the Config isn't valid. Use testConfig for the usual fixture.
+func newTestSpinner(t *testing.T, cfg Config) *Spinner {
+	t.Helper()
+
+	spinner, err := New(cfg)
+	testErrCheck(t, "New()", "", err)
+
+	return spinner
+}
+
 func TestNew(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -110,6 +149,54 @@ func TestNew(t *testing.T) {
 			},
 			err: "cfg.TerminalMode cannot have both ForceTTYMode and ForceNoTTYMode flags set",
 		},
+		{
+			name: "config_with_negative_DataUpdateBuffer",
+			cfg: Config{
+				Frequency:        100 * time.Millisecond,
+				DataUpdateBuffer: -1,
+			},
+			err: "cfg.DataUpdateBuffer cannot be negative",
+		},
+		{
+			name: "config_with_negative_ParallelChars",
+			cfg: Config{
+				Frequency:     100 * time.Millisecond,
+				ParallelChars: -1,
+			},
+			err: "cfg.ParallelChars cannot be negative",
+		},
+		{
+			name: "config_with_invalid_EastAsianWidth",
+			cfg: Config{
+				Frequency:      100 * time.Millisecond,
+				EastAsianWidth: AmbiguousWidthWide + 1,
+			},
+			err: "cfg.EastAsianWidth 3 is not a valid AmbiguousWidth",
+		},
+		{
+			name: "config_with_negative_MaxLineLength",
+			cfg: Config{
+				Frequency:     100 * time.Millisecond,
+				MaxLineLength: -1,
+			},
+			err: "cfg.MaxLineLength cannot be negative",
+		},
+		{
+			name: "config_with_negative_MessageWidth",
+			cfg: Config{
+				Frequency:    100 * time.Millisecond,
+				MessageWidth: -1,
+			},
+			err: "cfg.MessageWidth cannot be negative",
+		},
+		{
+			name: "config_with_negative_MaxWriteErrors",
+			cfg: Config{
+				Frequency:      100 * time.Millisecond,
+				MaxWriteErrors: -1,
+			},
+			err: "cfg.MaxWriteErrors cannot be negative",
+		},
 		{
 			name: "config_with_conflicting_TerminalMode_Term",
 			cfg: Config{
@@ -244,8 +331,8 @@ func TestNew(t *testing.T) {
 				t.Errorf("spinner.suffix = %q, want %q", spinner.suffix, tt.cfg.Suffix)
 			}
 
-			if spinner.message != tt.cfg.Message {
-				t.Errorf("spinner.message = %q, want %q", spinner.message, tt.cfg.Message)
+			if got := spinner.loadMessage().text; got != tt.cfg.Message {
+				t.Errorf("spinner.loadMessage().text = %q, want %q", got, tt.cfg.Message)
 			}
 
 			if spinner.stopMsg != tt.cfg.StopMessage {
@@ -333,7 +420,7 @@ func TestNew(t *testing.T) {
 
 			// handle the default value in New()
 			if len(tt.cfg.CharSet) == 0 {
-				tt.cfg.CharSet = CharSets[9]
+				tt.cfg.CharSet = DefaultCharSet
 			}
 
 			tt.charSet = make([]character, len(tt.cfg.CharSet))
@@ -356,6 +443,55 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNew_startIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		charSet    []string
+		startIndex int
+		err        string
+	}{
+		{
+			name:    "default",
+			charSet: CharSets[26],
+		},
+		{
+			name:       "last",
+			charSet:    CharSets[26],
+			startIndex: 2,
+		},
+		{
+			name:       "default_charset",
+			startIndex: 3,
+		},
+		{
+			name:       "out_of_range",
+			charSet:    CharSets[26],
+			startIndex: 3,
+			err:        "cfg.StartIndex must be within the CharSet (0-2)",
+		},
+		{
+			name:       "negative",
+			charSet:    CharSets[26],
+			startIndex: -1,
+			err:        "cfg.StartIndex must be within the CharSet (0-2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner, err := New(Config{Frequency: time.Second, CharSet: tt.charSet, StartIndex: tt.startIndex})
+
+			if cont := testErrCheck(t, "New()", tt.err, err); !cont {
+				return
+			}
+
+			if spinner.index != tt.startIndex {
+				t.Fatalf("spinner.index = %d, want %d", spinner.index, tt.startIndex)
+			}
+		})
+	}
+}
+
 func TestNew_dumbTerm(t *testing.T) {
 	t.Setenv("TERM", "dumb")
 
@@ -377,6 +513,91 @@ func TestNew_dumbTerm(t *testing.T) {
 	}
 }
 
+func TestNewProgress(t *testing.T) {
+	spinner, err := NewProgress(Config{TerminalMode: termModeTTY})
+	testErrCheck(t, "NewProgress()", "", err)
+
+	if !spinner.spinnerAtEnd {
+		t.Error("spinner.spinnerAtEnd = false, want true")
+	}
+
+	if !spinner.showPercent {
+		t.Error("spinner.showPercent = false, want true")
+	}
+
+	if !spinner.showElapsed {
+		t.Error("spinner.showElapsed = false, want true")
+	}
+
+	if spinner.frequency != 100*time.Millisecond {
+		t.Errorf("spinner.frequency = %s, want %s", spinner.frequency, 100*time.Millisecond)
+	}
+
+	if spinner.prefix != " " {
+		t.Errorf("spinner.prefix = %q, want %q", spinner.prefix, " ")
+	}
+
+	spinner, err = NewProgress(Config{TerminalMode: termModeTTY, Frequency: time.Second, Prefix: " > "})
+	testErrCheck(t, "NewProgress()", "", err)
+
+	if spinner.frequency != time.Second {
+		t.Errorf("spinner.frequency = %s, want %s", spinner.frequency, time.Second)
+	}
+
+	if spinner.prefix != " > " {
+		t.Errorf("spinner.prefix = %q, want %q", spinner.prefix, " > ")
+	}
+}
+
+func TestNew_termEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		termEnv   string
+		term      string
+		wantDumb  bool
+		wantSmart bool
+	}{
+		{
+			name:     "dumb_overrides_smart_TERM",
+			termEnv:  "dumb",
+			term:     "xterm-256color",
+			wantDumb: true,
+		},
+		{
+			name:      "smart_overrides_dumb_TERM",
+			termEnv:   "xterm",
+			term:      "dumb",
+			wantSmart: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TERM", tt.term)
+
+			spinner, err := New(Config{
+				Frequency:   time.Second,
+				Writer:      &bytes.Buffer{},
+				TermEnv:     tt.termEnv,
+				TTYDetector: func() bool { return true },
+			})
+			testErrCheck(t, "New()", "", err)
+
+			if !termModeForceTTY(spinner.termMode) {
+				t.Fatal("spinner.termMode does not contain ForceTTYMode flag")
+			}
+
+			if got := termModeForceDumb(spinner.termMode); got != tt.wantDumb {
+				t.Fatalf("termModeForceDumb() = %t, want %t", got, tt.wantDumb)
+			}
+
+			if got := termModeForceSmart(spinner.termMode); got != tt.wantSmart {
+				t.Fatalf("termModeForceSmart() = %t, want %t", got, tt.wantSmart)
+			}
+		})
+	}
+}
+
 func TestSpinner_Status(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -431,6 +652,31 @@ func TestSpinner_Status(t *testing.T) {
 	}
 }
 
+func TestSpinner_Paused(t *testing.T) {
+	tests := []struct {
+		status uint32
+		want   bool
+	}{
+		{status: statusStopped},
+		{status: statusStarting},
+		{status: statusRunning},
+		{status: statusStopping},
+		{status: statusPausing},
+		{status: statusPaused, want: true},
+		{status: statusUnpausing},
+	}
+
+	for _, tt := range tests {
+		t.Run(SpinnerStatus(tt.status).String(), func(t *testing.T) {
+			spinner := &Spinner{status: uint32Ptr(tt.status)}
+
+			if got := spinner.Paused(); got != tt.want {
+				t.Fatalf("spinner.Paused() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSpinner_notifyDataChange(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -473,6 +719,76 @@ func TestSpinner_notifyDataChange(t *testing.T) {
 	}
 }
 
+func TestSpinner_Stats(t *testing.T) {
+	t.Run("dropped", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			spinner     *Spinner
+			notifies    int
+			wantDropped uint64
+		}{
+			{
+				name:        "buffered_channel",
+				spinner:     &Spinner{dataUpdateCh: make(chan struct{}, 1)},
+				notifies:    3,
+				wantDropped: 2,
+			},
+			{
+				name:        "larger_buffered_channel",
+				spinner:     &Spinner{dataUpdateCh: make(chan struct{}, 3)},
+				notifies:    3,
+				wantDropped: 0,
+			},
+			{
+				name:        "unbuffered_channel_not_running",
+				spinner:     &Spinner{dataUpdateCh: make(chan struct{})},
+				notifies:    3,
+				wantDropped: 0,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				for i := 0; i < tt.notifies; i++ {
+					tt.spinner.notifyDataChange()
+				}
+
+				if got := tt.spinner.Stats().DataUpdatesDropped; got != tt.wantDropped {
+					t.Fatalf("DataUpdatesDropped = %d, want %d", got, tt.wantDropped)
+				}
+			})
+		}
+	})
+
+	t.Run("rendered", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		spinner, err := New(Config{
+			Writer:       buf,
+			CharSet:      []string{"y", "z"},
+			Message:      "msg",
+			TerminalMode: ForceNoTTYMode | ForceDumbTerminalMode,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		time.Sleep(50 * time.Millisecond)
+
+		spinner.Message("msg")
+
+		time.Sleep(50 * time.Millisecond)
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		want := SpinnerStats{FramesRendered: 2, DataUpdates: 1}
+
+		if diff := cmp.Diff(want, spinner.Stats()); diff != "" {
+			t.Fatalf("spinner.Stats() differs: (-want +got)\n%s", diff)
+		}
+	})
+}
+
 func TestSpinner_Frequency(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -643,1286 +959,7530 @@ func TestSpinner_CharSet(t *testing.T) {
 	}
 }
 
-func TestSpinner_StopCharacter(t *testing.T) {
-	tests := []struct {
-		name     string
-		char     string
-		charSize int
-		mw       int
-	}{
-		{
-			name:     "smaller_size",
-			char:     "x",
-			charSize: 1,
-			mw:       2,
-		},
-		{
-			name:     "larger_size",
-			char:     "xxx",
-			charSize: 3,
-			mw:       3,
-		},
-	}
+// safeBuffer is a bytes.Buffer that's safe to read while the painter writes
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			spinner := &Spinner{
-				mu:       &sync.Mutex{},
-				maxWidth: 2,
-			}
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
-			spinner.StopCharacter(tt.char)
+	return b.buf.Write(p)
+}
 
-			c := spinner.stopChar
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
-			if c.Value != tt.char {
-				t.Fatalf("c.Value = %q, want %q", c.Value, tt.char)
-			}
+	return b.buf.String()
+}
 
-			if c.Size != tt.charSize {
-				t.Fatalf("c.Size = %d, want %d", c.Size, tt.charSize)
-			}
+func TestSpinner_SetAnimation(t *testing.T) {
+	t.Run("validation", func(t *testing.T) {
+		spinner, err := New(Config{
+			Writer:       &bytes.Buffer{},
+			CharSet:      []string{"y"},
+			Frequency:    time.Second,
+			TerminalMode: termModeTTY,
+		})
+		testErrCheck(t, "New()", "", err)
 
-			if spinner.maxWidth != tt.mw {
-				t.Fatalf("spinner.maxWidth = %d, want %d", spinner.maxWidth, tt.mw)
-			}
+		testErrCheck(t, "spinner.SetAnimation()", "failed to set animation: must provide at least one string", spinner.SetAnimation(nil, time.Second))
+		testErrCheck(t, "spinner.SetAnimation()", "failed to set animation: duration must be greater than 0", spinner.SetAnimation([]string{"x"}, 0))
+
+		if spinner.chars[0].Value != "y" || spinner.frequency != time.Second {
+			t.Fatalf("spinner animation = %q @ %s, want it unchanged", spinner.chars[0].Value, spinner.frequency)
+		}
+	})
+
+	t.Run("running", func(t *testing.T) {
+		buf := &safeBuffer{}
+
+		spinner, err := New(Config{
+			Writer:       buf,
+			CharSet:      []string{"y"},
+			Frequency:    time.Hour,
+			ShowCursor:   true,
+			TerminalMode: termModeTTY,
 		})
-	}
-}
+		testErrCheck(t, "New()", "", err)
 
-func TestSpinner_StopFailCharacter(t *testing.T) {
-	tests := []struct {
-		name     string
-		char     string
-		charSize int
-		mw       int
-	}{
-		{
-			name:     "smaller_size",
-			char:     "x",
-			charSize: 1,
-			mw:       2,
-		},
-		{
-			name:     "larger_size",
-			char:     "xxx",
-			charSize: 3,
-			mw:       3,
-		},
-	}
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			spinner := &Spinner{
-				mu:       &sync.Mutex{},
-				maxWidth: 2,
-			}
+		testErrCheck(t, "spinner.SetAnimation()", "", spinner.SetAnimation([]string{"ab", "c"}, time.Millisecond))
 
-			spinner.StopFailCharacter(tt.char)
+		spinner.mu.Lock()
+		freq, mw := spinner.frequency, spinner.maxWidth
+		spinner.mu.Unlock()
 
-			c := spinner.stopFailChar
+		if freq != time.Millisecond {
+			t.Errorf("spinner.frequency = %s, want %s", freq, time.Millisecond)
+		}
 
-			if c.Value != tt.char {
-				t.Fatalf("c.Value = %q, want %q", c.Value, tt.char)
-			}
+		if mw != 2 {
+			t.Errorf("spinner.maxWidth = %d, want 2", mw)
+		}
 
-			if c.Size != tt.charSize {
-				t.Fatalf("c.Size = %d, want %d", c.Size, tt.charSize)
-			}
+		// with the new frequency the painter animates the new characters
+		deadline := time.Now().Add(time.Second)
 
-			if spinner.maxWidth != tt.mw {
-				t.Fatalf("spinner.maxWidth = %d, want %d", spinner.maxWidth, tt.mw)
+		for !strings.Contains(buf.String(), "\rc ") {
+			if time.Now().After(deadline) {
+				t.Fatalf("output = %q, want the new characters animated", buf.String())
 			}
-		})
-	}
-}
 
-func TestSpinner_Reverse(t *testing.T) {
-	cfg := Config{
-		Frequency: 100 * time.Millisecond,
-		CharSet:   CharSets[26],
-	}
+			time.Sleep(time.Millisecond)
+		}
 
-	spinner, err := New(cfg)
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+	})
+}
 
-	testErrCheck(t, "New()", "", err)
+func TestSpinner_SetAnimationStyle(t *testing.T) {
+	buf := &bytes.Buffer{}
 
-	spinner.index = 1
+	spinner, err := New(Config{
+		Writer:       buf,
+		CharSet:      []string{"y"},
+		ShowCursor:   true,
+		TerminalMode: termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
 
-	csRev := make([]character, len(spinner.chars))
-	copy(csRev, spinner.chars)
+	testErrCheck(t, "spinner.SetAnimationStyle()", "failed to set animation style: must provide at least one string", spinner.SetAnimationStyle(nil, false))
 
-	for i := len(csRev)/2 - 1; i >= 0; i-- {
-		opp := len(csRev) - 1 - i
-		csRev[i], csRev[opp] = csRev[opp], csRev[i]
+	if spinner.chars[0].Value != "y" || spinner.backward {
+		t.Fatalf("spinner animation = %q, backward = %t, want it unchanged", spinner.chars[0].Value, spinner.backward)
 	}
 
-	spinner.Reverse()
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
 
-	if diff := cmp.Diff(csRev, spinner.chars); diff != "" {
-		t.Errorf("spinner.chars differs: (-want +got)\n%s", diff)
+	spinner.Render()
+
+	testErrCheck(t, "spinner.SetAnimationStyle()", "", spinner.SetAnimationStyle([]string{"a", "bb", "c"}, false))
+
+	if !spinner.backward {
+		t.Error("spinner.backward = false, want true")
 	}
 
-	if spinner.index != 0 {
-		t.Error("index was not reset")
+	if spinner.maxWidth != 2 {
+		t.Errorf("spinner.maxWidth = %d, want 2", spinner.maxWidth)
 	}
-}
 
-func TestSpinner_erase(t *testing.T) {
-	const want = "\r\033[K\r"
+	for i := 0; i < 3; i++ {
+		spinner.Render()
+	}
 
-	buf := &bytes.Buffer{}
+	testErrCheck(t, "spinner.SetAnimationStyle()", "", spinner.SetAnimationStyle([]string{"d", "e"}, true))
 
-	testErrCheck(t, "spinner.erase()", "", erase(buf))
+	if spinner.backward {
+		t.Error("spinner.backward = true, want false")
+	}
 
-	got := buf.String()
+	for i := 0; i < 2; i++ {
+		spinner.Render()
+	}
 
-	if got != want {
-		t.Errorf("got = %q, want %q", got, want)
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	// the new characters are animated in the new direction
+	want := "\r\033[K\ry" +
+		"\r\033[K\ra " +
+		"\r\033[K\rc " +
+		"\r\033[K\rbb" +
+		"\r\033[K\rd" +
+		"\r\033[K\re" +
+		"\r\033[K\r"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
 	}
 }
 
-func TestSpinner_hideCursor(t *testing.T) {
-	const want = "\r\033[?25l\r"
+func TestSpinner_SetCharSetByIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		index int
+		err   string
+	}{
+		{
+			name:  "valid_index",
+			index: 59,
+		},
+		{
+			name:  "out_of_range",
+			index: len(CharSets),
+			err:   fmt.Sprintf("failed to set character set: %d is not a valid CharSets index", len(CharSets)),
+		},
+		{
+			name:  "negative",
+			index: -1,
+			err:   "failed to set character set: -1 is not a valid CharSets index",
+		},
+	}
 
-	buf := &bytes.Buffer{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner := &Spinner{mu: &sync.Mutex{}}
 
-	testErrCheck(t, "spinner.hideCursor()", "", hideCursor(buf))
+			if cont := testErrCheck(t, "spinner.SetCharSetByIndex()", tt.err, spinner.SetCharSetByIndex(tt.index)); !cont {
+				if spinner.chars != nil {
+					t.Fatal("spinner.chars was set")
+				}
 
-	got := buf.String()
+				return
+			}
 
-	if got != want {
-		t.Errorf("got = %q, want %q", got, want)
+			want, _ := setToCharSlice(CharSets[tt.index], nil)
+
+			if diff := cmp.Diff(want, spinner.chars); diff != "" {
+				t.Fatalf("spinner.chars differs: (-want +got)\n%s", diff)
+			}
+		})
 	}
 }
 
-func TestSpinner_unhideCursor(t *testing.T) {
-	const want = "\r\033[?25h\r"
-
+func TestSpinner_parallelChars(t *testing.T) {
 	buf := &bytes.Buffer{}
 
-	testErrCheck(t, "spinner.unhideCursor()", "", unhideCursor(buf))
+	cfg := testConfig(buf, termModeTTY)
+	cfg.CharSet = []string{"a", "b", "c", "d"}
+	cfg.ParallelChars = 3
 
-	got := buf.String()
+	spinner := newTestSpinner(t, cfg)
 
-	if got != want {
-		t.Errorf("got = %q, want %q", got, want)
+	if spinner.maxWidth != 3 {
+		t.Fatalf("spinner.maxWidth = %d, want 3", spinner.maxWidth)
+	}
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	for i := 0; i < 3; i++ {
+		spinner.Render()
+	}
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	want := "\r\033[K\rabc msg" +
+		"\r\033[K\rbcd msg" +
+		"\r\033[K\rcda msg" +
+		"\r\033[K\rv   stop\n"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
 	}
 }
 
-func TestSpinner_Start(t *testing.T) {
+func TestSpinner_CharSetFromReader(t *testing.T) {
 	tests := []struct {
-		name    string
-		spinner *Spinner
-
-		err string
+		name     string
+		r        io.Reader
+		want     []character
+		maxWidth int
+		err      string
 	}{
 		{
-			name: "invalid_frequency_when_tty",
-			spinner: &Spinner{
-				status:          uint32Ptr(statusStopped),
-				mu:              &sync.Mutex{},
-				frequency:       0,
-				colorFn:         fmt.Sprintf,
-				stopColorFn:     fmt.Sprintf,
-				stopFailColorFn: fmt.Sprintf,
-				termMode:        ForceTTYMode,
-			},
-			err: "spinner Frequency duration must be greater than 0 when used within a TTY",
+			name: "empty",
+			r:    strings.NewReader(""),
+			err:  "failed to set character set:  must provide at least one string",
 		},
 		{
-			name: "running_spinner",
-			spinner: &Spinner{
-				status:          uint32Ptr(statusRunning),
-				mu:              &sync.Mutex{},
-				frequency:       time.Millisecond,
-				colorFn:         fmt.Sprintf,
-				stopColorFn:     fmt.Sprintf,
-				stopFailColorFn: fmt.Sprintf,
-			},
-			err: "spinner already running or shutting down",
+			name: "only_empty_lines",
+			r:    strings.NewReader("\n\r\n\n"),
+			err:  "failed to set character set:  must provide at least one string",
 		},
 		{
-			name: "empty_CharSet",
-			spinner: &Spinner{
-				buffer:          &bytes.Buffer{},
-				status:          uint32Ptr(statusStopped),
-				mu:              &sync.Mutex{},
-				frequency:       time.Millisecond,
-				colorFn:         fmt.Sprintf,
-				stopColorFn:     fmt.Sprintf,
-				stopFailColorFn: fmt.Sprintf,
-				stopMsg:         "stop msg",
-				stopFailMsg:     "stop fail msg",
-			},
-			err: "before starting the spinner a CharSet must be set",
+			name: "read_error",
+			r:    iotest.ErrReader(errors.New("boom")),
+			err:  "failed to read character set: boom",
 		},
 		{
-			name: "spinner",
-			spinner: &Spinner{
-				buffer:          &bytes.Buffer{},
-				status:          uint32Ptr(statusStopped),
-				mu:              &sync.Mutex{},
-				frequency:       time.Millisecond,
-				colorFn:         fmt.Sprintf,
-				stopColorFn:     fmt.Sprintf,
-				stopFailColorFn: fmt.Sprintf,
-				stopMsg:         "stop msg",
-				stopFailMsg:     "stop fail msg",
-				maxWidth:        3,
-				chars: []character{
-					character{
-						Value: ".",
-						Size:  1,
-					},
-					character{
-						Value: "..",
-						Size:  21,
-					},
-					character{
-						Value: "...",
-						Size:  3,
-					},
-				},
-			},
+			name:     "frames",
+			r:        strings.NewReader("a\nbb\nccc\n"),
+			want:     []character{{Value: "a", Size: 1}, {Value: "bb", Size: 2}, {Value: "ccc", Size: 3}},
+			maxWidth: 3,
 		},
 		{
-			name: "spinner_not_tty",
-			spinner: &Spinner{
-				buffer:          &bytes.Buffer{},
-				status:          uint32Ptr(statusStopped),
-				mu:              &sync.Mutex{},
-				frequency:       9223372036854775807,
-				colorFn:         fmt.Sprintf,
-				stopColorFn:     fmt.Sprintf,
-				stopFailColorFn: fmt.Sprintf,
-				stopMsg:         "stop msg",
-				stopFailMsg:     "stop fail msg",
-				termMode:        ForceNoTTYMode,
-				maxWidth:        3,
-				chars: []character{
-					character{
-						Value: ".",
-						Size:  1,
-					},
-					character{
-						Value: "..",
-						Size:  21,
-					},
-					character{
-						Value: "...",
-						Size:  3,
-					},
-				},
-			},
+			name:     "crlf_and_empty_lines",
+			r:        strings.NewReader("a\r\n\r\nb\r\n\nc"),
+			want:     []character{{Value: "a", Size: 1}, {Value: "b", Size: 1}, {Value: "c", Size: 1}},
+			maxWidth: 1,
+		},
+		{
+			name:     "wide_frames",
+			r:        strings.NewReader("🌑\n🌒\n"),
+			want:     []character{{Value: "🌑", Size: 2}, {Value: "🌒", Size: 2}},
+			maxWidth: 2,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			buf := &bytes.Buffer{}
-			tt.spinner.writer = buf
-
-			err := tt.spinner.Start()
+			spinner := &Spinner{
+				mu: &sync.Mutex{},
+			}
 
-			if cont := testErrCheck(t, "Start()", tt.err, err); !cont {
+			if cont := testErrCheck(t, "spinner.CharSetFromReader()", tt.err, spinner.CharSetFromReader(tt.r)); !cont {
 				return
 			}
 
-			if tt.spinner.cancelCh == nil {
-				t.Fatal("tt.spinner.cancelCh == nil")
+			if diff := cmp.Diff(tt.want, spinner.chars); diff != "" {
+				t.Fatalf("spinner.chars differs: (-want +got)\n%s", diff)
 			}
 
-			if tt.spinner.doneCh == nil {
-				t.Fatal("tt.spinner.doneCh == nil")
+			if spinner.maxWidth != tt.maxWidth {
+				t.Fatalf("spinner.maxWidth = %d, want %d", spinner.maxWidth, tt.maxWidth)
 			}
+		})
+	}
+}
 
-			close(tt.spinner.cancelCh)
+func TestSpinner_CharSet_running(t *testing.T) {
+	buf := &bytes.Buffer{}
 
-			<-tt.spinner.doneCh
+	spinner, err := New(Config{
+		Frequency:    time.Millisecond,
+		Writer:       buf,
+		CharSet:      []string{"y", "z"},
+		TerminalMode: termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
 
-			if buf.Len() == 0 {
-				t.Fatal("painter did not write data")
-			}
+	testErrCheck(t, "spinner.Start()", "", spinner.Start())
 
-			if max := time.Duration(math.MaxInt64); termModeForceNoTTY(tt.spinner.termMode) && tt.spinner.frequency != max {
-				t.Fatalf("tt.spinner.duration = %s, want %s", tt.spinner.frequency, max)
-			}
-		})
+	testErrCheck(t, "spinner.CharSet()", "must provide at least one string", spinner.CharSet(nil))
+	testErrCheck(t, "spinner.CharSet()", "must provide at least one string", spinner.CharSet([]string{}))
+
+	spinner.Reverse()
+
+	// let the painter render a few frames with the unchanged character set
+	time.Sleep(20 * time.Millisecond)
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	if n := len(spinner.chars); n != 2 {
+		t.Fatalf("len(spinner.chars) = %d, want 2", n)
 	}
 }
 
-func TestSpinner_Pause(t *testing.T) {
+func TestSpinner_StopCharacter(t *testing.T) {
 	tests := []struct {
-		name    string
-		spinner *Spinner
-		err     string
+		name     string
+		char     string
+		charSize int
+		mw       int
 	}{
 		{
-			name: "not_running",
-			spinner: &Spinner{
-				status:  uint32Ptr(statusStopped),
-				pauseCh: make(chan struct{}, 1),
-			},
-			err: "spinner not running",
+			name:     "smaller_size",
+			char:     "x",
+			charSize: 1,
+			mw:       2,
 		},
 		{
-			name: "running",
-			spinner: &Spinner{
-				status:  uint32Ptr(statusRunning),
-				pauseCh: make(chan struct{}, 1),
-			},
+			name:     "larger_size",
+			char:     "xxx",
+			charSize: 3,
+			mw:       3,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if cont := testErrCheck(t, "Pause()", tt.err, tt.spinner.Pause()); !cont {
-				return
+			spinner := &Spinner{
+				mu:       &sync.Mutex{},
+				maxWidth: 2,
 			}
 
-			if tt.spinner.unpauseCh == nil {
-				t.Fatal("unpauseCh = nil")
-			}
+			spinner.StopCharacter(tt.char)
 
-			if tt.spinner.unpausedCh == nil {
-				t.Fatal("unpausedCh = nil")
+			c := spinner.stopChar
+
+			if c.Value != tt.char {
+				t.Fatalf("c.Value = %q, want %q", c.Value, tt.char)
 			}
 
-			select {
-			case _, ok := <-tt.spinner.pauseCh:
-				if !ok {
-					t.Fatal("unexpected closed channel")
-				}
-			default:
-				t.Fatal("expected message from pauseCh")
+			if c.Size != tt.charSize {
+				t.Fatalf("c.Size = %d, want %d", c.Size, tt.charSize)
 			}
 
-			if s := atomic.LoadUint32(tt.spinner.status); s != statusPaused {
-				t.Fatalf("status = %d, want %d", s, statusPaused)
+			if spinner.maxWidth != tt.mw {
+				t.Fatalf("spinner.maxWidth = %d, want %d", spinner.maxWidth, tt.mw)
 			}
 		})
 	}
 }
 
-func TestSpinner_Unpause(t *testing.T) {
+func TestSpinner_SetStop(t *testing.T) {
 	tests := []struct {
 		name    string
-		spinner *Spinner
+		success StopConfig
+		fail    StopConfig
+		want    string
 		err     string
 	}{
 		{
-			name: "not_paused",
-			spinner: &Spinner{
-				status:     uint32Ptr(statusStopped),
-				unpauseCh:  make(chan struct{}),
-				unpausedCh: make(chan struct{}),
-			},
-			err: "spinner not paused",
+			name:    "valid",
+			success: StopConfig{Character: "✓", Message: "done", Colors: []string{"fgGreen"}},
+			fail:    StopConfig{Character: "✗✗", Message: "failed", Colors: []string{"fgRed"}},
+			want: "\r\033[K\r" + color.New(color.FgGreen).Sprintf("✓ ") + " done\n" +
+				"\r\033[K\r" + color.New(color.FgRed).Sprintf("✗✗") + " failed\n",
 		},
 		{
-			name: "running",
-			spinner: &Spinner{
-				status:     uint32Ptr(statusPaused),
-				unpauseCh:  make(chan struct{}),
-				unpausedCh: make(chan struct{}),
-			},
+			name:    "invalid_success_colors",
+			success: StopConfig{Colors: []string{"invalid"}},
+			err:     "failed to build stop color function: invalid is not a valid color",
+		},
+		{
+			name: "invalid_fail_colors",
+			fail: StopConfig{Colors: []string{"invalid"}},
+			err:  "failed to build stop fail color function: invalid is not a valid color",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ch := tt.spinner.unpauseCh
-			close(tt.spinner.unpausedCh)
+			buf := &bytes.Buffer{}
 
-			if cont := testErrCheck(t, "Unpause()", tt.err, tt.spinner.Unpause()); !cont {
-				return
-			}
+			cfg := testConfig(buf, termModeTTY)
+			cfg.Message = ""
+			cfg.StopFailCharacter = "x"
+			cfg.StopFailMessage = "fail"
 
-			if tt.spinner.unpauseCh != nil {
-				t.Fatal("unpauseCh != nil")
-			}
+			spinner := newTestSpinner(t, cfg)
 
-			if tt.spinner.unpausedCh != nil {
-				t.Fatal("unpausedCh != nil")
+			if cont := testErrCheck(t, "spinner.SetStop()", tt.err, spinner.SetStop(tt.success, tt.fail)); !cont {
+				if spinner.stopChar.Value != "v" || spinner.stopFailChar.Value != "x" {
+					t.Fatalf("stop characters = %q, %q; want them unchanged", spinner.stopChar.Value, spinner.stopFailChar.Value)
+				}
+
+				return
 			}
 
-			select {
-			case _, ok := <-ch:
-				if ok {
-					t.Fatal("unexpected open channel")
-				}
-			default:
-				t.Fatal("expected unpauseCh closed")
+			if spinner.maxWidth != 2 {
+				t.Fatalf("spinner.maxWidth = %d, want 2", spinner.maxWidth)
 			}
 
-			if s := atomic.LoadUint32(tt.spinner.status); s != statusRunning {
-				t.Fatalf("status = %d, want %d", s, statusRunning)
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+			testErrCheck(t, "spinner.StopFail()", "", spinner.StopFail())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
 			}
 		})
 	}
 }
 
-func TestSpinner_Stop(t *testing.T) {
+func TestSpinner_StopFailCharacter(t *testing.T) {
 	tests := []struct {
-		name    string
-		spinner *Spinner
-		err     string
+		name     string
+		char     string
+		charSize int
+		mw       int
 	}{
 		{
-			name: "not_running",
-			spinner: &Spinner{
-				mu:       &sync.Mutex{},
-				status:   uint32Ptr(statusStopped),
-				cancelCh: make(chan struct{}),
-				doneCh:   make(chan struct{}),
-			},
-			err: "spinner not running or paused",
-		},
-		{
-			name: "running",
-			spinner: &Spinner{
-				mu:       &sync.Mutex{},
-				status:   uint32Ptr(statusRunning),
-				cancelCh: make(chan struct{}),
-				doneCh:   make(chan struct{}),
-			},
+			name:     "smaller_size",
+			char:     "x",
+			charSize: 1,
+			mw:       2,
 		},
 		{
-			name: "paused",
-			spinner: &Spinner{
-				mu:         &sync.Mutex{},
-				status:     uint32Ptr(statusPaused),
-				cancelCh:   make(chan struct{}),
-				doneCh:     make(chan struct{}),
-				unpauseCh:  make(chan struct{}),
-				unpausedCh: make(chan struct{}),
-			},
+			name:     "larger_size",
+			char:     "xxx",
+			charSize: 3,
+			mw:       3,
 		},
 	}
 
 	for _, tt := range tests {
-		tt := tt // create local copy
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.spinner.unpausedCh != nil {
-				close(tt.spinner.unpausedCh)
+			spinner := &Spinner{
+				mu:       &sync.Mutex{},
+				maxWidth: 2,
 			}
 
-			var ok bool
-			wait := make(chan struct{})
+			spinner.StopFailCharacter(tt.char)
 
-			go func(doneCh, cancelCh chan struct{}) {
-				close(doneCh)
-				_, ok = <-cancelCh
-				close(wait)
-			}(tt.spinner.doneCh, tt.spinner.cancelCh)
+			c := spinner.stopFailChar
 
-			if cont := testErrCheck(t, "spinner.Stop()", tt.err, tt.spinner.Stop()); !cont {
-				return
+			if c.Value != tt.char {
+				t.Fatalf("c.Value = %q, want %q", c.Value, tt.char)
 			}
 
-			<-wait
-
-			if !ok {
-				t.Error("expected stop() to send message and not close channel")
+			if c.Size != tt.charSize {
+				t.Fatalf("c.Size = %d, want %d", c.Size, tt.charSize)
 			}
 
-			if tt.spinner.index != 0 {
-				t.Errorf("tt.spinner.index = %d, want 0", tt.spinner.index)
+			if spinner.maxWidth != tt.mw {
+				t.Fatalf("spinner.maxWidth = %d, want %d", spinner.maxWidth, tt.mw)
 			}
+		})
+	}
+}
 
-			if tt.spinner.cancelCh != nil {
-				t.Error("tt.spinner.cancelCh is not nil")
-			}
+func TestSpinner_Reverse(t *testing.T) {
+	cfg := Config{
+		Frequency: 100 * time.Millisecond,
+		CharSet:   CharSets[26],
+	}
 
-			if tt.spinner.doneCh != nil {
-				t.Error("tt.spinner.doneCh is not nil")
-			}
+	spinner, err := New(cfg)
 
-			status := atomic.LoadUint32(tt.spinner.status)
-			if status != 0 {
-				t.Errorf("tt.spinner.status = %d, want 0", status)
-			}
-		})
+	testErrCheck(t, "New()", "", err)
+
+	spinner.index = 1
+
+	csRev := make([]character, len(spinner.chars))
+	copy(csRev, spinner.chars)
+
+	for i := len(csRev)/2 - 1; i >= 0; i-- {
+		opp := len(csRev) - 1 - i
+		csRev[i], csRev[opp] = csRev[opp], csRev[i]
+	}
+
+	spinner.Reverse()
+
+	if diff := cmp.Diff(csRev, spinner.chars); diff != "" {
+		t.Errorf("spinner.chars differs: (-want +got)\n%s", diff)
+	}
+
+	if spinner.index != 0 {
+		t.Error("index was not reset")
 	}
 }
 
-func TestSpinner_StopFail(t *testing.T) {
+func TestSpinner_RenderedWidth(t *testing.T) {
+	render := func(t *testing.T, s *Spinner) {
+		testErrCheck(t, "spinner.StartManual()", "", s.StartManual())
+		s.Render()
+	}
+
 	tests := []struct {
-		name    string
-		spinner *Spinner
-		err     string
+		name  string
+		cfg   Config
+		setup func(*testing.T, *Spinner)
+		want  int
 	}{
 		{
-			name: "not_running",
-			spinner: &Spinner{
-				mu:       &sync.Mutex{},
-				status:   uint32Ptr(statusStopped),
-				cancelCh: make(chan struct{}),
-				doneCh:   make(chan struct{}),
+			name: "ascii",
+			cfg: Config{
+				CharSet: []string{"y", "zz"},
+				Prefix:  "p",
+				Suffix:  " ",
+				Message: "msg",
 			},
-			err: "spinner not running or paused",
+			want: 7,
 		},
 		{
-			name: "running",
-			spinner: &Spinner{
-				mu:       &sync.Mutex{},
-				status:   uint32Ptr(statusRunning),
-				cancelCh: make(chan struct{}),
-				doneCh:   make(chan struct{}),
+			name: "cjk",
+			cfg: Config{
+				CharSet: []string{"日"},
+				Suffix:  " ",
+				Message: "本語",
+			},
+			want: 7,
+		},
+		{
+			name: "colored",
+			cfg: Config{
+				CharSet:  []string{"y"},
+				Suffix:   " ",
+				Message:  "msg",
+				Colors:   []string{"fgRed"},
+				ColorAll: true,
+			},
+			want: 5,
+		},
+		{
+			name: "last_painted",
+			cfg: Config{
+				CharSet:           []string{"a", "bbb"},
+				TrimTrailingSpace: true,
+			},
+			setup: render,
+			want:  1,
+		},
+		{
+			name: "backward",
+			cfg: Config{
+				CharSet:           []string{"a", "bb", "ccc"},
+				TrimTrailingSpace: true,
 			},
+			setup: func(t *testing.T, s *Spinner) {
+				s.SetDirection(false)
+				render(t, s)
+			},
+			want: 1,
+		},
+		{
+			name: "animated_message",
+			cfg: Config{
+				CharSet: []string{"y"},
+				Suffix:  " ",
+				Message: "msg",
+			},
+			setup: func(t *testing.T, s *Spinner) {
+				s.AnimateMessage([]string{"loading"}, time.Hour)
+			},
+			want: 9,
+		},
+		{
+			name: "message_width",
+			cfg: Config{
+				CharSet:      []string{"y"},
+				Suffix:       " ",
+				Message:      "msg",
+				MessageWidth: 6,
+			},
+			want: 8,
 		},
 	}
 
 	for _, tt := range tests {
-		tt := tt // create local copy
 		t.Run(tt.name, func(t *testing.T) {
-			var ok bool
-			wait := make(chan struct{})
+			cfg := tt.cfg
+			cfg.Writer = &bytes.Buffer{}
+			cfg.TerminalMode = termModeTTY
 
-			go func(doneCh, cancelCh chan struct{}) {
-				close(doneCh)
-				_, ok = <-cancelCh
-				close(wait)
-			}(tt.spinner.doneCh, tt.spinner.cancelCh)
+			spinner := newTestSpinner(t, cfg)
 
-			if cont := testErrCheck(t, "spinner.Stop()", tt.err, tt.spinner.StopFail()); !cont {
-				return
+			if tt.setup != nil {
+				tt.setup(t, spinner)
 			}
 
-			<-wait
-
-			if ok {
-				t.Error("expected stop() to not send message and instead close the channel")
+			if got := spinner.RenderedWidth(); got != tt.want {
+				t.Fatalf("spinner.RenderedWidth() = %d, want %d", got, tt.want)
 			}
+		})
+	}
+}
 
-			if tt.spinner.index != 0 {
-				t.Errorf("tt.spinner.index = %d, want 0", tt.spinner.index)
-			}
+func TestSpinner_SetColorAll(t *testing.T) {
+	buf := &bytes.Buffer{}
 
-			if tt.spinner.cancelCh != nil {
-				t.Error("tt.spinner.cancelCh is not nil")
-			}
+	cfg := testConfig(buf, termModeTTY)
+	cfg.Colors = []string{"fgRed"}
+	cfg.StopColors = []string{"fgGreen"}
 
-			if tt.spinner.doneCh != nil {
-				t.Error("tt.spinner.doneCh is not nil")
-			}
+	spinner := newTestSpinner(t, cfg)
 
-			status := atomic.LoadUint32(tt.spinner.status)
-			if status != 0 {
-				t.Errorf("tt.spinner.status = %d, want 0", status)
-			}
-		})
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	spinner.Render()
+	spinner.SetColorAll(true)
+	spinner.Render()
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	red := color.New(color.FgRed)
+	green := color.New(color.FgGreen)
+
+	want := "\r\033[K\r" + red.Sprintf("y") + " msg" +
+		"\r\033[K\r" + red.Sprintf("y msg") +
+		"\r\033[K\r" + green.Sprintf("v stop") + "\n"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
 	}
 }
 
-func TestSpinner_paintUpdate(t *testing.T) {
+func TestSpinner_SetSpinnerAtEnd(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	cfg := testConfig(buf, termModeTTY)
+	cfg.Prefix = " "
+	cfg.Suffix = " s"
+	cfg.SuffixAutoColon = true
+
+	spinner := newTestSpinner(t, cfg)
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	spinner.Render()
+	spinner.SetSpinnerAtEnd(true)
+	spinner.Render()
+	spinner.SetSpinnerAtEnd(false)
+	spinner.Render()
+	spinner.SetSpinnerAtEnd(true)
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	want := "\r\033[K\r y s: msg" +
+		"\r\033[K\rmsg y s" +
+		"\r\033[K\r y s: msg" +
+		"\r\033[K\rstop v s\n"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
+	}
+}
+
+func TestSpinner_autoSpace(t *testing.T) {
 	tests := []struct {
-		name    string
-		spinner *Spinner
-		want    string
+		name      string
+		prefix    string
+		message   string
+		autoSpace bool
+		want      string
 	}{
 		{
-			name: "spinner_no_hide_cursor",
-			spinner: &Spinner{
-				buffer:    &bytes.Buffer{},
-				mu:        &sync.Mutex{},
-				prefix:    "a",
-				message:   "msg",
-				suffix:    " ",
-				maxWidth:  1,
-				colorFn:   fmt.Sprintf,
-				chars:     []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
-				frequency: 10,
-				termMode:  termModeTTY,
-			},
-			want: "\r\033[K\ray msg\r\033[K\raz msg\r\033[K\raz msg\r\033[K\ray msg",
+			name:    "disabled",
+			message: "msg",
+			want:    "msgy",
 		},
 		{
-			name: "spinner_no_hide_cursor_spinnerAtEnd",
-			spinner: &Spinner{
-				buffer:       &bytes.Buffer{},
-				mu:           &sync.Mutex{},
-				prefix:       " a",
-				message:      "msg",
-				suffix:       " ",
-				maxWidth:     1,
-				colorFn:      fmt.Sprintf,
-				chars:        []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
-				frequency:    10,
-				spinnerAtEnd: true,
-				termMode:     termModeTTY,
-			},
-			want: "\r\033[K\rmsg ay \r\033[K\rmsg az \r\033[K\rmsg az \r\033[K\rmsg ay ",
+			name:      "empty_prefix",
+			message:   "msg",
+			autoSpace: true,
+			want:      "msg y",
 		},
 		{
-			name: "spinner_no_hide_cursor_auto_cursor_empty_suffix",
-			spinner: &Spinner{
-				buffer:          &bytes.Buffer{},
-				mu:              &sync.Mutex{},
-				prefix:          "a",
-				message:         "msg",
-				suffix:          " ",
-				maxWidth:        1,
-				colorFn:         fmt.Sprintf,
-				chars:           []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
-				frequency:       10,
-				suffixAutoColon: true,
-				termMode:        termModeTTY,
-			},
-			want: "\r\033[K\ray msg\r\033[K\raz msg\r\033[K\raz msg\r\033[K\ray msg",
+			name:      "single_space_prefix",
+			prefix:    " ",
+			message:   "msg",
+			autoSpace: true,
+			want:      "msg y",
 		},
 		{
-			name: "spinner_no_hide_cursor_auto_cursor_suffix",
-			spinner: &Spinner{
-				buffer:          &bytes.Buffer{},
-				mu:              &sync.Mutex{},
-				prefix:          "a",
-				message:         "msg",
-				suffix:          " foo",
-				maxWidth:        1,
-				colorFn:         fmt.Sprintf,
-				chars:           []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
-				frequency:       10,
-				suffixAutoColon: true,
-				termMode:        termModeTTY,
-			},
-			want: "\r\033[K\ray foo: msg\r\033[K\raz foo: msg\r\033[K\raz foo: msg\r\033[K\ray foo: msg",
+			name:      "many_spaces_prefix",
+			prefix:    "   ",
+			message:   "msg   ",
+			autoSpace: true,
+			want:      "msg y",
 		},
 		{
-			name: "spinner_hide_cursor",
-			spinner: &Spinner{
-				buffer:       &bytes.Buffer{},
-				cursorHidden: true,
-				mu:           &sync.Mutex{},
-				prefix:       "a",
-				message:      "msg",
-				suffix:       " ",
-				maxWidth:     1,
-				colorFn:      fmt.Sprintf,
-				chars:        []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
-				frequency:    10,
-				termMode:     termModeTTY,
-			},
-			want: "\r\033[K\r\r\033[?25l\ray msg\r\033[K\r\r\033[?25l\raz msg\r\033[K\r\r\033[?25l\raz msg\r\033[K\r\r\033[?25l\ray msg",
+			name:      "text_prefix",
+			prefix:    "[",
+			message:   "msg",
+			autoSpace: true,
+			want:      "msg [y",
 		},
 		{
-			name: "spinner_hide_cursor_dumbterm",
-			spinner: &Spinner{
-				buffer:       &bytes.Buffer{},
-				cursorHidden: true,
-				mu:           &sync.Mutex{},
-				prefix:       "a",
-				message:      "msg",
-				suffix:       " ",
-				maxWidth:     1,
-				colorFn:      fmt.Sprintf,
-				chars:        []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
-				frequency:    10,
-				// TODO(theckman): verify
-				termMode: ForceDumbTerminalMode,
-			},
-			want: "\r\ray msg\r      \raz msg\r      \raz msg\r      \ray msg",
+			name:      "spaced_text_prefix",
+			prefix:    "  [",
+			message:   "msg ",
+			autoSpace: true,
+			want:      "msg [y",
 		},
 		{
-			name: "spinner_empty_print",
-			spinner: &Spinner{
-				buffer:    &bytes.Buffer{},
-				mu:        &sync.Mutex{},
-				maxWidth:  0,
-				colorFn:   fmt.Sprintf,
-				chars:     []character{{Value: "", Size: 0}},
-				frequency: 10,
-				termMode:  termModeTTY,
-			},
-			want: "\r\033[K\r\r\033[K\r\r\033[K\r\r\033[K\r",
+			name:      "no_message",
+			prefix:    "  ",
+			autoSpace: true,
+			want:      "y",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			buf := &bytes.Buffer{}
-			tt.spinner.writer = buf
 
-			tm := time.NewTimer(10 * time.Millisecond)
+			spinner, err := New(Config{
+				Writer:       buf,
+				CharSet:      []string{"y"},
+				Prefix:       tt.prefix,
+				Message:      tt.message,
+				SpinnerAtEnd: true,
+				AutoSpace:    tt.autoSpace,
+				ShowCursor:   true,
+				TerminalMode: termModeTTY,
+			})
+			testErrCheck(t, "New()", "", err)
 
-			tt.spinner.paintUpdate(tm, true)
-			tt.spinner.paintUpdate(tm, true)
-			tt.spinner.paintUpdate(tm, false)
-			tt.spinner.paintUpdate(tm, true)
-			tm.Stop()
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
 
-			got := buf.String()
+			spinner.Render()
 
-			if diff := cmp.Diff(tt.want, got); diff != "" {
+			if diff := cmp.Diff("\r\033[K\r"+tt.want, buf.String()); diff != "" {
 				t.Fatalf("output differs: (-want / +got)\n%s", diff)
 			}
 		})
 	}
 }
 
-func TestSpinner_paintStop(t *testing.T) {
+func TestSpinner_SetSuffix(t *testing.T) {
 	tests := []struct {
-		name    string
-		ok      bool
-		spinner *Spinner
-		want    string
+		name   string
+		suffix string
+		colors []string
+		want   string
+		err    string
 	}{
 		{
-			name: "ok",
-			ok:   true,
-			spinner: &Spinner{
-				buffer:      &bytes.Buffer{},
-				mu:          &sync.Mutex{},
-				prefix:      "a",
-				suffix:      " ",
-				maxWidth:    1,
-				stopColorFn: fmt.Sprintf,
-				stopChar:    character{Value: "x", Size: 1},
-				stopMsg:     "stop",
-				termMode:    termModeTTY,
-			},
-			want: "\r\033[K\rax stop\n",
+			name:   "no_colors",
+			suffix: " suffix",
+			want:   " suffix",
 		},
 		{
-			name: "ok_spinnerAtEnd",
-			ok:   true,
-			spinner: &Spinner{
-				buffer:       &bytes.Buffer{},
-				mu:           &sync.Mutex{},
-				prefix:       " a",
-				suffix:       " ",
-				maxWidth:     1,
-				stopColorFn:  fmt.Sprintf,
-				spinnerAtEnd: true,
-				stopChar:     character{Value: "x", Size: 1},
-				stopMsg:      "stop",
-				termMode:     termModeTTY,
-			},
-			want: "\r\033[K\rstop ax \n",
+			name:   "valid_colors",
+			suffix: " suffix",
+			colors: []string{"fgYellow", "bold"},
+			want:   color.New(color.FgYellow, color.Bold).Sprintf("%s", " suffix"),
 		},
 		{
-			name: "ok_spinnerAtEnd_suffixAutoColon",
-			ok:   true,
-			spinner: &Spinner{
-				buffer:          &bytes.Buffer{},
-				mu:              &sync.Mutex{},
-				prefix:          " a",
-				suffix:          " ",
-				maxWidth:        1,
-				stopColorFn:     fmt.Sprintf,
-				spinnerAtEnd:    true,
-				suffixAutoColon: true,
-				stopChar:        character{Value: "x", Size: 1},
-				stopMsg:         "stop",
-				termMode:        termModeTTY,
-			},
-			want: "\r\033[K\rstop ax \n",
+			name:   "invalid_colors",
+			suffix: " suffix",
+			colors: []string{"fgYellow", "invalid"},
+			err:    "failed to build suffix color function: invalid is not a valid color",
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner := &Spinner{
+				mu:           &sync.Mutex{},
+				suffix:       " old",
+				dataUpdateCh: make(chan struct{}, 1),
+			}
+
+			err := spinner.SetSuffix(tt.suffix, tt.colors...)
+
+			if cont := testErrCheck(t, "spinner.SetSuffix()", tt.err, err); !cont {
+				if spinner.suffix != " old" {
+					t.Errorf("spinner.suffix = %q, want %q", spinner.suffix, " old")
+				}
+
+				return
+			}
+
+			if spinner.suffix != tt.suffix {
+				t.Errorf("spinner.suffix = %q, want %q", spinner.suffix, tt.suffix)
+			}
+
+			if got := colorSegment(spinner.suffixColorFn, spinner.suffix); got != tt.want {
+				t.Errorf("colorSegment() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpinner_AppendMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		appends []string
+		want    string
+	}{
 		{
-			name: "ok_auto_colon_empty_suffix",
-			ok:   true,
-			spinner: &Spinner{
-				buffer:          &bytes.Buffer{},
-				mu:              &sync.Mutex{},
-				prefix:          "a",
-				suffix:          " ",
-				maxWidth:        1,
-				stopColorFn:     fmt.Sprintf,
-				stopChar:        character{Value: "x", Size: 1},
-				stopMsg:         "stop",
-				suffixAutoColon: true,
-				termMode:        termModeTTY,
-			},
-			want: "\r\033[K\rax stop\n",
+			name:    "empty_message",
+			appends: []string{"downloading"},
+			want:    "downloading",
 		},
 		{
-			name: "ok_auto_colon_suffix",
-			ok:   true,
-			spinner: &Spinner{
-				buffer:          &bytes.Buffer{},
-				mu:              &sync.Mutex{},
-				prefix:          "a",
-				suffix:          " foo",
-				maxWidth:        1,
-				stopColorFn:     fmt.Sprintf,
-				stopChar:        character{Value: "x", Size: 1},
-				stopMsg:         "stop",
-				suffixAutoColon: true,
-				termMode:        termModeTTY,
-			},
-			want: "\r\033[K\rax foo: stop\n",
+			name:    "successive_appends",
+			message: "downloading",
+			appends: []string{".", ".", "."},
+			want:    "downloading...",
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner := withMessage(&Spinner{
+				mu:           &sync.Mutex{},
+				dataUpdateCh: make(chan struct{}, 1),
+			}, tt.message)
+
+			for _, a := range tt.appends {
+				spinner.AppendMessage(a)
+			}
+
+			if got := spinner.loadMessage().text; got != tt.want {
+				t.Errorf("spinner.loadMessage().text = %q, want %q", got, tt.want)
+			}
+
+			select {
+			case <-spinner.dataUpdateCh:
+			default:
+				t.Error("data update notification not sent")
+			}
+		})
+	}
+}
+
+func TestSpinner_MessageReset(t *testing.T) {
+	tests := []struct {
+		name  string
+		index int
+	}{
 		{
-			name: "ok_auto_colon_no_msg",
-			ok:   true,
-			spinner: &Spinner{
-				buffer:          &bytes.Buffer{},
-				mu:              &sync.Mutex{},
-				prefix:          "a",
-				suffix:          " ",
-				maxWidth:        1,
-				stopColorFn:     fmt.Sprintf,
-				stopChar:        character{Value: "x", Size: 1},
-				stopMsg:         "",
-				suffixAutoColon: true,
-				termMode:        termModeTTY,
-			},
-			want: "\r\033[K\rax \n",
+			name:  "index_zero",
+			index: 0,
 		},
 		{
-			name: "ok_unhide",
-			ok:   true,
-			spinner: &Spinner{
-				buffer:       &bytes.Buffer{},
-				mu:           &sync.Mutex{},
-				cursorHidden: true,
-				prefix:       "a",
-				suffix:       " ",
-				maxWidth:     1,
-				stopColorFn:  fmt.Sprintf,
-				stopChar:     character{Value: "x", Size: 1},
-				stopMsg:      "stop",
-				termMode:     termModeTTY,
-			},
-			want: "\r\033[K\r\r\033[?25h\rax stop\n",
+			name:  "index_nonzero",
+			index: 2,
 		},
-		{
-			name: "ok_unhide_dumbterm",
-			ok:   true,
-			spinner: &Spinner{
-				buffer:       &bytes.Buffer{},
-				mu:           &sync.Mutex{},
-				cursorHidden: true,
-				prefix:       "a",
-				suffix:       " ",
-				maxWidth:     1,
-				stopColorFn:  fmt.Sprintf,
-				stopChar:     character{Value: "x", Size: 1},
-				stopMsg:      "stop",
-				// TODO(theckman): verify
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner, err := New(Config{Frequency: time.Second, CharSet: CharSets[26]})
+			testErrCheck(t, "New()", "", err)
+
+			spinner.index = tt.index
+
+			spinner.MessageReset("new phase")
+
+			if got := spinner.loadMessage().text; got != "new phase" {
+				t.Errorf("spinner.loadMessage().text = %q, want %q", got, "new phase")
+			}
+
+			if spinner.index != 0 {
+				t.Errorf("spinner.index = %d, want 0", spinner.index)
+			}
+		})
+	}
+}
+
+func TestSpinner_Deadline(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	cfg := testConfig(buf, ForceNoTTYMode|ForceDumbTerminalMode)
+	cfg.StopCharacter = ""
+	cfg.StopMessage = ""
+	cfg.ShowRemaining = true
+
+	spinner := newTestSpinner(t, cfg)
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	// no deadline set
+	spinner.Render()
+
+	for _, d := range []time.Duration{3 * time.Second, time.Second, -time.Second} {
+		spinner.Deadline(time.Now().Add(d))
+		spinner.Render()
+	}
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	want := "y msg\ny msg 3s\ny msg 1s\ny msg 0s\n"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
+	}
+}
+
+func TestSpinner_Percent(t *testing.T) {
+	tests := []struct {
+		name    string
+		percent float64
+		err     string
+	}{
+		{
+			name:    "zero",
+			percent: 0,
+		},
+		{
+			name:    "hundred",
+			percent: 100,
+		},
+		{
+			name:    "negative",
+			percent: -1,
+			err:     "percent must be between 0 and 100",
+		},
+		{
+			name:    "over_hundred",
+			percent: 100.5,
+			err:     "percent must be between 0 and 100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner := &Spinner{
+				mu:      &sync.Mutex{},
+				percent: 50,
+			}
+
+			err := spinner.Percent(tt.percent)
+
+			if cont := testErrCheck(t, "spinner.Percent()", tt.err, err); !cont {
+				if spinner.percent != 50 {
+					t.Errorf("spinner.percent = %f, want 50", spinner.percent)
+				}
+
+				return
+			}
+
+			if spinner.percent != tt.percent {
+				t.Errorf("spinner.percent = %f, want %f", spinner.percent, tt.percent)
+			}
+
+			if !spinner.percentSet {
+				t.Error("spinner.percentSet = false, want true")
+			}
+		})
+	}
+}
+
+func TestNew_percentColorThresholds(t *testing.T) {
+	tests := []struct {
+		name       string
+		thresholds []PercentColorThreshold
+		err        string
+	}{
+		{
+			name:       "valid",
+			thresholds: []PercentColorThreshold{{Min: 50, Colors: []string{"fgGreen"}}, {Min: 0, Colors: []string{"fgRed"}}},
+		},
+		{
+			name:       "NaN_min",
+			thresholds: []PercentColorThreshold{{Min: 0, Colors: []string{"fgRed"}}, {Min: math.NaN(), Colors: []string{"fgGreen"}}},
+			err:        "cfg.PercentColorThresholds[1].Min must be a number",
+		},
+		{
+			name:       "invalid_color",
+			thresholds: []PercentColorThreshold{{Min: 0, Colors: []string{"bogus"}}},
+			err:        "failed to build percent color threshold 0 color function: bogus is not a valid color",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner, err := New(Config{
+				Writer:                 &bytes.Buffer{},
+				PercentColorThresholds: tt.thresholds,
+				TerminalMode:           termModeTTY,
+			})
+			if cont := testErrCheck(t, "New()", tt.err, err); !cont {
+				return
+			}
+
+			// sorted by Min
+			want := []PercentColorThreshold{tt.thresholds[1], tt.thresholds[0]}
+
+			if diff := cmp.Diff(want, spinner.Config().PercentColorThresholds); diff != "" {
+				t.Fatalf("Config().PercentColorThresholds differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_percentColorThresholds(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	red, yellow, green := color.New(color.FgRed), color.New(color.FgYellow), color.New(color.FgGreen)
+
+	tests := []struct {
+		name     string
+		colorAll bool
+		want     string
+	}{
+		{
+			name: "percent",
+			want: "\r\033[K\ry msg 5%" +
+				"\r\033[K\ry msg " + red.Sprint("10%") +
+				"\r\033[K\ry msg " + yellow.Sprint("50%") +
+				"\r\033[K\ry msg " + green.Sprint("90%") +
+				"\r\033[K\r",
+		},
+		{
+			name:     "color_all",
+			colorAll: true,
+			want: "\r\033[K\ry msg 5%" +
+				"\r\033[K\r" + red.Sprint("y msg 10%") +
+				"\r\033[K\r" + yellow.Sprint("y msg 50%") +
+				"\r\033[K\r" + green.Sprint("y msg 90%") +
+				"\r\033[K\r",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, termModeTTY)
+			cfg.StopCharacter = ""
+			cfg.StopMessage = ""
+			cfg.ShowPercent = true
+			cfg.PercentColorThresholds = []PercentColorThreshold{
+				{Min: 70, Colors: []string{"fgGreen"}},
+				{Min: 10, Colors: []string{"fgRed"}},
+				{Min: 30, Colors: []string{"fgYellow"}},
+			}
+			cfg.PercentColorAll = tt.colorAll
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			for _, pct := range []float64{5, 10, 50, 90} {
+				testErrCheck(t, "spinner.Percent()", "", spinner.Percent(pct))
+				spinner.Render()
+			}
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_SubMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		termMode TerminalMode
+		keepSub  bool // don't clear the sub message before stopping
+		want     string
+	}{
+		{
+			name:     "smart_term",
+			termMode: termModeTTY,
+			want: "\r\033[K\ry msg\n  detail" +
+				"\r\033[K\033[1A\r\033[K\rz msg\n  other" +
+				"\r\033[K\033[1A\r\033[K\ry msg" +
+				"\r\033[K\rv stop\n",
+		},
+		{
+			name:     "smart_term_stop",
+			termMode: termModeTTY,
+			keepSub:  true,
+			want: "\r\033[K\ry msg\n  detail" +
+				"\r\033[K\033[1A\r\033[K\rz msg\n  other" +
+				"\r\033[K\033[1A\r\033[K\rv stop\n",
+		},
+		{
+			name:     "dumb_term",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			want:     "\r\ry msg\r     \rz msg\r     \ry msg\r     \rv stop\n",
+		},
+		{
+			name:     "not_tty",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			want:     "y msg\n  detail\nz msg\n  other\ny msg\nv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.CharSet = []string{"y", "z"}
+			cfg.SubMessage = "detail"
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+			spinner.SubMessage("other")
+			spinner.Render()
+
+			if !tt.keepSub {
+				spinner.SubMessage("")
+				spinner.Render()
+			}
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_Freeze(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	spinner, err := New(Config{
+		Writer:       buf,
+		CharSet:      []string{"x", "y", "z"},
+		Suffix:       " ",
+		Message:      "msg",
+		ShowCursor:   true,
+		TerminalMode: termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	spinner.Render()
+	spinner.Freeze()
+	spinner.Render()
+	spinner.Message("other")
+	spinner.Render()
+	spinner.Thaw()
+	spinner.Render()
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	want := "\r\033[K\rx msg" +
+		"\r\033[K\rx msg" +
+		"\r\033[K\rx other" +
+		"\r\033[K\ry other" +
+		"\r\033[K\r"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
+	}
+}
+
+func TestSpinner_HideSpinner(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	cfg := testConfig(buf, termModeTTY)
+	cfg.CharSet = []string{"a", "b", "c"}
+	cfg.Prefix = "["
+	cfg.Suffix = "] "
+
+	spinner := newTestSpinner(t, cfg)
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	spinner.Render()
+	spinner.HideSpinner(true)
+	spinner.Render()
+
+	if got := spinner.RenderedWidth(); got != 3 {
+		t.Fatalf("spinner.RenderedWidth() = %d, want 3", got)
+	}
+
+	spinner.Render()
+	spinner.HideSpinner(false)
+	spinner.Render()
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	// the animation advances while hidden
+	want := "\r\033[K\r[a] msg" +
+		"\r\033[K\rmsg" +
+		"\r\033[K\rmsg" +
+		"\r\033[K\r[a] msg" +
+		"\r\033[K\r[v] stop\n"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
+	}
+}
+
+func TestSpinner_HideSpinner_colorAll(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	buf := &bytes.Buffer{}
+
+	cfg := testConfig(buf, termModeTTY)
+	cfg.Message = "50% done"
+	cfg.Colors = []string{"fgRed"}
+	cfg.ColorAll = true
+	cfg.ShowPercent = true
+
+	spinner := newTestSpinner(t, cfg)
+
+	spinner.HideSpinner(true)
+	spinner.Percent(50)
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	spinner.Render()
+
+	// the message must not be used as a format string
+	want := "\r\033[K\r\x1b[31m50% done 50%\x1b[0m"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
+	}
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+}
+
+func TestSpinner_SetDirection(t *testing.T) {
+	tests := []struct {
+		name    string
+		forward bool
+		want    []int
+	}{
+		{
+			name:    "forward",
+			forward: true,
+			want:    []int{1, 2, 0, 1},
+		},
+		{
+			name:    "backward",
+			forward: false,
+			want:    []int{2, 1, 0, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner := &Spinner{
+				buffer:   &bytes.Buffer{},
+				writer:   io.Discard,
+				mu:       &sync.Mutex{},
+				colorFn:  fmt.Sprintf,
+				chars:    []character{{Value: "x", Size: 1}, {Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				maxWidth: 1,
+				termMode: termModeTTY,
+			}
+
+			spinner.SetDirection(tt.forward)
+
+			if spinner.backward != !tt.forward {
+				t.Fatalf("spinner.backward = %t, want %t", spinner.backward, !tt.forward)
+			}
+
+			for i, want := range tt.want {
+				spinner.paintUpdate(nil, true)
+
+				if spinner.index != want {
+					t.Fatalf("spinner.index after frame %d = %d, want %d", i, spinner.index, want)
+				}
+			}
+
+			if len(spinner.chars) != 3 || spinner.chars[0].Value != "x" {
+				t.Error("spinner.chars was modified")
+			}
+		})
+	}
+}
+
+func TestSpinner_erase(t *testing.T) {
+	const want = "\r\033[K\r"
+
+	buf := &bytes.Buffer{}
+
+	testErrCheck(t, "spinner.erase()", "", erase(buf))
+
+	got := buf.String()
+
+	if got != want {
+		t.Errorf("got = %q, want %q", got, want)
+	}
+}
+
+func TestSpinner_hideCursor(t *testing.T) {
+	const want = "\r\033[?25l\r"
+
+	buf := &bytes.Buffer{}
+
+	testErrCheck(t, "spinner.hideCursor()", "", hideCursor(buf))
+
+	got := buf.String()
+
+	if got != want {
+		t.Errorf("got = %q, want %q", got, want)
+	}
+}
+
+func TestSpinner_unhideCursor(t *testing.T) {
+	const want = "\r\033[?25h\r"
+
+	buf := &bytes.Buffer{}
+
+	testErrCheck(t, "spinner.unhideCursor()", "", unhideCursor(buf))
+
+	got := buf.String()
+
+	if got != want {
+		t.Errorf("got = %q, want %q", got, want)
+	}
+}
+
+func TestSpinner_Start(t *testing.T) {
+	tests := []struct {
+		name    string
+		spinner *Spinner
+
+		err string
+	}{
+		{
+			name: "invalid_frequency_when_tty",
+			spinner: &Spinner{
+				status:          uint32Ptr(statusStopped),
+				mu:              &sync.Mutex{},
+				frequency:       0,
+				colorFn:         fmt.Sprintf,
+				stopColorFn:     fmt.Sprintf,
+				stopFailColorFn: fmt.Sprintf,
+				termMode:        ForceTTYMode,
+			},
+			err: "spinner Frequency duration must be greater than 0 when used within a TTY",
+		},
+		{
+			name: "running_spinner",
+			spinner: &Spinner{
+				status:          uint32Ptr(statusRunning),
+				mu:              &sync.Mutex{},
+				frequency:       time.Millisecond,
+				colorFn:         fmt.Sprintf,
+				stopColorFn:     fmt.Sprintf,
+				stopFailColorFn: fmt.Sprintf,
+			},
+			err: "spinner already running or shutting down",
+		},
+		{
+			name: "empty_CharSet",
+			spinner: &Spinner{
+				buffer:          &bytes.Buffer{},
+				status:          uint32Ptr(statusStopped),
+				mu:              &sync.Mutex{},
+				frequency:       time.Millisecond,
+				colorFn:         fmt.Sprintf,
+				stopColorFn:     fmt.Sprintf,
+				stopFailColorFn: fmt.Sprintf,
+				stopMsg:         "stop msg",
+				stopFailMsg:     "stop fail msg",
+			},
+			err: "before starting the spinner a CharSet must be set",
+		},
+		{
+			name: "spinner",
+			spinner: &Spinner{
+				buffer:          &bytes.Buffer{},
+				status:          uint32Ptr(statusStopped),
+				mu:              &sync.Mutex{},
+				frequency:       time.Millisecond,
+				colorFn:         fmt.Sprintf,
+				stopColorFn:     fmt.Sprintf,
+				stopFailColorFn: fmt.Sprintf,
+				stopMsg:         "stop msg",
+				stopFailMsg:     "stop fail msg",
+				maxWidth:        3,
+				chars: []character{
+					character{
+						Value: ".",
+						Size:  1,
+					},
+					character{
+						Value: "..",
+						Size:  21,
+					},
+					character{
+						Value: "...",
+						Size:  3,
+					},
+				},
+			},
+		},
+		{
+			name: "spinner_not_tty",
+			spinner: &Spinner{
+				buffer:          &bytes.Buffer{},
+				status:          uint32Ptr(statusStopped),
+				mu:              &sync.Mutex{},
+				frequency:       9223372036854775807,
+				colorFn:         fmt.Sprintf,
+				stopColorFn:     fmt.Sprintf,
+				stopFailColorFn: fmt.Sprintf,
+				stopMsg:         "stop msg",
+				stopFailMsg:     "stop fail msg",
+				termMode:        ForceNoTTYMode,
+				maxWidth:        3,
+				chars: []character{
+					character{
+						Value: ".",
+						Size:  1,
+					},
+					character{
+						Value: "..",
+						Size:  21,
+					},
+					character{
+						Value: "...",
+						Size:  3,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			tt.spinner.writer = buf
+
+			err := tt.spinner.Start()
+
+			if cont := testErrCheck(t, "Start()", tt.err, err); !cont {
+				return
+			}
+
+			if tt.spinner.cancelCh == nil {
+				t.Fatal("tt.spinner.cancelCh == nil")
+			}
+
+			if tt.spinner.doneCh == nil {
+				t.Fatal("tt.spinner.doneCh == nil")
+			}
+
+			close(tt.spinner.cancelCh)
+
+			<-tt.spinner.doneCh
+
+			if buf.Len() == 0 {
+				t.Fatal("painter did not write data")
+			}
+
+			if max := time.Duration(math.MaxInt64); termModeForceNoTTY(tt.spinner.termMode) && tt.spinner.frequency != max {
+				t.Fatalf("tt.spinner.duration = %s, want %s", tt.spinner.frequency, max)
+			}
+		})
+	}
+}
+
+func TestSpinner_idempotentStart(t *testing.T) {
+	tests := []struct {
+		name       string
+		idempotent bool
+		pause      bool
+		err        string
+	}{
+		{
+			name: "default",
+			err:  "spinner already running or shutting down",
+		},
+		{
+			name:       "idempotent",
+			idempotent: true,
+		},
+		{
+			name:       "idempotent_paused",
+			idempotent: true,
+			pause:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner, err := New(Config{
+				Writer:          &bytes.Buffer{},
+				CharSet:         []string{"y"},
+				TerminalMode:    termModeTTY,
+				IdempotentStart: tt.idempotent,
+			})
+			testErrCheck(t, "New()", "", err)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			if tt.pause {
+				testErrCheck(t, "spinner.Pause()", "", spinner.Pause())
+			}
+
+			want := SpinnerRunning
+			if tt.pause {
+				want = SpinnerPaused
+			}
+
+			testErrCheck(t, "spinner.Start()", tt.err, spinner.Start())
+			testErrCheck(t, "spinner.StartManual()", tt.err, spinner.StartManual())
+
+			if st := spinner.Status(); st != want {
+				t.Fatalf("spinner.Status() = %s, want %s", st, want)
+			}
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+		})
+	}
+}
+
+func TestSpinner_cycleOnce(t *testing.T) {
+	t.Run("painter", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		cfg := testConfig(buf, termModeTTY)
+		cfg.Message = ""
+		cfg.Frequency = time.Millisecond
+		cfg.CharSet = []string{"x", "y", "z"}
+		cfg.CycleOnce = true
+
+		spinner := newTestSpinner(t, cfg)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		deadline := time.Now().Add(time.Second)
+
+		for spinner.Status() != SpinnerStopped {
+			if time.Now().After(deadline) {
+				t.Fatal("spinner did not stop itself after one cycle")
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+
+		want := "\r\033[K\rx \r\033[K\ry \r\033[K\rz \r\033[K\rv stop\n"
+
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		}
+
+		testErrCheck(t, "spinner.Stop()", "spinner not running or paused", spinner.Stop())
+	})
+
+	t.Run("manual", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		spinner, err := New(Config{
+			Writer:        buf,
+			CharSet:       []string{"x", "y"},
+			Suffix:        " ",
+			ShowCursor:    true,
+			StopCharacter: "v",
+			TerminalMode:  termModeTTY,
+			CycleOnce:     true,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+		spinner.Render()
+
+		if st := spinner.Status(); st != SpinnerRunning {
+			t.Fatalf("spinner.Status() = %s, want %s", st, SpinnerRunning)
+		}
+
+		spinner.Render()
+
+		if st := spinner.Status(); st != SpinnerStopped {
+			t.Fatalf("spinner.Status() = %s, want %s", st, SpinnerStopped)
+		}
+
+		want := "\r\033[K\rx \r\033[K\ry \r\033[K\rv \n"
+
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		}
+	})
+}
+
+func TestSpinner_preserveIndexOnStop(t *testing.T) {
+	tests := []struct {
+		name     string
+		preserve bool
+		want     string
+	}{
+		{
+			name: "reset",
+			want: "x\ny\nv\nx\nv\n",
+		},
+		{
+			name:     "preserve",
+			preserve: true,
+			want:     "x\ny\nv\nz\nv\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			spinner, err := New(Config{
+				Writer:              buf,
+				CharSet:             []string{"x", "y", "z"},
+				StopCharacter:       "v",
+				TerminalMode:        ForceNoTTYMode | ForceDumbTerminalMode,
+				PreserveIndexOnStop: tt.preserve,
+			})
+			testErrCheck(t, "New()", "", err)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+			spinner.Render()
+			spinner.Render()
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+			spinner.Render()
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_onFrame(t *testing.T) {
+	t.Run("manual", func(t *testing.T) {
+		var got []int
+
+		spinner, err := New(Config{
+			Writer:       &bytes.Buffer{},
+			CharSet:      []string{"x", "y", "z"},
+			TerminalMode: termModeTTY,
+			OnFrame:      func(index int) { got = append(got, index) },
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+		for i := 0; i < 4; i++ {
+			spinner.Render()
+		}
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		if diff := cmp.Diff([]int{0, 1, 2, 0}, got); diff != "" {
+			t.Fatalf("indices differ: (-want / +got)\n%s", diff)
+		}
+	})
+
+	t.Run("painter", func(t *testing.T) {
+		frames := make(chan int, 16)
+
+		spinner, err := New(Config{
+			Writer:       &bytes.Buffer{},
+			Frequency:    time.Millisecond,
+			CharSet:      []string{"x", "y", "z", "a", "b"},
+			TerminalMode: termModeTTY,
+			OnFrame: func(index int) {
+				select {
+				case frames <- index:
+				default:
+				}
+			},
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		for want := 0; want < 3; want++ {
+			select {
+			case got := <-frames:
+				if got != want {
+					t.Fatalf("index = %d, want %d", got, want)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for OnFrame to be called")
+			}
+		}
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+	})
+}
+
+func TestSpinner_Render(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	cfg := testConfig(buf, termModeTTY)
+	cfg.CharSet = []string{"y", "z"}
+
+	spinner := newTestSpinner(t, cfg)
+
+	// not started manually, so nothing should be rendered
+	spinner.Render()
+
+	if n := buf.Len(); n != 0 {
+		t.Fatalf("buf.Len() = %d, want 0", n)
+	}
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	frames := []string{"\r\033[K\ry msg", "\r\033[K\rz msg", "\r\033[K\ry msg"}
+
+	for i, want := range frames {
+		spinner.Render()
+
+		got := buf.String()
+		buf.Reset()
+
+		if got != want {
+			t.Fatalf("frame %d = %q, want %q", i, got, want)
+		}
+	}
+
+	testErrCheck(t, "spinner.Pause()", "", spinner.Pause())
+
+	spinner.Render()
+
+	if n := buf.Len(); n != 0 {
+		t.Fatalf("buf.Len() = %d while paused, want 0", n)
+	}
+
+	testErrCheck(t, "spinner.Unpause()", "", spinner.Unpause())
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	if got, want := buf.String(), "\r\033[K\rv stop\n"; got != want {
+		t.Fatalf("stop output = %q, want %q", got, want)
+	}
+
+	if spinner.manual {
+		t.Error("spinner.manual = true after Stop(), want false")
+	}
+}
+
+func TestSpinner_dataUpdateBuffer(t *testing.T) {
+	tests := []struct {
+		name   string
+		buffer int
+		want   string
+	}{
+		{
+			name: "default",
+			want: "y msg0\nz msg3\nv stop\n",
+		},
+		{
+			name:   "buffered",
+			buffer: 3,
+			want:   "y msg0\nz msg3\ny msg3\nz msg3\nv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, ForceNoTTYMode|ForceDumbTerminalMode)
+			cfg.ShowCursor = false
+			cfg.CharSet = []string{"y", "z"}
+			cfg.Message = "msg0"
+			cfg.DataUpdateBuffer = tt.buffer
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+			// let the first frame render
+			time.Sleep(50 * time.Millisecond)
+
+			// queue up the updates while the painter isn't receiving them
+			testErrCheck(t, "spinner.Pause()", "", spinner.Pause())
+
+			for i := 1; i <= 3; i++ {
+				spinner.Message(fmt.Sprintf("msg%d", i))
+			}
+
+			testErrCheck(t, "spinner.Unpause()", "", spinner.Unpause())
+
+			time.Sleep(50 * time.Millisecond)
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_silentWhenNotTTY(t *testing.T) {
+	tests := []struct {
+		name     string
+		termMode TerminalMode
+		want     string
+	}{
+		{
+			name:     "not_tty",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			want:     "v stop\n",
+		},
+		{
+			name:     "tty",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			want:     "\r\ry msg\r     \ry msg\r     \rv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.ShowCursor = false
+			cfg.Frequency = time.Hour
+			cfg.CharSet = []string{"y", "z"}
+			cfg.SilentWhenNotTTY = true
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+			time.Sleep(50 * time.Millisecond)
+
+			spinner.Message("msg")
+
+			time.Sleep(50 * time.Millisecond)
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+// timedWriter records the time of each call to Write
+type timedWriter struct {
+	mu     sync.Mutex
+	writes []time.Time
+}
+
+func (w *timedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.writes = append(w.writes, time.Now())
+
+	return len(p), nil
+}
+
+func TestSpinner_maxRedrawRate(t *testing.T) {
+	t.Run("coalesced_and_flushed", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		cfg := testConfig(buf, termModeTTY)
+		cfg.CharSet = []string{"x", "y", "z"}
+		cfg.MaxRedrawRate = time.Hour
+
+		spinner := newTestSpinner(t, cfg)
+
+		testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+		spinner.Render()
+		spinner.Render()
+		spinner.Render()
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		const want = "\r\033[K\rx msg\r\033[K\rz msg\r\033[K\rv stop\n"
+
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		}
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("skipping test in short mode.")
+		}
+
+		const rate = 50 * time.Millisecond
+
+		w := &timedWriter{}
+
+		spinner, err := New(Config{
+			Frequency:     5 * time.Millisecond,
+			Writer:        w,
+			CharSet:       []string{"x", "y", "z"},
+			TerminalMode:  termModeTTY,
+			MaxRedrawRate: rate,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		time.Sleep(300 * time.Millisecond)
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		// the last two writes are likely the flushed frame and the stop line
+		writes := w.writes[:len(w.writes)-2]
+
+		if len(writes) < 3 {
+			t.Fatalf("len(writes) = %d, want at least 3", len(writes))
+		}
+
+		for i := 1; i < len(writes); i++ {
+			if d := writes[i].Sub(writes[i-1]); d < rate {
+				t.Fatalf("write %d happened %s after the previous, want at least %s", i, d, rate)
+			}
+		}
+	})
+}
+
+var errFlakyWrite = errors.New("flaky write")
+
+// flakyWriter is an io.Writer that fails while fail is set
+type flakyWriter struct {
+	mu     sync.Mutex
+	fail   bool
+	writes int
+}
+
+func (w *flakyWriter) setFail(fail bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.fail = fail
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.writes++
+
+	if w.fail {
+		return 0, errFlakyWrite
+	}
+
+	return len(p), nil
+}
+
+func TestSpinner_maxWriteErrors(t *testing.T) {
+	t.Run("painter", func(t *testing.T) {
+		w := &flakyWriter{fail: true}
+
+		spinner, err := New(Config{
+			Writer:         w,
+			Frequency:      time.Millisecond,
+			CharSet:        []string{"y", "z"},
+			TerminalMode:   termModeTTY,
+			MaxWriteErrors: 3,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		deadline := time.Now().Add(time.Second)
+
+		for spinner.Status() != SpinnerStopped {
+			if time.Now().After(deadline) {
+				t.Fatal("spinner did not stop itself after reaching MaxWriteErrors")
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+
+		if err := spinner.LastError(); !errors.Is(err, errFlakyWrite) {
+			t.Fatalf("spinner.LastError() = %v, want %v", err, errFlakyWrite)
+		}
+
+		w.mu.Lock()
+		writes := w.writes
+		w.mu.Unlock()
+
+		if writes != 3 {
+			t.Errorf("writes = %d, want 3", writes)
+		}
+
+		testErrCheck(t, "spinner.Stop()", "spinner not running or paused", spinner.Stop())
+
+		// the spinner must be able to start again
+		w.setFail(false)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		if err := spinner.LastError(); err != nil {
+			t.Errorf("spinner.LastError() = %v, want <nil>", err)
+		}
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+	})
+
+	t.Run("consecutive", func(t *testing.T) {
+		w := &flakyWriter{}
+
+		spinner, err := New(Config{
+			Writer:         w,
+			CharSet:        []string{"y", "z"},
+			TerminalMode:   termModeTTY,
+			MaxWriteErrors: 2,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+		// a successful write resets the count of failures
+		w.setFail(true)
+		spinner.Render()
+		w.setFail(false)
+		spinner.Render()
+		w.setFail(true)
+		spinner.Render()
+
+		if st := spinner.Status(); st != SpinnerRunning {
+			t.Fatalf("spinner.Status() = %s, want %s", st, SpinnerRunning)
+		}
+
+		spinner.Render()
+
+		if st := spinner.Status(); st != SpinnerStopped {
+			t.Fatalf("spinner.Status() = %s, want %s", st, SpinnerStopped)
+		}
+
+		if err := spinner.LastError(); !errors.Is(err, errFlakyWrite) {
+			t.Fatalf("spinner.LastError() = %v, want %v", err, errFlakyWrite)
+		}
+	})
+}
+
+func TestSpinner_newline(t *testing.T) {
+	tests := []struct {
+		name     string
+		termMode TerminalMode
+		json     bool
+		want     string
+	}{
+		{
+			name:     "smart_term",
+			termMode: termModeTTY,
+			want:     "\r\033[K\ry msg\r\n  detail\r\033[K\033[1A\r\033[K\rv stop\r\n",
+		},
+		{
+			name:     "not_tty",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			want:     "y msg\r\n  detail\r\nv stop\r\n",
+		},
+		{
+			name:     "not_tty_json",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			json:     true,
+			want:     `{"status":"running","message":"msg","elapsed_ms":0}` + "\r\n" + `{"status":"stopped","message":"stop","elapsed_ms":0}` + "\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.SubMessage = "detail"
+			cfg.JSONMode = tt.json
+			cfg.Newline = "\r\n"
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			got := buf.String()
+
+			if tt.json {
+				// the elapsed time isn't deterministic
+				got = regexp.MustCompile(`"elapsed_ms":\d+`).ReplaceAllString(got, `"elapsed_ms":0`)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_forceColorInNoTTY(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	red, green := color.New(color.FgRed), color.New(color.FgGreen)
+
+	tests := []struct {
+		name       string
+		forceColor bool
+		termMode   TerminalMode
+		want       string
+	}{
+		{
+			name:       "forced",
+			forceColor: true,
+			termMode:   ForceNoTTYMode | ForceDumbTerminalMode,
+			want: red.Sprint("y") + " msg\n" +
+				red.Sprint("y") + " " + color.New(color.FgYellow).Sprint("warn") + "\n" +
+				green.Sprint("v") + " stop\n",
+		},
+		{
+			name:     "not_forced",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			want:     "y msg\ny warn\nv stop\n",
+		},
+		{
+			name:       "dumb_term",
+			forceColor: true,
+			termMode:   ForceTTYMode | ForceDumbTerminalMode,
+			want:       "\r\ry msg\r     \ry warn\r      \rv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.ShowCursor = false
+			cfg.Colors = []string{"fgRed"}
+			cfg.StopColors = []string{"fgGreen"}
+			cfg.ForceColorInNoTTY = tt.forceColor
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+			spinner.MessageSeverity(SeverityWarn, "warn")
+			spinner.Render()
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_jsonMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		fail     bool
+		termMode TerminalMode
+		want     []jsonStatus
+		wantText string
+	}{
+		{
+			name:     "stop",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			want: []jsonStatus{
+				{Status: "running", Message: "msg"},
+				{Status: "running", Message: "other \"quoted\""},
+				{Status: "stopped", Message: "stop"},
+			},
+		},
+		{
+			name:     "stop_fail",
+			fail:     true,
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			want: []jsonStatus{
+				{Status: "running", Message: "msg"},
+				{Status: "running", Message: "other \"quoted\""},
+				{Status: "failed", Message: "fail"},
+			},
+		},
+		{
+			name:     "tty_ignored",
+			termMode: termModeTTY,
+			wantText: "\r\033[K\ry msg\r\033[K\rz other \"quoted\"\r\033[K\rv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.CharSet = []string{"y", "z"}
+			cfg.StopFailCharacter = "x"
+			cfg.StopFailMessage = "fail"
+			cfg.JSONMode = true
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+			spinner.Message(`other "quoted"`)
+			spinner.Render()
+
+			if tt.fail {
+				testErrCheck(t, "spinner.StopFail()", "", spinner.StopFail())
+			} else {
+				testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+			}
+
+			if tt.want == nil {
+				if diff := cmp.Diff(tt.wantText, buf.String()); diff != "" {
+					t.Fatalf("output differs: (-want / +got)\n%s", diff)
+				}
+
+				return
+			}
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+			got := make([]jsonStatus, len(lines))
+
+			for i, line := range lines {
+				if err := json.Unmarshal([]byte(line), &got[i]); err != nil {
+					t.Fatalf("lines[%d] = %q is not valid JSON: %v", i, line, err)
+				}
+
+				if !strings.Contains(line, `"elapsed_ms":`) {
+					t.Errorf("lines[%d] = %q, want elapsed_ms field", i, line)
+				}
+
+				got[i].ElapsedMS = 0
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("lines differ: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_stickyBottom(t *testing.T) {
+	tests := []struct {
+		name     string
+		termMode TerminalMode
+		want     string
+	}{
+		{
+			name:     "smart_term",
+			termMode: termModeTTY,
+			want: "\033[s\033[9999;1H\r\033[K\ry msg\033[u" +
+				"\033[s\033[9999;1H\r\033[K\rz msg\033[u" +
+				"\033[s\033[9999;1H\r\033[K\r\033[uv stop\n",
+		},
+		{
+			name:     "dumb_term",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			want:     "\r\ry msg\r     \rz msg\r     \rv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.CharSet = []string{"y", "z"}
+			cfg.SubMessage = "detail"
+			cfg.StickyBottom = true
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+			spinner.Render()
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+// overlapWriter is an io.Writer that records whether writes were concurrent
+type overlapWriter struct {
+	inFlight int32
+	overlaps int32
+	writes   int32
+}
+
+func (w *overlapWriter) Write(p []byte) (int, error) {
+	if atomic.AddInt32(&w.inFlight, 1) > 1 {
+		atomic.AddInt32(&w.overlaps, 1)
+	}
+
+	time.Sleep(100 * time.Microsecond)
+
+	atomic.AddInt32(&w.writes, 1)
+	atomic.AddInt32(&w.inFlight, -1)
+
+	return len(p), nil
+}
+
+func TestSpinner_singleWrite(t *testing.T) {
+	w := &overlapWriter{}
+
+	var spinners []*Spinner
+
+	for i := 0; i < 4; i++ {
+		spinner, err := New(Config{
+			Writer:       w,
+			Frequency:    time.Millisecond,
+			CharSet:      []string{"x", "y", "z"},
+			Message:      strings.Repeat("msg", 10),
+			TerminalMode: termModeTTY,
+			SingleWrite:  true,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		spinners = append(spinners, spinner)
+	}
+
+	if spinners[0].writeKey == nil || spinners[0].writeKey != spinners[1].writeKey {
+		t.Fatal("spinners writing to the same Writer don't share a lock")
+	}
+
+	for _, spinner := range spinners {
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	for _, spinner := range spinners {
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+	}
+
+	if n := atomic.LoadInt32(&w.writes); n < 8 {
+		t.Fatalf("writes = %d, want at least 8", n)
+	}
+
+	if n := atomic.LoadInt32(&w.overlaps); n != 0 {
+		t.Fatalf("overlapping writes = %d, want 0", n)
+	}
+}
+
+// sliceWriter is a comparable io.Writer type, whose values may not be
+type sliceWriter struct {
+	v interface{}
+}
+
+func (sliceWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+func Test_writerKey(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	tests := []struct {
+		name string
+		w    io.Writer
+		want interface{}
+	}{
+		{
+			name: "pointer",
+			w:    buf,
+			want: buf,
+		},
+		{
+			name: "comparable_value",
+			w:    sliceWriter{v: 1},
+			want: sliceWriter{v: 1},
+		},
+		{
+			name: "uncomparable_type",
+			w:    writerFunc(func(p []byte) (int, error) { return len(p), nil }),
+		},
+		{
+			name: "uncomparable_value",
+			w:    sliceWriter{v: []int{1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := writerKey(tt.w); got != tt.want {
+				t.Fatalf("writerKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_lockWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	unlock := lockWriter(buf)
+
+	locked := make(chan struct{})
+
+	go func() {
+		lockWriter(buf)()
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("lockWriter() didn't block while the Writer was locked")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	unlock()
+	<-locked
+
+	writerLocks.mu.Lock()
+	defer writerLocks.mu.Unlock()
+
+	if _, ok := writerLocks.locks[buf]; ok {
+		t.Fatal("writerLocks still has an entry for the Writer once unlocked")
+	}
+}
+
+func TestSpinner_timestamp(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		re     string
+	}{
+		{
+			name: "default_format",
+			re:   `^\d{2}:\d{2}:\d{2} `,
+		},
+		{
+			name:   "custom_format",
+			format: "2006-01-02T15:04:05",
+			re:     `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2} `,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, ForceNoTTYMode|ForceDumbTerminalMode)
+			cfg.ShowCursor = false
+			cfg.CharSet = []string{"y", "z"}
+			cfg.Timestamp = true
+			cfg.TimestampFormat = tt.format
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			want := []string{"y msg", "v stop"}
+
+			if len(lines) != len(want) {
+				t.Fatalf("len(lines) = %d, want %d: %q", len(lines), len(want), lines)
+			}
+
+			re := regexp.MustCompile(tt.re)
+
+			for i, line := range lines {
+				loc := re.FindStringIndex(line)
+				if loc == nil {
+					t.Fatalf("lines[%d] = %q, want timestamp matching %s", i, line, tt.re)
+				}
+
+				if got := line[loc[1]:]; got != want[i] {
+					t.Errorf("lines[%d] = %q, want %q after timestamp", i, got, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSpinner_altScreen(t *testing.T) {
+	tests := []struct {
+		name     string
+		fail     bool
+		termMode TerminalMode
+		want     string
+	}{
+		{
+			name:     "stop",
+			termMode: termModeTTY,
+			want:     "\033[?1049h\r\033[K\ry msg\033[?1049l\r\033[K\rv stop\n",
+		},
+		{
+			name:     "stop_fail",
+			fail:     true,
+			termMode: termModeTTY,
+			want:     "\033[?1049h\r\033[K\ry msg\033[?1049l\r\033[K\rx fail\n",
+		},
+		{
+			name:     "dumb_term",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			want:     "\r\ry msg\r     \rv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.CharSet = []string{"y", "z"}
+			cfg.StopFailCharacter = "x"
+			cfg.StopFailMessage = "fail"
+			cfg.AltScreen = true
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+
+			if tt.fail {
+				testErrCheck(t, "spinner.StopFail()", "", spinner.StopFail())
+			} else {
+				testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+			}
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_Pause(t *testing.T) {
+	tests := []struct {
+		name    string
+		spinner *Spinner
+		err     string
+	}{
+		{
+			name: "not_running",
+			spinner: &Spinner{
+				status:  uint32Ptr(statusStopped),
+				pauseCh: make(chan struct{}, 1),
+			},
+			err: "spinner not running or paused",
+		},
+		{
+			name: "stopping",
+			spinner: &Spinner{
+				status:  uint32Ptr(statusStopping),
+				pauseCh: make(chan struct{}, 1),
+			},
+			err: "spinner not running or paused",
+		},
+		{
+			name: "already_paused",
+			spinner: &Spinner{
+				status:  uint32Ptr(statusPaused),
+				pauseCh: make(chan struct{}, 1),
+			},
+			err: "spinner not running",
+		},
+		{
+			name: "running",
+			spinner: &Spinner{
+				mu:      &sync.Mutex{},
+				status:  uint32Ptr(statusRunning),
+				pauseCh: make(chan struct{}, 1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cont := testErrCheck(t, "Pause()", tt.err, tt.spinner.Pause()); !cont {
+				return
+			}
+
+			if tt.spinner.unpauseCh == nil {
+				t.Fatal("unpauseCh = nil")
+			}
+
+			if tt.spinner.unpausedCh == nil {
+				t.Fatal("unpausedCh = nil")
+			}
+
+			select {
+			case _, ok := <-tt.spinner.pauseCh:
+				if !ok {
+					t.Fatal("unexpected closed channel")
+				}
+			default:
+				t.Fatal("expected message from pauseCh")
+			}
+
+			if s := atomic.LoadUint32(tt.spinner.status); s != statusPaused {
+				t.Fatalf("status = %d, want %d", s, statusPaused)
+			}
+		})
+	}
+}
+
+func TestSpinner_Pause_painterStopped(t *testing.T) {
+	done := make(chan struct{})
+	close(done)
+
+	spinner := &Spinner{
+		mu:      &sync.Mutex{},
+		status:  uint32Ptr(statusRunning),
+		pauseCh: make(chan struct{}), // nothing receives from it
+		doneCh:  done,
+	}
+
+	if err := spinner.Pause(); err != ErrNotRunning {
+		t.Fatalf("Pause() error = %v, want %v", err, ErrNotRunning)
+	}
+
+	if s := atomic.LoadUint32(spinner.status); s != statusRunning {
+		t.Fatalf("status = %d, want %d", s, statusRunning)
+	}
+
+	if spinner.unpauseCh != nil || spinner.unpausedCh != nil {
+		t.Fatal("unpause channels were not cleared")
+	}
+}
+
+func TestSpinner_Pause_concurrentStop(t *testing.T) {
+	spinner, err := New(Config{
+		Writer:       &safeBuffer{},
+		Frequency:    time.Millisecond,
+		CharSet:      []string{"y"},
+		ShowCursor:   true,
+		TerminalMode: termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
+
+	for i := 0; i < 100; i++ {
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		var pauseErr, stopErr error
+		var wg sync.WaitGroup
+
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			pauseErr = spinner.Pause()
+		}()
+
+		go func() {
+			defer wg.Done()
+			stopErr = spinner.Stop()
+		}()
+
+		finished := make(chan struct{})
+
+		go func() {
+			wg.Wait()
+			close(finished)
+		}()
+
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("iteration %d: Pause() and Stop() deadlocked", i)
+		}
+
+		// if Stop() got there first Pause() fails with ErrNotRunning,
+		// otherwise the paused spinner was stopped
+		switch {
+		case stopErr == nil:
+			if pauseErr != nil && !errors.Is(pauseErr, ErrNotRunning) {
+				t.Fatalf("iteration %d: Pause() error = %v, want %v", i, pauseErr, ErrNotRunning)
+			}
+
+		case pauseErr == nil:
+			// Stop() failed while the spinner was pausing, so stop it now
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		default:
+			t.Fatalf("iteration %d: both Pause() (%v) and Stop() (%v) failed", i, pauseErr, stopErr)
+		}
+
+		if st := spinner.Status(); st != SpinnerStopped {
+			t.Fatalf("iteration %d: status = %s, want %s", i, st, SpinnerStopped)
+		}
+	}
+}
+
+func TestSpinner_PauseRendering(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	cfg := testConfig(buf, termModeTTY)
+	cfg.StopCharacter = ""
+	cfg.StopMessage = ""
+
+	spinner := newTestSpinner(t, cfg)
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	spinner.Render()
+
+	testErrCheck(t, "spinner.PauseRendering()", "", spinner.PauseRendering())
+	testErrCheck(t, "spinner.PauseRendering()", "spinner not running", spinner.PauseRendering())
+
+	spinner.Message("new")
+	spinner.Suffix(": ")
+	spinner.Render()
+
+	testErrCheck(t, "spinner.Unpause()", "", spinner.Unpause())
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	want := "\r\033[K\ry msg" +
+		"\r\033[K\r" +
+		"\r\033[K\ry: new" +
+		"\r\033[K\r"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
+	}
+}
+
+func TestSpinner_PauseRendering_painter(t *testing.T) {
+	buf := &safeBuffer{}
+
+	cfg := testConfig(buf, termModeTTY)
+	cfg.StopCharacter = ""
+	cfg.StopMessage = ""
+	cfg.Frequency = time.Hour
+
+	spinner := newTestSpinner(t, cfg)
+
+	testErrCheck(t, "spinner.Start()", "", spinner.Start())
+	testErrCheck(t, "spinner.WaitRendered()", "", spinner.WaitRendered(time.Second))
+	testErrCheck(t, "spinner.PauseRendering()", "", spinner.PauseRendering())
+
+	paused := buf.String()
+
+	for i := 0; i < 5; i++ {
+		spinner.Message(fmt.Sprintf("msg %d", i))
+	}
+
+	// nothing is written while paused
+	if got := buf.String(); got != paused {
+		t.Fatalf("output while paused = %q, want %q", got, paused)
+	}
+
+	if want := "\r\033[K\ry msg\r\033[K\r"; paused != want {
+		t.Fatalf("output when pausing = %q, want %q", paused, want)
+	}
+
+	testErrCheck(t, "spinner.Unpause()", "", spinner.Unpause())
+
+	// the latest state is rendered immediately after unpausing
+	want := paused + "\r\033[K\ry msg 4"
+
+	if got := buf.String(); !strings.HasPrefix(got, want) {
+		t.Fatalf("output after unpausing = %q, want prefix %q", got, want)
+	}
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	// updates queued while paused may render the same frame again
+	rest := strings.TrimPrefix(buf.String(), want)
+	rest = strings.ReplaceAll(rest, "\r\033[K\ry msg 4", "")
+
+	if diff := cmp.Diff("\r\033[K\r", rest); diff != "" {
+		t.Fatalf("output after unpausing differs: (-want / +got)\n%s", diff)
+	}
+}
+
+func TestSpinner_PauseAndLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		manual bool
+		fnErr  error
+		err    string
+	}{
+		{
+			name:   "manual",
+			manual: true,
+		},
+		{
+			name: "painter",
+		},
+		{
+			name:   "fn_error",
+			manual: true,
+			fnErr:  errors.New("fn failed"),
+			err:    "fn failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &safeBuffer{}
+
+			cfg := testConfig(buf, termModeTTY)
+			cfg.StopCharacter = ""
+			cfg.StopMessage = ""
+			cfg.Frequency = time.Hour
+
+			spinner := newTestSpinner(t, cfg)
+
+			fn := func(w io.Writer) error {
+				if _, err := io.WriteString(w, "line 1\nline 2\n"); err != nil {
+					return err
+				}
+
+				return tt.fnErr
+			}
+
+			testErrCheck(t, "spinner.PauseAndLog()", "not running", spinner.PauseAndLog(fn))
+
+			if tt.manual {
+				testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+				spinner.Render()
+			} else {
+				testErrCheck(t, "spinner.Start()", "", spinner.Start())
+				testErrCheck(t, "spinner.WaitRendered()", "", spinner.WaitRendered(time.Second))
+			}
+
+			testErrCheck(t, "spinner.PauseAndLog()", tt.err, spinner.PauseAndLog(fn))
+
+			if got := atomic.LoadUint32(spinner.status); got != statusRunning {
+				t.Fatalf("spinner.status = %d, want %d", got, statusRunning)
+			}
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			// the frame is erased before the output, and rendered again below it
+			want := "\r\033[K\ry msg" +
+				"\r\033[K\r" +
+				"line 1\nline 2\n" +
+				"\r\033[K\ry msg" +
+				"\r\033[K\r"
+
+			if diff := cmp.Diff(want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_Unpause(t *testing.T) {
+	tests := []struct {
+		name    string
+		spinner *Spinner
+		err     string
+	}{
+		{
+			name: "not_paused",
+			spinner: &Spinner{
+				status:     uint32Ptr(statusStopped),
+				unpauseCh:  make(chan struct{}),
+				unpausedCh: make(chan struct{}),
+			},
+			err: "spinner not paused",
+		},
+		{
+			name: "running",
+			spinner: &Spinner{
+				status:     uint32Ptr(statusPaused),
+				unpauseCh:  make(chan struct{}),
+				unpausedCh: make(chan struct{}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := tt.spinner.unpauseCh
+			close(tt.spinner.unpausedCh)
+
+			if cont := testErrCheck(t, "Unpause()", tt.err, tt.spinner.Unpause()); !cont {
+				return
+			}
+
+			if tt.spinner.unpauseCh != nil {
+				t.Fatal("unpauseCh != nil")
+			}
+
+			if tt.spinner.unpausedCh != nil {
+				t.Fatal("unpausedCh != nil")
+			}
+
+			select {
+			case _, ok := <-ch:
+				if ok {
+					t.Fatal("unexpected open channel")
+				}
+			default:
+				t.Fatal("expected unpauseCh closed")
+			}
+
+			if s := atomic.LoadUint32(tt.spinner.status); s != statusRunning {
+				t.Fatalf("status = %d, want %d", s, statusRunning)
+			}
+		})
+	}
+}
+
+func TestSpinner_Stop(t *testing.T) {
+	tests := []struct {
+		name    string
+		spinner *Spinner
+		err     string
+	}{
+		{
+			name: "not_running",
+			spinner: &Spinner{
+				mu:       &sync.Mutex{},
+				status:   uint32Ptr(statusStopped),
+				cancelCh: make(chan struct{}),
+				doneCh:   make(chan struct{}),
+			},
+			err: "spinner not running or paused",
+		},
+		{
+			name: "running",
+			spinner: &Spinner{
+				mu:       &sync.Mutex{},
+				status:   uint32Ptr(statusRunning),
+				cancelCh: make(chan struct{}),
+				doneCh:   make(chan struct{}),
+			},
+		},
+		{
+			name: "paused",
+			spinner: &Spinner{
+				mu:         &sync.Mutex{},
+				status:     uint32Ptr(statusPaused),
+				cancelCh:   make(chan struct{}),
+				doneCh:     make(chan struct{}),
+				unpauseCh:  make(chan struct{}),
+				unpausedCh: make(chan struct{}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // create local copy
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.spinner.unpausedCh != nil {
+				close(tt.spinner.unpausedCh)
+			}
+
+			var ok bool
+			wait := make(chan struct{})
+
+			go func(doneCh, cancelCh chan struct{}) {
+				close(doneCh)
+				_, ok = <-cancelCh
+				close(wait)
+			}(tt.spinner.doneCh, tt.spinner.cancelCh)
+
+			if cont := testErrCheck(t, "spinner.Stop()", tt.err, tt.spinner.Stop()); !cont {
+				return
+			}
+
+			<-wait
+
+			if !ok {
+				t.Error("expected stop() to send message and not close channel")
+			}
+
+			if tt.spinner.index != 0 {
+				t.Errorf("tt.spinner.index = %d, want 0", tt.spinner.index)
+			}
+
+			if tt.spinner.cancelCh != nil {
+				t.Error("tt.spinner.cancelCh is not nil")
+			}
+
+			if tt.spinner.doneCh != nil {
+				t.Error("tt.spinner.doneCh is not nil")
+			}
+
+			status := atomic.LoadUint32(tt.spinner.status)
+			if status != 0 {
+				t.Errorf("tt.spinner.status = %d, want 0", status)
+			}
+		})
+	}
+}
+
+// blockingWriter is an io.Writer that blocks each write until it's released
+type blockingWriter struct {
+	entered chan struct{} // closed on the first write
+	release chan struct{}
+	once    sync.Once
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.entered) })
+
+	<-w.release
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.String()
+}
+
+func TestSpinner_TryStop(t *testing.T) {
+	t.Run("invalid_timeout", func(t *testing.T) {
+		spinner := &Spinner{status: uint32Ptr(statusRunning)}
+
+		testErrCheck(t, "spinner.TryStop()", "timeout must be greater than 0", spinner.TryStop(0))
+	})
+
+	t.Run("not_running", func(t *testing.T) {
+		spinner := &Spinner{status: uint32Ptr(statusStopped)}
+
+		testErrCheck(t, "spinner.TryStop()", "spinner not running or paused", spinner.TryStop(time.Second))
+	})
+
+	t.Run("stops", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		spinner, err := New(Config{
+			Writer:        buf,
+			Frequency:     time.Hour,
+			CharSet:       []string{"y"},
+			ShowCursor:    true,
+			StopCharacter: "v",
+			TerminalMode:  termModeTTY,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+		testErrCheck(t, "spinner.TryStop()", "", spinner.TryStop(time.Second))
+
+		if got := buf.String(); !strings.HasSuffix(got, "v\n") {
+			t.Fatalf("output = %q, want stop line", got)
+		}
+	})
+
+	t.Run("blocked_writer", func(t *testing.T) {
+		w := &blockingWriter{entered: make(chan struct{}), release: make(chan struct{})}
+
+		spinner, err := New(Config{
+			Writer:        w,
+			Frequency:     time.Hour,
+			CharSet:       []string{"y"},
+			ShowCursor:    true,
+			StopCharacter: "v",
+			TerminalMode:  termModeTTY,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		<-w.entered
+
+		const timeout = 20 * time.Millisecond
+
+		start := time.Now()
+
+		testErrCheck(t, "spinner.TryStop()", "timed out after 20ms waiting for the spinner to stop", spinner.TryStop(timeout))
+
+		if d := time.Since(start); d < timeout {
+			t.Fatalf("spinner.TryStop() returned after %s, want at least %s", d, timeout)
+		}
+
+		if st := spinner.Status(); st != SpinnerStopped {
+			t.Fatalf("spinner.Status() = %s, want %s", st, SpinnerStopped)
+		}
+
+		// let the painter finish, printing the stop line
+		close(w.release)
+
+		deadline := time.Now().Add(time.Second)
+
+		for !strings.HasSuffix(w.String(), "v\n") {
+			if time.Now().After(deadline) {
+				t.Fatalf("output = %q, want stop line", w.String())
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+	})
+
+	t.Run("restart_after_timeout", func(t *testing.T) {
+		w := &blockingWriter{entered: make(chan struct{}), release: make(chan struct{})}
+
+		spinner, err := New(Config{
+			Writer:        w,
+			Frequency:     time.Millisecond,
+			CharSet:       []string{"y", "z"},
+			ShowCursor:    true,
+			StopCharacter: "v",
+			TerminalMode:  termModeTTY,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		<-w.entered
+
+		testErrCheck(t, "spinner.TryStop()", "timed out after 10ms waiting for the spinner to stop", spinner.TryStop(10*time.Millisecond))
+
+		started := make(chan error, 1)
+
+		go func() { started <- spinner.Start() }()
+
+		select {
+		case err := <-started:
+			t.Fatalf("spinner.Start() = %v before the abandoned painter exited", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(w.release)
+
+		testErrCheck(t, "spinner.Start()", "", <-started)
+
+		time.Sleep(5 * time.Millisecond)
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		if got := w.String(); strings.Count(got, "v\n") != 2 {
+			t.Fatalf("output = %q, want two stop lines", got)
+		}
+	})
+}
+
+func TestSpinner_StopIfRunning(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	cfg := testConfig(buf, termModeTTY)
+	cfg.Message = ""
+
+	spinner := newTestSpinner(t, cfg)
+
+	testErrCheck(t, "spinner.StopIfRunning()", "", spinner.StopIfRunning())
+
+	if buf.Len() != 0 {
+		t.Fatalf("output = %q, want empty", buf.String())
+	}
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+	testErrCheck(t, "spinner.StopIfRunning()", "", spinner.StopIfRunning())
+
+	if st := spinner.Status(); st != SpinnerStopped {
+		t.Fatalf("spinner.Status() = %s, want %s", st, SpinnerStopped)
+	}
+
+	if want := "\r\033[K\rv stop\n"; buf.String() != want {
+		t.Fatalf("output = %q, want %q", buf.String(), want)
+	}
+
+	testErrCheck(t, "spinner.StopIfRunning()", "", spinner.StopIfRunning())
+}
+
+func TestSpinner_StopFail(t *testing.T) {
+	tests := []struct {
+		name    string
+		spinner *Spinner
+		err     string
+	}{
+		{
+			name: "not_running",
+			spinner: &Spinner{
+				mu:       &sync.Mutex{},
+				status:   uint32Ptr(statusStopped),
+				cancelCh: make(chan struct{}),
+				doneCh:   make(chan struct{}),
+			},
+			err: "spinner not running or paused",
+		},
+		{
+			name: "running",
+			spinner: &Spinner{
+				mu:       &sync.Mutex{},
+				status:   uint32Ptr(statusRunning),
+				cancelCh: make(chan struct{}),
+				doneCh:   make(chan struct{}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt // create local copy
+		t.Run(tt.name, func(t *testing.T) {
+			var ok bool
+			wait := make(chan struct{})
+
+			go func(doneCh, cancelCh chan struct{}) {
+				close(doneCh)
+				_, ok = <-cancelCh
+				close(wait)
+			}(tt.spinner.doneCh, tt.spinner.cancelCh)
+
+			if cont := testErrCheck(t, "spinner.Stop()", tt.err, tt.spinner.StopFail()); !cont {
+				return
+			}
+
+			<-wait
+
+			if ok {
+				t.Error("expected stop() to not send message and instead close the channel")
+			}
+
+			if tt.spinner.index != 0 {
+				t.Errorf("tt.spinner.index = %d, want 0", tt.spinner.index)
+			}
+
+			if tt.spinner.cancelCh != nil {
+				t.Error("tt.spinner.cancelCh is not nil")
+			}
+
+			if tt.spinner.doneCh != nil {
+				t.Error("tt.spinner.doneCh is not nil")
+			}
+
+			status := atomic.LoadUint32(tt.spinner.status)
+			if status != 0 {
+				t.Errorf("tt.spinner.status = %d, want 0", status)
+			}
+		})
+	}
+}
+
+func TestSpinner_stopFailBlink(t *testing.T) {
+	tests := []struct {
+		name     string
+		stop     bool
+		colors   []string
+		termMode TerminalMode
+		want     string
+	}{
+		{
+			name:     "stop_fail",
+			termMode: termModeTTY,
+			want:     "\r\033[K\r\033[5mx\033[25m fail\n",
+		},
+		{
+			name:     "stop_fail_colors",
+			colors:   []string{"fgRed"},
+			termMode: termModeTTY,
+			want:     "\r\033[K\r" + color.New(color.FgRed).Sprintf("\033[5mx\033[25m") + " fail\n",
+		},
+		{
+			name:     "stop",
+			stop:     true,
+			termMode: termModeTTY,
+			want:     "\r\033[K\rv stop\n",
+		},
+		{
+			name:     "dumb_term",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			want:     "\r\rx fail\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.Message = ""
+			cfg.StopFailCharacter = "x"
+			cfg.StopFailMessage = "fail"
+			cfg.StopFailColors = tt.colors
+			cfg.StopFailBlink = true
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			if tt.stop {
+				testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+			} else {
+				testErrCheck(t, "spinner.StopFail()", "", spinner.StopFail())
+			}
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_stopMessageURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		fail     bool
+		termMode TerminalMode
+		want     string
+	}{
+		{
+			name:     "stop",
+			termMode: termModeTTY,
+			want:     "\r\033[K\rv \033]8;;https://example.com/log\033\\stop\033]8;;\033\\\n",
+		},
+		{
+			name:     "stop_fail",
+			fail:     true,
+			termMode: termModeTTY,
+			want:     "\r\033[K\rx fail\n",
+		},
+		{
+			name:     "dumb_term",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			want:     "\r\rv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.Message = ""
+			cfg.StopMessageURL = "https://example.com/log"
+			cfg.StopFailCharacter = "x"
+			cfg.StopFailMessage = "fail"
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			if tt.fail {
+				testErrCheck(t, "spinner.StopFail()", "", spinner.StopFail())
+			} else {
+				testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+			}
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_stopCharacterFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		fail     bool
+		termMode TerminalMode
+		want     string
+	}{
+		{
+			name:     "dumb_term_stop",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			want:     "\r\rOK stop\n",
+		},
+		{
+			name:     "dumb_term_stop_fail",
+			fail:     true,
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			want:     "\r\rFAIL fail\n",
+		},
+		{
+			name:     "not_tty_stop",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			want:     "OK stop\n",
+		},
+		{
+			name:     "smart_term_stop",
+			termMode: termModeTTY,
+			want:     "\r\033[K\r✓ stop\n",
+		},
+		{
+			name:     "smart_term_stop_fail",
+			fail:     true,
+			termMode: termModeTTY,
+			want:     "\r\033[K\r✗ fail\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.Message = ""
+			cfg.StopCharacter = "✓"
+			cfg.StopCharacterFallback = "OK"
+			cfg.StopFailCharacter = "✗"
+			cfg.StopFailCharacterFallback = "FAIL"
+			cfg.StopFailMessage = "fail"
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			if tt.fail {
+				testErrCheck(t, "spinner.StopFail()", "", spinner.StopFail())
+			} else {
+				testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+			}
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_bell(t *testing.T) {
+	tests := []struct {
+		name           string
+		fail           bool
+		bellOnStop     bool
+		bellOnStopFail bool
+		termMode       TerminalMode
+		want           string
+	}{
+		{
+			name:       "stop",
+			bellOnStop: true,
+			termMode:   termModeTTY,
+			want:       "\r\033[K\rv stop\n\a",
+		},
+		{
+			name:       "stop_fail_without_bell",
+			fail:       true,
+			bellOnStop: true,
+			termMode:   termModeTTY,
+			want:       "\r\033[K\rx fail\n",
+		},
+		{
+			name:           "stop_fail",
+			fail:           true,
+			bellOnStopFail: true,
+			termMode:       termModeTTY,
+			want:           "\r\033[K\rx fail\n\a",
+		},
+		{
+			name:       "dumb_term",
+			bellOnStop: true,
+			termMode:   ForceTTYMode | ForceDumbTerminalMode,
+			want:       "\r\rv stop\n\a",
+		},
+		{
+			name:       "not_tty",
+			bellOnStop: true,
+			termMode:   ForceNoTTYMode | ForceDumbTerminalMode,
+			want:       "v stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.Message = ""
+			cfg.StopFailCharacter = "x"
+			cfg.StopFailMessage = "fail"
+			cfg.BellOnStop = tt.bellOnStop
+			cfg.BellOnStopFail = tt.bellOnStopFail
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			if tt.fail {
+				testErrCheck(t, "spinner.StopFail()", "", spinner.StopFail())
+			} else {
+				testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+			}
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_StopOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		outcome string
+		want    string
+		newErr  string
+		err     string
+	}{
+		{
+			name: "defined_outcome",
+			cfg: Config{
+				OutcomeCharacters: map[string]string{"warn": "⚠⚠"},
+				OutcomeMessages:   map[string]string{"warn": "careful"},
+				OutcomeColors:     map[string][]string{"warn": {"fgYellow"}},
+			},
+			outcome: "warn",
+			want:    "\r\033[K\r" + color.New(color.FgYellow).Sprintf("⚠⚠") + " careful\n",
+		},
+		{
+			name: "message_only_outcome",
+			cfg: Config{
+				OutcomeMessages: map[string]string{"skip": "skipped"},
+			},
+			outcome: "skip",
+			want:    "\r\033[K\rskipped\n",
+		},
+		{
+			name: "undefined_outcome",
+			cfg: Config{
+				OutcomeMessages: map[string]string{"skip": "skipped"},
+			},
+			outcome: "warn",
+			err:     `stop outcome "warn" is not defined`,
+		},
+		{
+			name: "invalid_outcome_colors",
+			cfg: Config{
+				OutcomeColors: map[string][]string{"warn": {"invalid"}},
+			},
+			newErr: `failed to build "warn" outcome color function: invalid is not a valid color`,
+		},
+		{
+			name: "empty_outcome_name",
+			cfg: Config{
+				OutcomeMessages: map[string]string{"": "empty"},
+			},
+			newErr: "outcome names must not be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := tt.cfg
+			cfg.Writer = buf
+			cfg.CharSet = []string{"y"}
+			cfg.Suffix = " "
+			cfg.ShowCursor = true
+			cfg.TerminalMode = termModeTTY
+
+			spinner, err := New(cfg)
+
+			if cont := testErrCheck(t, "New()", tt.newErr, err); !cont {
+				return
+			}
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			if cont := testErrCheck(t, "spinner.StopOutcome()", tt.err, spinner.StopOutcome(tt.outcome)); !cont {
+				if st := spinner.Status(); st != SpinnerRunning {
+					t.Fatalf("spinner.Status() = %s, want %s", st, SpinnerRunning)
+				}
+
+				return
+			}
+
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("output = %q, want %q", got, tt.want)
+			}
+
+			if spinner.stopOutcome != "" {
+				t.Errorf("spinner.stopOutcome = %q, want empty", spinner.stopOutcome)
+			}
+
+			if c, ok := tt.cfg.OutcomeCharacters[tt.outcome]; ok && spinner.maxWidth != runewidth.StringWidth(c) {
+				t.Errorf("spinner.maxWidth = %d, want %d", spinner.maxWidth, runewidth.StringWidth(c))
+			}
+		})
+	}
+}
+
+func TestSpinner_StopAndPrint(t *testing.T) {
+	tests := []struct {
+		name     string
+		termMode TerminalMode
+		manual   bool
+		want     string
+	}{
+		{
+			name:     "smart_term",
+			termMode: termModeTTY,
+			manual:   true,
+			want:     "\r\033[K\ry msg\r\033[K\rrow 1\nrow 2\n",
+		},
+		{
+			name:     "dumb_term",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			manual:   true,
+			want:     "\r\ry msg\r     \rrow 1\nrow 2\n",
+		},
+		{
+			name:     "not_tty",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			manual:   true,
+			want:     "y msg\nrow 1\nrow 2\n",
+		},
+		{
+			name:     "painter",
+			termMode: termModeTTY,
+			want:     "\r\033[K\ry msg\r\033[K\rrow 1\nrow 2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.Frequency = time.Hour
+
+			spinner := newTestSpinner(t, cfg)
+
+			if tt.manual {
+				testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+				spinner.Render()
+			} else {
+				testErrCheck(t, "spinner.Start()", "", spinner.Start())
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			testErrCheck(t, "spinner.StopAndPrint()", "", spinner.StopAndPrint("row 1\nrow 2\n"))
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+
+			if spinner.stopPrint != nil {
+				t.Errorf("spinner.stopPrint = %q, want nil", *spinner.stopPrint)
+			}
+
+			testErrCheck(t, "spinner.StopAndPrint()", "spinner not running or paused", spinner.StopAndPrint("row"))
+		})
+	}
+}
+
+func TestSpinner_StopInline(t *testing.T) {
+	tests := []struct {
+		name       string
+		termMode   TerminalMode
+		hideCursor bool
+		manual     bool
+		want       string
+	}{
+		{
+			name:     "smart_term",
+			termMode: termModeTTY,
+			manual:   true,
+			want:     "\r\033[K\ry msg\r\033[K\rv stop",
+		},
+		{
+			name:       "smart_term_hidden_cursor",
+			termMode:   termModeTTY,
+			hideCursor: true,
+			manual:     true,
+			want:       "\r\033[K\r\r\033[?25l\ry msg\r\033[K\r\r\033[?25h\rv stop",
+		},
+		{
+			name:     "dumb_term",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			manual:   true,
+			want:     "\r\ry msg\r     \rv stop",
+		},
+		{
+			name:     "not_tty",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			manual:   true,
+			want:     "y msg\nv stop",
+		},
+		{
+			name:     "painter",
+			termMode: termModeTTY,
+			want:     "\r\033[K\ry msg\r\033[K\rv stop",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.Frequency = time.Hour
+			cfg.ShowCursor = !tt.hideCursor
+
+			spinner := newTestSpinner(t, cfg)
+
+			if tt.manual {
+				testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+				spinner.Render()
+			} else {
+				testErrCheck(t, "spinner.Start()", "", spinner.Start())
+				testErrCheck(t, "spinner.WaitRendered()", "", spinner.WaitRendered(time.Second))
+			}
+
+			testErrCheck(t, "spinner.StopInline()", "", spinner.StopInline())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+
+			testErrCheck(t, "spinner.StopInline()", "spinner not running or paused", spinner.StopInline())
+
+			// the next stop line is terminated as usual
+			buf.Reset()
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if got := buf.String(); !strings.HasSuffix(got, "v stop\n") {
+				t.Fatalf("output after Stop() = %q, want it to end with a newline", got)
+			}
+		})
+	}
+}
+
+func TestSpinner_StopPreserve(t *testing.T) {
+	tests := []struct {
+		name       string
+		termMode   TerminalMode
+		hideCursor bool
+		manual     bool
+		want       string
+	}{
+		{
+			name:     "smart_term",
+			termMode: termModeTTY,
+			manual:   true,
+			want:     "\r\033[K\ry msg\nv stop\n",
+		},
+		{
+			name:       "smart_term_hidden_cursor",
+			termMode:   termModeTTY,
+			hideCursor: true,
+			manual:     true,
+			want:       "\r\033[K\r\r\033[?25l\ry msg\n\r\033[?25h\rv stop\n",
+		},
+		{
+			name:     "dumb_term",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			manual:   true,
+			want:     "\r\ry msg\nv stop\n",
+		},
+		{
+			name:     "not_tty",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			manual:   true,
+			want:     "y msg\nv stop\n",
+		},
+		{
+			name:     "painter",
+			termMode: termModeTTY,
+			want:     "\r\033[K\ry msg\nv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.Frequency = time.Hour
+			cfg.ShowCursor = !tt.hideCursor
+
+			spinner := newTestSpinner(t, cfg)
+
+			if tt.manual {
+				testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+				spinner.Render()
+			} else {
+				testErrCheck(t, "spinner.Start()", "", spinner.Start())
+				testErrCheck(t, "spinner.WaitRendered()", "", spinner.WaitRendered(time.Second))
+			}
+
+			testErrCheck(t, "spinner.StopPreserve()", "", spinner.StopPreserve())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+
+			testErrCheck(t, "spinner.StopPreserve()", "spinner not running or paused", spinner.StopPreserve())
+
+			if termModeForceNoTTY(tt.termMode) {
+				// frames are never erased
+				return
+			}
+
+			// the next stop erases the last frame as usual
+			buf.Reset()
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+			spinner.Render()
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if got := buf.String(); strings.Contains(got, "y msg\nv stop") {
+				t.Fatalf("output after Stop() = %q, want the last frame erased", got)
+			}
+		})
+	}
+}
+
+func TestSpinner_label(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{
+			name: "default_layout",
+			cfg:  Config{},
+			want: "\r\033[K\rbuild: y msg\r\033[K\rbuild: z other\r\033[K\rbuild: y other\r\033[K\rbuild: v stop\n",
+		},
+		{
+			name: "spinner_at_end",
+			cfg:  Config{SpinnerAtEnd: true},
+			want: "\r\033[K\rbuild: msg y\r\033[K\rbuild: other z\r\033[K\rbuild: other y\r\033[K\rbuild: stop v\n",
+		},
+		{
+			name: "template",
+			cfg:  Config{Template: "{spinner} {label}{message}"},
+			want: "\r\033[K\ry build: msg\r\033[K\rz build: other\r\033[K\ry build: other\r\033[K\rv build: stop\n",
+		},
+		{
+			name: "not_tty",
+			cfg:  Config{TerminalMode: ForceNoTTYMode | ForceDumbTerminalMode},
+			want: "build: y msg\nbuild: z other\nbuild: y other\nbuild: v stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := tt.cfg
+			cfg.Writer = buf
+			cfg.Frequency = time.Hour
+			cfg.CharSet = []string{"y", "z"}
+			cfg.Label = "build: "
+			cfg.Message = "msg"
+			cfg.StopCharacter = "v"
+			cfg.StopMessage = "stop"
+			cfg.ShowCursor = true
+
+			if cfg.SpinnerAtEnd {
+				cfg.Prefix = " "
+			} else {
+				cfg.Suffix = " "
+			}
+
+			if cfg.TerminalMode == 0 {
+				cfg.TerminalMode = termModeTTY
+			}
+
+			spinner, err := New(cfg)
+			testErrCheck(t, "New()", "", err)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+			spinner.Message("other")
+			spinner.Render()
+			spinner.Render()
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+
+			if got := spinner.Config().Label; got != "build: " {
+				t.Fatalf("spinner.Config().Label = %q, want %q", got, "build: ")
+			}
+		})
+	}
+}
+
+func TestSpinner_paintUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spinner *Spinner
+		want    string
+	}{
+		{
+			name: "spinner_no_hide_cursor",
+			spinner: withMessage(&Spinner{
+				buffer:    &bytes.Buffer{},
+				mu:        &sync.Mutex{},
+				prefix:    "a",
+				suffix:    " ",
+				maxWidth:  1,
+				colorFn:   fmt.Sprintf,
+				chars:     []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency: 10,
+				termMode:  termModeTTY,
+			}, "msg"),
+			want: "\r\033[K\ray msg\r\033[K\raz msg\r\033[K\raz msg\r\033[K\ray msg",
+		},
+		{
+			name: "spinner_no_hide_cursor_spinnerAtEnd",
+			spinner: withMessage(&Spinner{
+				buffer:       &bytes.Buffer{},
+				mu:           &sync.Mutex{},
+				prefix:       " a",
+				suffix:       " ",
+				maxWidth:     1,
+				colorFn:      fmt.Sprintf,
+				chars:        []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency:    10,
+				spinnerAtEnd: true,
+				termMode:     termModeTTY,
+			}, "msg"),
+			want: "\r\033[K\rmsg ay \r\033[K\rmsg az \r\033[K\rmsg az \r\033[K\rmsg ay ",
+		},
+		{
+			name: "spinner_no_hide_cursor_auto_cursor_empty_suffix",
+			spinner: withMessage(&Spinner{
+				buffer:          &bytes.Buffer{},
+				mu:              &sync.Mutex{},
+				prefix:          "a",
+				suffix:          " ",
+				maxWidth:        1,
+				colorFn:         fmt.Sprintf,
+				chars:           []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency:       10,
+				suffixAutoColon: true,
+				termMode:        termModeTTY,
+			}, "msg"),
+			want: "\r\033[K\ray msg\r\033[K\raz msg\r\033[K\raz msg\r\033[K\ray msg",
+		},
+		{
+			name: "spinner_no_hide_cursor_auto_cursor_suffix",
+			spinner: withMessage(&Spinner{
+				buffer:          &bytes.Buffer{},
+				mu:              &sync.Mutex{},
+				prefix:          "a",
+				suffix:          " foo",
+				maxWidth:        1,
+				colorFn:         fmt.Sprintf,
+				chars:           []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency:       10,
+				suffixAutoColon: true,
+				termMode:        termModeTTY,
+			}, "msg"),
+			want: "\r\033[K\ray foo: msg\r\033[K\raz foo: msg\r\033[K\raz foo: msg\r\033[K\ray foo: msg",
+		},
+		{
+			name: "spinner_hide_cursor",
+			spinner: withMessage(&Spinner{
+				buffer:       &bytes.Buffer{},
+				cursorHidden: true,
+				mu:           &sync.Mutex{},
+				prefix:       "a",
+				suffix:       " ",
+				maxWidth:     1,
+				colorFn:      fmt.Sprintf,
+				chars:        []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency:    10,
+				termMode:     termModeTTY,
+			}, "msg"),
+			want: "\r\033[K\r\r\033[?25l\ray msg\r\033[K\r\r\033[?25l\raz msg\r\033[K\r\r\033[?25l\raz msg\r\033[K\r\r\033[?25l\ray msg",
+		},
+		{
+			name: "spinner_hide_cursor_dumbterm",
+			spinner: withMessage(&Spinner{
+				buffer:       &bytes.Buffer{},
+				cursorHidden: true,
+				mu:           &sync.Mutex{},
+				prefix:       "a",
+				suffix:       " ",
+				maxWidth:     1,
+				colorFn:      fmt.Sprintf,
+				chars:        []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency:    10,
+				// TODO(theckman): verify
+				termMode: ForceDumbTerminalMode,
+			}, "msg"),
+			want: "\r\ray msg\r      \raz msg\r      \raz msg\r      \ray msg",
+		},
+		{
+			name: "spinner_percent_spinnerAtEnd",
+			spinner: withMessage(&Spinner{
+				buffer:       &bytes.Buffer{},
+				mu:           &sync.Mutex{},
+				prefix:       " a",
+				suffix:       " ",
+				maxWidth:     1,
+				colorFn:      fmt.Sprintf,
+				chars:        []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency:    10,
+				spinnerAtEnd: true,
+				showPercent:  true,
+				percent:      42.9,
+				termMode:     termModeTTY,
+			}, "msg"),
+			want: "\r\033[K\rmsg 42% ay \r\033[K\rmsg 42% az \r\033[K\rmsg 42% az \r\033[K\rmsg 42% ay ",
+		},
+		{
+			name: "spinner_percent_elapsed_no_message",
+			spinner: &Spinner{
+				buffer:      &bytes.Buffer{},
+				mu:          &sync.Mutex{},
+				prefix:      "a",
+				suffix:      " ",
+				maxWidth:    1,
+				colorFn:     fmt.Sprintf,
+				chars:       []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency:   10,
+				showPercent: true,
+				showElapsed: true,
+				percent:     7,
+				startTime:   time.Now().Add(-2500 * time.Millisecond),
+				termMode:    termModeTTY,
+			},
+			want: "\r\033[K\ray 7% 2s\r\033[K\raz 7% 2s\r\033[K\raz 7% 2s\r\033[K\ray 7% 2s",
+		},
+		{
+			name: "spinner_suffix_color",
+			spinner: withMessage(&Spinner{
+				buffer:        &bytes.Buffer{},
+				mu:            &sync.Mutex{},
+				prefix:        "a",
+				suffix:        " s ",
+				maxWidth:      1,
+				colorFn:       fmt.Sprintf,
+				suffixColorFn: func(format string, a ...interface{}) string { return "<" + fmt.Sprintf(format, a...) + ">" },
+				chars:         []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency:     10,
+				termMode:      termModeTTY,
+			}, "msg"),
+			want: "\r\033[K\ray< s >msg\r\033[K\raz< s >msg\r\033[K\raz< s >msg\r\033[K\ray< s >msg",
+		},
+		{
+			name: "spinner_osc_progress",
+			spinner: withMessage(&Spinner{
+				buffer:          &bytes.Buffer{},
+				mu:              &sync.Mutex{},
+				prefix:          "a",
+				suffix:          " ",
+				maxWidth:        1,
+				colorFn:         fmt.Sprintf,
+				chars:           []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency:       10,
+				emitOSCProgress: true,
+				percent:         42,
+				percentSet:      true,
+				termMode:        termModeTTY,
+			}, "msg"),
+			want: "\r\033[K\ray msg\033]9;4;1;42\007\r\033[K\raz msg\033]9;4;1;42\007\r\033[K\raz msg\033]9;4;1;42\007\r\033[K\ray msg\033]9;4;1;42\007",
+		},
+		{
+			name: "spinner_osc_progress_dumbterm",
+			spinner: withMessage(&Spinner{
+				buffer:          &bytes.Buffer{},
+				mu:              &sync.Mutex{},
+				prefix:          "a",
+				suffix:          " ",
+				maxWidth:        1,
+				colorFn:         fmt.Sprintf,
+				chars:           []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency:       10,
+				emitOSCProgress: true,
+				percent:         42,
+				percentSet:      true,
+				termMode:        ForceTTYMode | ForceDumbTerminalMode,
+			}, "msg"),
+			want: "\r\ray msg\r      \raz msg\r      \raz msg\r      \ray msg",
+		},
+		{
+			name: "spinner_backward",
+			spinner: withMessage(&Spinner{
+				buffer:    &bytes.Buffer{},
+				mu:        &sync.Mutex{},
+				prefix:    "a",
+				suffix:    " ",
+				maxWidth:  1,
+				colorFn:   fmt.Sprintf,
+				chars:     []character{{Value: "x", Size: 1}, {Value: "y", Size: 1}, {Value: "z", Size: 1}},
+				frequency: 10,
+				backward:  true,
+				termMode:  termModeTTY,
+			}, "msg"),
+			want: "\r\033[K\rax msg\r\033[K\raz msg\r\033[K\raz msg\r\033[K\ray msg",
+		},
+		{
+			name: "spinner_no_chars",
+			spinner: withMessage(&Spinner{
+				buffer:    &bytes.Buffer{},
+				mu:        &sync.Mutex{},
+				prefix:    "a",
+				suffix:    " ",
+				maxWidth:  1,
+				colorFn:   fmt.Sprintf,
+				frequency: 10,
+				termMode:  termModeTTY,
+			}, "msg"),
+			want: "\r\033[K\rmsg\r\033[K\rmsg\r\033[K\rmsg\r\033[K\rmsg",
+		},
+		{
+			name: "spinner_empty_print",
+			spinner: &Spinner{
+				buffer:    &bytes.Buffer{},
+				mu:        &sync.Mutex{},
+				maxWidth:  0,
+				colorFn:   fmt.Sprintf,
+				chars:     []character{{Value: "", Size: 0}},
+				frequency: 10,
+				termMode:  termModeTTY,
+			},
+			want: "\r\033[K\r\r\033[K\r\r\033[K\r\r\033[K\r",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			tt.spinner.writer = buf
+
+			tm := time.NewTimer(10 * time.Millisecond)
+
+			tt.spinner.paintUpdate(tm, true)
+			tt.spinner.paintUpdate(tm, true)
+			tt.spinner.paintUpdate(tm, false)
+			tt.spinner.paintUpdate(tm, true)
+			tm.Stop()
+
+			got := buf.String()
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_paintStop(t *testing.T) {
+	tests := []struct {
+		name    string
+		ok      bool
+		spinner *Spinner
+		want    string
+	}{
+		{
+			name: "ok",
+			ok:   true,
+			spinner: &Spinner{
+				buffer:      &bytes.Buffer{},
+				mu:          &sync.Mutex{},
+				prefix:      "a",
+				suffix:      " ",
+				maxWidth:    1,
+				stopColorFn: fmt.Sprintf,
+				stopChar:    character{Value: "x", Size: 1},
+				stopMsg:     "stop",
+				termMode:    termModeTTY,
+			},
+			want: "\r\033[K\rax stop\n",
+		},
+		{
+			name: "ok_spinnerAtEnd",
+			ok:   true,
+			spinner: &Spinner{
+				buffer:       &bytes.Buffer{},
+				mu:           &sync.Mutex{},
+				prefix:       " a",
+				suffix:       " ",
+				maxWidth:     1,
+				stopColorFn:  fmt.Sprintf,
+				spinnerAtEnd: true,
+				stopChar:     character{Value: "x", Size: 1},
+				stopMsg:      "stop",
+				termMode:     termModeTTY,
+			},
+			want: "\r\033[K\rstop ax \n",
+		},
+		{
+			name: "ok_spinnerAtEnd_suffixAutoColon",
+			ok:   true,
+			spinner: &Spinner{
+				buffer:          &bytes.Buffer{},
+				mu:              &sync.Mutex{},
+				prefix:          " a",
+				suffix:          " ",
+				maxWidth:        1,
+				stopColorFn:     fmt.Sprintf,
+				spinnerAtEnd:    true,
+				suffixAutoColon: true,
+				stopChar:        character{Value: "x", Size: 1},
+				stopMsg:         "stop",
+				termMode:        termModeTTY,
+			},
+			want: "\r\033[K\rstop ax \n",
+		},
+		{
+			name: "ok_auto_colon_empty_suffix",
+			ok:   true,
+			spinner: &Spinner{
+				buffer:          &bytes.Buffer{},
+				mu:              &sync.Mutex{},
+				prefix:          "a",
+				suffix:          " ",
+				maxWidth:        1,
+				stopColorFn:     fmt.Sprintf,
+				stopChar:        character{Value: "x", Size: 1},
+				stopMsg:         "stop",
+				suffixAutoColon: true,
+				termMode:        termModeTTY,
+			},
+			want: "\r\033[K\rax stop\n",
+		},
+		{
+			name: "ok_auto_colon_suffix",
+			ok:   true,
+			spinner: &Spinner{
+				buffer:          &bytes.Buffer{},
+				mu:              &sync.Mutex{},
+				prefix:          "a",
+				suffix:          " foo",
+				maxWidth:        1,
+				stopColorFn:     fmt.Sprintf,
+				stopChar:        character{Value: "x", Size: 1},
+				stopMsg:         "stop",
+				suffixAutoColon: true,
+				termMode:        termModeTTY,
+			},
+			want: "\r\033[K\rax foo: stop\n",
+		},
+		{
+			name: "ok_auto_colon_no_msg",
+			ok:   true,
+			spinner: &Spinner{
+				buffer:          &bytes.Buffer{},
+				mu:              &sync.Mutex{},
+				prefix:          "a",
+				suffix:          " ",
+				maxWidth:        1,
+				stopColorFn:     fmt.Sprintf,
+				stopChar:        character{Value: "x", Size: 1},
+				stopMsg:         "",
+				suffixAutoColon: true,
+				termMode:        termModeTTY,
+			},
+			want: "\r\033[K\rax \n",
+		},
+		{
+			name: "ok_unhide",
+			ok:   true,
+			spinner: &Spinner{
+				buffer:       &bytes.Buffer{},
+				mu:           &sync.Mutex{},
+				cursorHidden: true,
+				prefix:       "a",
+				suffix:       " ",
+				maxWidth:     1,
+				stopColorFn:  fmt.Sprintf,
+				stopChar:     character{Value: "x", Size: 1},
+				stopMsg:      "stop",
+				termMode:     termModeTTY,
+			},
+			want: "\r\033[K\r\r\033[?25h\rax stop\n",
+		},
+		{
+			name: "ok_unhide_dumbterm",
+			ok:   true,
+			spinner: &Spinner{
+				buffer:       &bytes.Buffer{},
+				mu:           &sync.Mutex{},
+				cursorHidden: true,
+				prefix:       "a",
+				suffix:       " ",
+				maxWidth:     1,
+				stopColorFn:  fmt.Sprintf,
+				stopChar:     character{Value: "x", Size: 1},
+				stopMsg:      "stop",
+				// TODO(theckman): verify
 				termMode:     ForceDumbTerminalMode,
 				lastPrintLen: 10,
 			},
 			want: "\r          \rax stop\n",
 		},
 		{
-			name: "fail",
-			spinner: &Spinner{
-				buffer:          &bytes.Buffer{},
-				mu:              &sync.Mutex{},
-				prefix:          "a",
-				suffix:          " ",
-				maxWidth:        1,
-				stopFailColorFn: fmt.Sprintf,
-				stopFailChar:    character{Value: "y", Size: 1},
-				stopFailMsg:     "stop",
-				termMode:        termModeTTY,
-			},
-			want: "\r\033[K\ray stop\n",
+			name: "fail",
+			spinner: &Spinner{
+				buffer:          &bytes.Buffer{},
+				mu:              &sync.Mutex{},
+				prefix:          "a",
+				suffix:          " ",
+				maxWidth:        1,
+				stopFailColorFn: fmt.Sprintf,
+				stopFailChar:    character{Value: "y", Size: 1},
+				stopFailMsg:     "stop",
+				termMode:        termModeTTY,
+			},
+			want: "\r\033[K\ray stop\n",
+		},
+		{
+			name: "fail_no_char_no_msg",
+			spinner: &Spinner{
+				buffer:          &bytes.Buffer{},
+				mu:              &sync.Mutex{},
+				prefix:          "a",
+				suffix:          " ",
+				maxWidth:        1,
+				stopFailColorFn: fmt.Sprintf,
+				termMode:        termModeTTY,
+			},
+			want: "\r\033[K\r",
+		},
+		{
+			name: "fail_no_char_no_msg_dumb_term",
+			spinner: &Spinner{
+				buffer:   &bytes.Buffer{},
+				mu:       &sync.Mutex{},
+				prefix:   "a",
+				suffix:   " ",
+				maxWidth: 1,
+				// TODO(theckman): verify
+				termMode:        ForceDumbTerminalMode,
+				stopFailColorFn: fmt.Sprintf,
+			},
+			want: "\r\r",
+		},
+		{
+			name: "fail_colorall",
+			spinner: &Spinner{
+				buffer:   &bytes.Buffer{},
+				mu:       &sync.Mutex{},
+				prefix:   "a",
+				suffix:   " ",
+				maxWidth: 1,
+				stopFailColorFn: func(format string, a ...interface{}) string {
+					return fmt.Sprintf("fullColor: %s", fmt.Sprintf(format, a...))
+				},
+				stopFailChar: character{Value: "y", Size: 1},
+				stopFailMsg:  "stop",
+				colorAll:     true,
+				termMode:     termModeTTY,
+			},
+			want: "\r\033[K\rfullColor: ay stop\n",
+		},
+		{
+			name: "fail_colorall_spinnerAtEnd",
+			spinner: &Spinner{
+				buffer:   &bytes.Buffer{},
+				mu:       &sync.Mutex{},
+				prefix:   " a",
+				suffix:   " ",
+				maxWidth: 1,
+				stopFailColorFn: func(format string, a ...interface{}) string {
+					return fmt.Sprintf("fullColor: %s", fmt.Sprintf(format, a...))
+				},
+				stopFailChar: character{Value: "y", Size: 1},
+				stopFailMsg:  "stop",
+				colorAll:     true,
+				spinnerAtEnd: true,
+				termMode:     termModeTTY,
+			},
+			want: "\r\033[K\rfullColor: stop ay \n",
+		},
+		{
+			name: "fail_colorall_no_char",
+			spinner: &Spinner{
+				buffer:   &bytes.Buffer{},
+				mu:       &sync.Mutex{},
+				prefix:   "a",
+				suffix:   " ",
+				maxWidth: 0,
+				stopFailColorFn: func(format string, a ...interface{}) string {
+					return fmt.Sprintf("fullColor: %s", fmt.Sprintf(format, a...))
+				},
+				stopFailChar: character{Value: "", Size: 0},
+				stopFailMsg:  "stop",
+				colorAll:     true,
+				termMode:     termModeTTY,
+			},
+			want: "\r\033[K\rfullColor: stop\n",
+		},
+		{
+			name: "ok_osc_progress",
+			ok:   true,
+			spinner: &Spinner{
+				buffer:          &bytes.Buffer{},
+				mu:              &sync.Mutex{},
+				prefix:          "a",
+				suffix:          " ",
+				maxWidth:        1,
+				stopColorFn:     fmt.Sprintf,
+				stopChar:        character{Value: "x", Size: 1},
+				stopMsg:         "stop",
+				emitOSCProgress: true,
+				percentSet:      true,
+				termMode:        termModeTTY,
+			},
+			want: "\r\033[K\r\033]9;4;0;0\007ax stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			tt.spinner.writer = buf
+
+			tt.spinner.paintStop(tt.ok)
+
+			got := buf.String()
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_handleFrequencyUpdate(t *testing.T) {
+	tests := []struct {
+		name         string
+		newFrequency time.Duration
+		lastTickAgo  time.Duration
+		shouldTick   time.Duration
+	}{
+		{
+			name:         "moreTime",
+			newFrequency: 200 * time.Millisecond,
+			lastTickAgo:  100 * time.Millisecond,
+			shouldTick:   (100 * time.Millisecond) + (500 * time.Microsecond),
+		},
+		{
+			name:         "lessTime",
+			newFrequency: 100 * time.Millisecond,
+			lastTickAgo:  200 * time.Millisecond,
+			shouldTick:   100 * time.Microsecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timer := time.NewTimer(0)
+			lastTick := time.Now().Add(-tt.lastTickAgo)
+
+			time.Sleep(10 * time.Microsecond)
+
+			handleFrequencyUpdate(tt.newFrequency, timer, lastTick)
+
+			testTimer := time.NewTimer(tt.shouldTick)
+
+			select {
+			case <-timer.C:
+				testTimer.Stop()
+			case <-testTimer.C:
+				timer.Stop()
+				t.Fatal("timer didn't fire when expected")
+			}
+		})
+	}
+}
+
+func Test_setToCharSlice(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     []string
+		wantNil   bool
+		wantChars []character
+		wantSize  int
+	}{
+		{
+			name:    "nil",
+			wantNil: true,
+		},
+		{
+			name:      "full",
+			input:     []string{"x", "zzz"},
+			wantChars: []character{{Value: "x", Size: 1}, {Value: "zzz", Size: 3}},
+			wantSize:  3,
+		},
+		{
+			name:      "combining_accent",
+			input:     []string{"e\u0301", "\u0301", "a\u0300\u0316"},
+			wantChars: []character{{Value: "e\u0301", Size: 1}, {Value: "\u0301", Size: 1}, {Value: "a\u0300\u0316", Size: 1}},
+			wantSize:  1,
+		},
+		{
+			name:      "combining_sequences",
+			input:     []string{"e\u0301e\u0301", "\u0301\u0301"},
+			wantChars: []character{{Value: "e\u0301e\u0301", Size: 2}, {Value: "\u0301\u0301", Size: 1}},
+			wantSize:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chars, size := setToCharSlice(tt.input, nil)
+
+			if size != tt.wantSize {
+				t.Errorf("size = %d, want %d", size, tt.wantSize)
+			}
+
+			if tt.wantNil && chars != nil {
+				t.Fatal("chars not nil")
+			}
+
+			for i := range chars {
+				if x, y := chars[i], tt.wantChars[i]; x != y {
+					t.Errorf("chars[%d] = %#v, want %#v", i, x, y)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderFrame(t *testing.T) {
+	redFn := color.New(color.FgRed).SprintfFunc()
+
+	tests := []struct {
+		name string
+		opts FrameOptions
+		op   paintOp
+	}{
+		{
+			name: "uncolored",
+			opts: FrameOptions{MaxWidth: 2, Character: "y", Prefix: "p", Suffix: " s", Message: "msg", SuffixAutoColon: true},
+			op:   paintOp{maxWidth: 2, char: character{Value: "y", Size: 1}, prefix: "p", suffix: " s", message: "msg", suffixAutoColon: true, colorFn: fmt.Sprintf},
+		},
+		{
+			name: "colored",
+			opts: FrameOptions{MaxWidth: 1, Character: "y", Suffix: " ", Message: "msg", ColorFn: redFn},
+			op:   paintOp{maxWidth: 1, char: character{Value: "y", Size: 1}, suffix: " ", message: "msg", colorFn: redFn},
+		},
+		{
+			name: "color_all_spinner_at_end",
+			opts: FrameOptions{MaxWidth: 2, Character: "日", Prefix: " ", Message: "msg", ColorAll: true, SpinnerAtEnd: true, ColorFn: redFn},
+			op:   paintOp{maxWidth: 2, char: character{Value: "日", Size: 2}, prefix: " ", message: "msg", colorAll: true, spinnerAtEnd: true, colorFn: redFn},
+		},
+		{
+			name: "max_width_too_small",
+			opts: FrameOptions{Character: "yy", Suffix: " ", Message: "msg"},
+			op:   paintOp{maxWidth: 2, char: character{Value: "yy", Size: 2}, suffix: " ", message: "msg", colorFn: fmt.Sprintf},
+		},
+		{
+			name: "template",
+			opts: FrameOptions{MaxWidth: 1, Character: "y", Message: "msg", Template: "[{spinner}] {message}"},
+			op:   paintOp{maxWidth: 1, char: character{Value: "y", Size: 1}, message: "msg", template: "[{spinner}] {message}", colorFn: fmt.Sprintf},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			tt.op.writer = buf
+
+			if _, err := paint(tt.op); err != nil {
+				t.Fatalf("paint() error = %v", err)
+			}
+
+			if got, want := RenderFrame(tt.opts), buf.String(); got != want {
+				t.Fatalf("RenderFrame() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func BenchmarkRenderFrame(b *testing.B) {
+	opts := FrameOptions{MaxWidth: 1, Character: "y", Suffix: " ", Message: "msg"}
+
+	for i := 0; i < b.N; i++ {
+		_ = RenderFrame(opts)
+	}
+}
+
+func BenchmarkSpinner_Render(b *testing.B) {
+	newSpinner := func(b *testing.B) *Spinner {
+		b.Helper()
+
+		spinner, err := New(Config{
+			Writer:       io.Discard,
+			CharSet:      CharSets[9],
+			Colors:       []string{"fgRed"},
+			ShowCursor:   true,
+			TerminalMode: termModeTTY,
+		})
+		if err != nil {
+			b.Fatalf("New() error = %v", err)
+		}
+
+		if err := spinner.SetSuffix(" suffix", "fgBlue"); err != nil {
+			b.Fatalf("spinner.SetSuffix() error = %v", err)
+		}
+
+		spinner.MessageSeverity(SeverityWarn, "message")
+
+		if err := spinner.StartManual(); err != nil {
+			b.Fatalf("spinner.StartManual() error = %v", err)
+		}
+
+		return spinner
+	}
+
+	// only the spinner character changes, so the colored segments are reused
+	b.Run("unchanged", func(b *testing.B) {
+		spinner := newSpinner(b)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			spinner.Render()
+		}
+	})
+
+	// the data changes before every frame, so the segments are colored again
+	b.Run("data_changed", func(b *testing.B) {
+		spinner := newSpinner(b)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			spinner.MessageSeverity(SeverityWarn, "message")
+			spinner.Render()
+		}
+	})
+}
+
+// BenchmarkSpinner_updateWhilePainting measures updating the spinner from
+// many goroutines while the painter renders frames as fast as it can. Message()
+// doesn't take the mutex the painter holds while building a frame, unlike
+// Suffix(), so comparing the two shows the cost of contending with the painter.
+// Run it with -cpu set to more than 1 for the comparison to be meaningful.
+func BenchmarkSpinner_updateWhilePainting(b *testing.B) {
+	message := strings.Repeat("message ", 10)
+
+	benchmarks := []struct {
+		name   string
+		update func(s *Spinner)
+	}{
+		{
+			name:   "Message",
+			update: func(s *Spinner) { s.Message(message) },
+		},
+		{
+			name:   "Suffix",
+			update: func(s *Spinner) { s.Suffix(" suffix") },
+		},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			spinner, err := New(Config{
+				Writer:           io.Discard,
+				Frequency:        time.Microsecond,
+				CharSet:          CharSets[9],
+				Colors:           []string{"fgRed"},
+				Message:          message,
+				MessageWidth:     len(message),
+				DataUpdateBuffer: 64,
+				ShowCursor:       true,
+				TerminalMode:     termModeTTY,
+			})
+			if err != nil {
+				b.Fatalf("New() error = %v", err)
+			}
+
+			if err := spinner.Start(); err != nil {
+				b.Fatalf("spinner.Start() error = %v", err)
+			}
+
+			defer func() { _ = spinner.Stop() }()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					bm.update(spinner)
+				}
+			})
+		})
+	}
+}
+
+func TestSpinner_Message_concurrent(t *testing.T) {
+	// the frames are matched without the severity colors
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+
+	color.NoColor = true
+
+	buf := &safeBuffer{}
+
+	spinner, err := New(Config{
+		Writer:       buf,
+		Frequency:    time.Millisecond,
+		CharSet:      []string{"y"},
+		Suffix:       " ",
+		ShowCursor:   true,
+		TerminalMode: termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
+
+	testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 200; j++ {
+				if j%2 == 0 {
+					spinner.Message(fmt.Sprintf("worker %d update %d", i, j))
+				} else {
+					spinner.MessageSeverity(SeverityInfo, fmt.Sprintf("worker %d update %d", i, j))
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	spinner.Message("done")
+
+	if got, want := string(spinner.Snapshot()), "\r\033[K\ry done"; got != want {
+		t.Fatalf("spinner.Snapshot() = %q, want %q", got, want)
+	}
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	// every frame must be a whole message, and never a mix of updates
+	frameRe := regexp.MustCompile(`^y (worker \d+ update \d+|done)?$`)
+
+	for _, frame := range strings.Split(buf.String(), "\r\033[K\r") {
+		if len(frame) == 0 {
+			continue
+		}
+
+		if !frameRe.MatchString(frame) {
+			t.Fatalf("frame %q is malformed", frame)
+		}
+	}
+}
+
+func TestSpinner_segmentCache(t *testing.T) {
+	tests := []struct {
+		name   string
+		setter func(s *Spinner) error
+	}{
+		{
+			name:   "Colors",
+			setter: func(s *Spinner) error { return s.Colors("fgGreen") },
+		},
+		{
+			name:   "SetSuffix",
+			setter: func(s *Spinner) error { return s.SetSuffix(" ", "fgYellow") },
+		},
+		{
+			name:   "Suffix",
+			setter: func(s *Spinner) error { s.Suffix(" suffix "); return nil },
+		},
+		{
+			name:   "Prefix",
+			setter: func(s *Spinner) error { s.Prefix("prefix "); return nil },
+		},
+		{
+			name:   "Message",
+			setter: func(s *Spinner) error { s.Message("other"); return nil },
+		},
+		{
+			name:   "AppendMessage",
+			setter: func(s *Spinner) error { s.AppendMessage(" more"); return nil },
+		},
+		{
+			name:   "MessageSeverity",
+			setter: func(s *Spinner) error { s.MessageSeverity(SeverityError, "msg"); return nil },
+		},
+		{
+			name:   "SetColorAll",
+			setter: func(s *Spinner) error { s.SetColorAll(true); return nil },
+		},
+		{
+			name:   "SetSpinnerAtEnd",
+			setter: func(s *Spinner) error { s.SetSpinnerAtEnd(true); return nil },
+		},
+		{
+			name:   "HideSpinner",
+			setter: func(s *Spinner) error { s.HideSpinner(true); return nil },
+		},
+	}
+
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	newSpinner := func(t *testing.T, buf io.Writer) *Spinner {
+		t.Helper()
+
+		spinner, err := New(Config{
+			Writer:       buf,
+			CharSet:      []string{"y"},
+			Colors:       []string{"fgRed"},
+			ShowCursor:   true,
+			TerminalMode: termModeTTY,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.SetSuffix()", "", spinner.SetSuffix(" ", "fgBlue"))
+		spinner.MessageSeverity(SeverityWarn, "msg")
+
+		return spinner
+	}
+
+	// secondFrame renders two frames, applying the setter before the first
+	// or second one, and returns the second frame
+	secondFrame := func(t *testing.T, setter func(s *Spinner) error, early bool) string {
+		t.Helper()
+
+		buf := &bytes.Buffer{}
+		spinner := newSpinner(t, buf)
+
+		if setter != nil && early {
+			testErrCheck(t, "setter()", "", setter(spinner))
+		}
+
+		testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+		spinner.Render()
+		buf.Reset()
+
+		if setter != nil && !early {
+			testErrCheck(t, "setter()", "", setter(spinner))
+		}
+
+		spinner.Render()
+
+		frame := buf.String()
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		return frame
+	}
+
+	unchanged := secondFrame(t, nil, false)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := secondFrame(t, tt.setter, true)
+			got := secondFrame(t, tt.setter, false)
+
+			if want == unchanged {
+				t.Fatalf("setter didn't change the frame: %q", want)
+			}
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("frame after the setter differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_segmentCache_colorMessage(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	warn := &messageState{text: "msg", colorFn: color.New(color.FgYellow).SprintfFunc()}
+	fail := &messageState{text: "msg", colorFn: color.New(color.FgRed).SprintfFunc()}
+
+	c := (&segmentCache{}).reset(1)
+
+	if got, want := c.colorMessage(warn.colorFn, warn, "msg"), warn.colorFn("msg"); got != want {
+		t.Fatalf("c.colorMessage() = %q, want %q", got, want)
+	}
+
+	// same text and version, but a different color function
+	if got, want := c.colorMessage(fail.colorFn, fail, "msg"), fail.colorFn("msg"); got != want {
+		t.Fatalf("c.colorMessage() = %q, want %q", got, want)
+	}
+}
+
+func Test_truncateLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		max  int
+		want string
+	}{
+		{
+			name: "no_max",
+			line: "abcdef",
+			want: "abcdef",
+		},
+		{
+			name: "shorter",
+			line: "abc",
+			max:  4,
+			want: "abc",
+		},
+		{
+			name: "at_boundary",
+			line: "abcd",
+			max:  4,
+			want: "abcd",
+		},
+		{
+			name: "over_boundary",
+			line: "abcde",
+			max:  4,
+			want: "abc…",
+		},
+		{
+			name: "multibyte_at_boundary",
+			line: "ab日本",
+			max:  6,
+			want: "ab日本",
+		},
+		{
+			name: "multibyte_over_boundary",
+			line: "ab日本語",
+			max:  6,
+			want: "ab日…",
+		},
+		{
+			name: "multibyte_split",
+			line: "a日本",
+			max:  3,
+			want: "a…",
+		},
+		{
+			name: "colored",
+			line: "\x1b[31my\x1b[0m message",
+			max:  5,
+			want: "\x1b[31my\x1b[0m me…\x1b[0m",
+		},
+		{
+			name: "hyperlink",
+			line: "v \x1b]8;;https://example.com\x1b\\done now\x1b]8;;\x1b\\",
+			max:  5,
+			want: "v \x1b]8;;https://example.com\x1b\\do…\x1b]8;;\x1b\\",
+		},
+		{
+			name: "colored_at_boundary",
+			line: "\x1b[31my\x1b[0m msg",
+			max:  5,
+			want: "\x1b[31my\x1b[0m msg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateLine(tt.line, tt.max, nil); got != tt.want {
+				t.Fatalf("truncateLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpinner_maxLineLength(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	cfg := testConfig(buf, termModeTTY)
+	cfg.Message = "a long message"
+	cfg.StopMessage = "done"
+	cfg.MaxLineLength = 8
+
+	spinner := newTestSpinner(t, cfg)
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	spinner.Render()
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	want := "\r\033[K\ry a lon…\r\033[K\rv done\n"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
+	}
+}
+
+func TestSpinner_painter(t *testing.T) {
+	t.Run("animated", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("skipping test in short mode.")
+		}
+
+		const want = "\r\033[K\ray msg\r\033[K\ray othermsg\r\033[K\raz msg\r\033[K\ray msg\r\x1b[K\rav stop\n"
+
+		buf := &bytes.Buffer{}
+
+		cancel, done, dataUpdate, pause := make(chan struct{}), make(chan struct{}), make(chan struct{}), make(chan struct{})
+		frequencyUpdate := make(chan time.Duration, 1)
+
+		spinner := withMessage(&Spinner{
+			buffer:            &bytes.Buffer{},
+			mu:                &sync.Mutex{},
+			writer:            buf,
+			prefix:            "a",
+			suffix:            " ",
+			maxWidth:          1,
+			colorFn:           fmt.Sprintf,
+			chars:             []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+			stopColorFn:       fmt.Sprintf,
+			stopMsg:           "stop",
+			stopChar:          character{Value: "v", Size: 1},
+			frequency:         2000 * time.Millisecond,
+			cancelCh:          cancel,
+			doneCh:            done,
+			dataUpdateCh:      dataUpdate,
+			frequencyUpdateCh: frequencyUpdate,
+			termMode:          termModeTTY,
+		}, "msg")
+
+		go spinner.painter(cancel, dataUpdate, pause, done, frequencyUpdate, nil, nil, nil)
+
+		time.Sleep(500 * time.Millisecond)
+
+		spinner.mu.Lock()
+
+		spinner.message.Store(&messageState{text: "othermsg"})
+		spinner.dataUpdateCh <- struct{}{}
+
+		spinner.mu.Unlock()
+
+		time.Sleep(500 * time.Millisecond)
+
+		spinner.unpauseCh, spinner.unpausedCh = make(chan struct{}), make(chan struct{})
+		pause <- struct{}{}
+
+		close(spinner.unpauseCh)
+		_, ok := <-spinner.unpausedCh
+
+		if ok {
+			t.Fatal("unexpected successful channel receive")
+		}
+
+		spinner.unpauseCh = nil
+		spinner.unpausedCh = nil
+
+		spinner.mu.Lock()
+
+		spinner.message.Store(&messageState{text: "msg"})
+		spinner.frequency = 1000 * time.Millisecond
+		frequencyUpdate <- 1000 * time.Millisecond
+
+		spinner.mu.Unlock()
+
+		time.Sleep(1200 * time.Millisecond)
+
+		cancel <- struct{}{}
+
+		<-done
+
+		got := buf.String()
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		}
+	})
+
+	t.Run("no_tty", func(t *testing.T) {
+		const want = "ay msg\naz othermsg\nay msg\naz msg\nav stop\n"
+
+		buf := &bytes.Buffer{}
+
+		cancel, done, dataUpdate, pause := make(chan struct{}), make(chan struct{}), make(chan struct{}), make(chan struct{})
+		frequencyUpdate := make(chan time.Duration, 1)
+
+		spinner := withMessage(&Spinner{
+			buffer:            &bytes.Buffer{},
+			mu:                &sync.Mutex{},
+			writer:            buf,
+			prefix:            "a",
+			suffix:            " ",
+			maxWidth:          1,
+			colorFn:           fmt.Sprintf,
+			chars:             []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
+			stopColorFn:       fmt.Sprintf,
+			stopMsg:           "stop",
+			stopChar:          character{Value: "v", Size: 1},
+			frequency:         time.Duration(math.MaxInt64),
+			cancelCh:          cancel,
+			doneCh:            done,
+			dataUpdateCh:      dataUpdate,
+			frequencyUpdateCh: frequencyUpdate,
+			termMode:          ForceDumbTerminalMode | ForceNoTTYMode,
+		}, "msg")
+
+		go spinner.painter(cancel, dataUpdate, pause, done, frequencyUpdate, nil, nil, nil)
+
+		time.Sleep(100 * time.Millisecond)
+
+		spinner.mu.Lock()
+
+		spinner.message.Store(&messageState{text: "othermsg"})
+		spinner.dataUpdateCh <- struct{}{}
+
+		spinner.mu.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+
+		spinner.unpauseCh, spinner.unpausedCh = make(chan struct{}), make(chan struct{})
+		pause <- struct{}{}
+
+		close(spinner.unpauseCh)
+		_, ok := <-spinner.unpausedCh
+
+		if ok {
+			t.Fatal("unexpected successful channel receive")
+		}
+
+		spinner.unpauseCh = nil
+		spinner.unpausedCh = nil
+
+		spinner.mu.Lock()
+
+		spinner.message.Store(&messageState{text: "msg"})
+		spinner.dataUpdateCh <- struct{}{}
+
+		spinner.mu.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+
+		spinner.mu.Lock()
+
+		spinner.message.Store(&messageState{text: "msg"})
+		spinner.dataUpdateCh <- struct{}{}
+
+		spinner.mu.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+
+		cancel <- struct{}{}
+
+		<-done
+
+		got := buf.String()
+
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		}
+	})
+}
+
+func TestSpinnerStatus_String(t *testing.T) {
+	tests := []struct {
+		name string
+		ss   SpinnerStatus
+		want string
+	}{
+		{
+			name: "stopped",
+			ss:   SpinnerStopped,
+			want: "stopped",
+		},
+		{
+			name: "starting",
+			ss:   SpinnerStarting,
+			want: "starting",
+		},
+		{
+			name: "running",
+			ss:   SpinnerRunning,
+			want: "running",
+		},
+		{
+			name: "stopping",
+			ss:   SpinnerStopping,
+			want: "stopping",
+		},
+		{
+			name: "pausing",
+			ss:   SpinnerPausing,
+			want: "pausing",
+		},
+		{
+			name: "paused",
+			ss:   SpinnerPaused,
+			want: "paused",
+		},
+		{
+			name: "unpausing",
+			ss:   SpinnerUnpausing,
+			want: "unpausing",
+		},
+		{
+			name: "unknown",
+			ss:   42,
+			want: "unknown (42)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ss.String(); got != tt.want {
+				t.Errorf("got = %#v, got %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_eastAsianWidth(t *testing.T) {
+	tests := []struct {
+		name        string
+		eastAsian   AmbiguousWidth
+		defaultWide bool // runewidth default detected from the environment
+		wantSize    int
+		want        string
+	}{
+		{
+			name:      "narrow",
+			eastAsian: AmbiguousWidthNarrow,
+			wantSize:  1,
+			want:      "\r\033[K\r★ m…\r\033[K\rx ★★\n",
+		},
+		{
+			name:        "narrow_overrides_default",
+			eastAsian:   AmbiguousWidthNarrow,
+			defaultWide: true,
+			wantSize:    1,
+			want:        "\r\033[K\r★ m…\r\033[K\rx ★★\n",
+		},
+		{
+			name:      "wide",
+			eastAsian: AmbiguousWidthWide,
+			wantSize:  2,
+			want:      "\r\033[K\r★ …\r\033[K\rx  …\n",
+		},
+		{
+			name:     "auto_narrow",
+			wantSize: 1,
+			want:     "\r\033[K\r★ m…\r\033[K\rx ★★\n",
+		},
+		{
+			name:        "auto_wide",
+			defaultWide: true,
+			wantSize:    2,
+			want:        "\r\033[K\r★ …\r\033[K\rx  …\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(wide bool) { runewidth.DefaultCondition.EastAsianWidth = wide }(runewidth.DefaultCondition.EastAsianWidth)
+			runewidth.DefaultCondition.EastAsianWidth = tt.defaultWide
+
+			// RenderFrame() pads the character to MaxWidth, and should agree
+			// with the spinner when using the runewidth defaults
+			if tt.eastAsian == AmbiguousWidthAuto {
+				want := strings.Repeat(" ", 2-tt.wantSize)
+
+				if got := RenderFrame(FrameOptions{MaxWidth: 2, Character: "★"}); got != "★"+want {
+					t.Fatalf("RenderFrame() = %q, want %q", got, "★"+want)
+				}
+			}
+
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, termModeTTY)
+			cfg.CharSet = []string{"★"}
+			cfg.StopCharacter = "x"
+			cfg.StopMessage = "★★"
+			cfg.MaxLineLength = 4
+			cfg.EastAsianWidth = tt.eastAsian
+
+			spinner := newTestSpinner(t, cfg)
+
+			if got := spinner.chars[0].Size; got != tt.wantSize {
+				t.Fatalf("spinner.chars[0].Size = %d, want %d", got, tt.wantSize)
+			}
+
+			if spinner.maxWidth != tt.wantSize {
+				t.Fatalf("spinner.maxWidth = %d, want %d", spinner.maxWidth, tt.wantSize)
+			}
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_Config(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	cfg := Config{
+		Frequency:                 100 * time.Millisecond,
+		Writer:                    buf,
+		ShowCursor:                true,
+		SpinnerAtEnd:              true,
+		Colors:                    []string{"fgYellow"},
+		ColorProfile:              ColorProfileANSI256,
+		CharSet:                   []string{"a", "b", "c"},
+		StartIndex:                1,
+		ParallelChars:             2,
+		Prefix:                    "p",
+		Suffix:                    " ",
+		SuffixAutoColon:           true,
+		Message:                   "msg",
+		SubMessage:                "sub",
+		ShowPercent:               true,
+		ProgressUnit:              "files",
+		Timestamp:                 true,
+		TimestampFormat:           time.Kitchen,
+		StopMessage:               "stop",
+		StopCharacter:             "✓",
+		StopCharacterFallback:     "v",
+		StopColors:                []string{"fgGreen"},
+		StopFailMessage:           "fail",
+		StopFailCharacter:         "✗",
+		StopFailCharacterFallback: "x",
+		StopFailColors:            []string{"fgRed"},
+		BellOnStopFail:            true,
+		OutcomeCharacters:         map[string]string{"skipped": "-"},
+		OutcomeMessages:           map[string]string{"skipped": "skipped"},
+		OutcomeColors:             map[string][]string{"skipped": {"fgYellow"}},
+		TerminalMode:              termModeTTY,
+		DumbEraseNewline:          true,
+		DataUpdateBuffer:          4,
+		MaxLineLength:             80,
+		EastAsianWidth:            AmbiguousWidthWide,
+		PreserveIndexOnStop:       true,
+		Newline:                   "\r\n",
+		MaxRedrawRate:             time.Second,
+		MaxWriteErrors:            3,
+	}
+
+	spinner, err := New(cfg)
+	testErrCheck(t, "New()", "", err)
+
+	got := spinner.Config()
+
+	if got.Writer != buf {
+		t.Fatalf("got.Writer = %v, want %v", got.Writer, buf)
+	}
+
+	ignoreWriter := cmpopts.IgnoreFields(Config{}, "Writer")
+
+	if diff := cmp.Diff(cfg, got, ignoreWriter); diff != "" {
+		t.Fatalf("Config() differs: (-want / +got)\n%s", diff)
+	}
+
+	// resolved values and runtime changes should be reflected
+	spinner, err = New(Config{Frequency: time.Second, Writer: buf, TerminalMode: ForceTTYMode | ForceDumbTerminalMode})
+	testErrCheck(t, "New()", "", err)
+
+	spinner.Message("updated")
+	testErrCheck(t, "spinner.StopColors()", "", spinner.StopColors("fgBlue"))
+
+	want := Config{
+		Frequency:        time.Second,
+		CharSet:          DefaultCharSet,
+		StopColors:       []string{"fgBlue"},
+		ColorProfile:     ColorProfileTrueColor,
+		Message:          "updated",
+		TerminalMode:     ForceTTYMode | ForceDumbTerminalMode,
+		DataUpdateBuffer: 1,
+	}
+
+	if diff := cmp.Diff(want, spinner.Config(), ignoreWriter); diff != "" {
+		t.Fatalf("Config() differs: (-want / +got)\n%s", diff)
+	}
+
+	// the returned Config should be usable to construct an equivalent spinner
+	clone, err := New(spinner.Config())
+	testErrCheck(t, "New()", "", err)
+
+	if diff := cmp.Diff(spinner.Config(), clone.Config(), ignoreWriter); diff != "" {
+		t.Fatalf("clone Config() differs: (-want / +got)\n%s", diff)
+	}
+}
+
+func TestSpinner_LogMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		termMode TerminalMode
+		jsonMode bool
+		start    bool
+		sub      string
+		want     string
+	}{
+		{
+			name:     "stopped",
+			termMode: termModeTTY,
+			want:     "log\n",
+		},
+		{
+			name:     "stopped_json",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			jsonMode: true,
+			want:     `{"status":"log","message":"log","elapsed_ms":0}` + "\n",
+		},
+		{
+			name:     "smart_terminal",
+			termMode: termModeTTY,
+			start:    true,
+			want:     "\r\033[K\ry msg\r\033[K\rlog\n\r\033[K\ry msg\r\033[K\rv stop\n",
+		},
+		{
+			name:     "smart_terminal_sub_message",
+			termMode: termModeTTY,
+			start:    true,
+			sub:      "sub",
+			want:     "\r\033[K\ry msg\n  sub\r\033[K\033[1A\r\033[K\rlog\n\r\033[K\ry msg\n  sub\r\033[K\033[1A\r\033[K\rv stop\n",
+		},
+		{
+			name:     "dumb_terminal",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			start:    true,
+			want:     "\r\ry msg\r     \rlog\n\r\ry msg\r     \rv stop\n",
+		},
+		{
+			name:     "no_tty",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			start:    true,
+			want:     "y msg\nlog\nv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.SubMessage = tt.sub
+			cfg.JSONMode = tt.jsonMode
+
+			spinner := newTestSpinner(t, cfg)
+
+			if tt.start {
+				testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+				spinner.Render()
+			}
+
+			testErrCheck(t, "spinner.LogMessage()", "", spinner.LogMessage("log"))
+
+			if tt.start {
+				testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+			}
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("write_error", func(t *testing.T) {
+		spinner, err := New(Config{
+			Writer:       &flakyWriter{fail: true},
+			CharSet:      []string{"y"},
+			TerminalMode: termModeTTY,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.LogMessage()", "failed to write log message: ", spinner.LogMessage("log"))
+	})
+}
+
+func TestSpinner_LogMessage_concurrent(t *testing.T) {
+	buf := &safeBuffer{}
+
+	cfg := testConfig(buf, termModeTTY)
+	cfg.Frequency = time.Millisecond
+
+	spinner := newTestSpinner(t, cfg)
+
+	testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 25; j++ {
+				_ = spinner.LogMessage(fmt.Sprintf("log %d-%d", i, j))
+			}
+		}(i)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 25; j++ {
+				spinner.Message(fmt.Sprintf("msg %d-%d", i, j))
+			}
+		}(i)
+	}
+
+	// stop while messages are still being logged
+	time.Sleep(5 * time.Millisecond)
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	wg.Wait()
+
+	out := buf.String()
+
+	// every log line and frame must be whole, with nothing written in the
+	// middle of them
+	segment := regexp.MustCompile(`^(?:log \d-\d+\n)*(?:y msg(?: \d-\d+)?)?$`)
+
+	segments := strings.Split(out, "\r\033[K\r")
+
+	last := segments[len(segments)-1]
+	if !strings.HasSuffix(last, "v stop\n") {
+		t.Fatalf("last segment = %q, want it to end with the stop line", last)
+	}
+
+	segments[len(segments)-1] = strings.TrimSuffix(last, "v stop\n")
+
+	for i, seg := range segments {
+		if !segment.MatchString(seg) {
+			t.Fatalf("segments[%d] = %q is not a whole frame or log line", i, seg)
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 25; j++ {
+			if line := fmt.Sprintf("log %d-%d\n", i, j); !strings.Contains(out, line) {
+				t.Fatalf("output is missing log line %q", line)
+			}
+		}
+	}
+}
+
+func TestSpinner_SetSpeed(t *testing.T) {
+	tests := []struct {
+		name       string
+		multiplier float64
+		want       time.Duration
+		err        string
+	}{
+		{
+			name:       "normal",
+			multiplier: 1,
+			want:       100 * time.Millisecond,
+		},
+		{
+			name:       "twice_as_fast",
+			multiplier: 2,
+			want:       50 * time.Millisecond,
+		},
+		{
+			name:       "four_times_as_fast",
+			multiplier: 4,
+			want:       25 * time.Millisecond,
+		},
+		{
+			name:       "half_as_fast",
+			multiplier: 0.5,
+			want:       200 * time.Millisecond,
+		},
+		{
+			name:       "zero",
+			multiplier: 0,
+			err:        "speed multiplier must be a finite number greater than 0",
+		},
+		{
+			name:       "negative",
+			multiplier: -1,
+			err:        "speed multiplier must be a finite number greater than 0",
+		},
+		{
+			name:       "nan",
+			multiplier: math.NaN(),
+			err:        "speed multiplier must be a finite number greater than 0",
+		},
+		{
+			name:       "infinity",
+			multiplier: math.Inf(1),
+			err:        "speed multiplier must be a finite number greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner, err := New(Config{
+				Writer:       &bytes.Buffer{},
+				Frequency:    100 * time.Millisecond,
+				TerminalMode: termModeTTY,
+			})
+			testErrCheck(t, "New()", "", err)
+
+			spinner.frequencyUpdateCh = make(chan time.Duration, 1)
+
+			if cont := testErrCheck(t, "spinner.SetSpeed()", tt.err, spinner.SetSpeed(tt.multiplier)); !cont {
+				return
+			}
+
+			if got := spinner.interval(); got != tt.want {
+				t.Fatalf("spinner.interval() = %s, want %s", got, tt.want)
+			}
+
+			if got := <-spinner.frequencyUpdateCh; got != tt.want {
+				t.Fatalf("frequency update = %s, want %s", got, tt.want)
+			}
+
+			// the base frequency is unchanged, and changing it keeps the speed
+			if spinner.frequency != 100*time.Millisecond {
+				t.Fatalf("spinner.frequency = %s, want %s", spinner.frequency, 100*time.Millisecond)
+			}
+
+			testErrCheck(t, "spinner.Frequency()", "", spinner.Frequency(200*time.Millisecond))
+
+			if got := <-spinner.frequencyUpdateCh; got != 2*tt.want {
+				t.Fatalf("frequency update = %s, want %s", got, 2*tt.want)
+			}
+		})
+	}
+}
+
+func TestSpinner_StopTimed(t *testing.T) {
+	const (
+		run   = 50 * time.Millisecond
+		pause = 100 * time.Millisecond
+		slack = 40 * time.Millisecond
+	)
+
+	tests := []struct {
+		name  string
+		fail  bool
+		pause bool
+		want  time.Duration
+	}{
+		{
+			name: "stop",
+			want: run,
+		},
+		{
+			name: "stop_fail",
+			fail: true,
+			want: run,
+		},
+		{
+			name:  "paused_time_excluded",
+			pause: true,
+			want:  2 * run,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner, err := New(Config{
+				Writer:       &safeBuffer{},
+				Frequency:    10 * time.Millisecond,
+				CharSet:      []string{"y"},
+				ShowCursor:   true,
+				TerminalMode: termModeTTY,
+			})
+			testErrCheck(t, "New()", "", err)
+
+			stop := spinner.StopTimed
+			if tt.fail {
+				stop = spinner.StopFailTimed
+			}
+
+			_, err = stop()
+			testErrCheck(t, "stop()", "spinner not running or paused", err)
+
+			testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+			time.Sleep(run)
+
+			if tt.pause {
+				testErrCheck(t, "spinner.Pause()", "", spinner.Pause())
+				time.Sleep(pause)
+				testErrCheck(t, "spinner.Unpause()", "", spinner.Unpause())
+				time.Sleep(run)
+			}
+
+			got, err := stop()
+			testErrCheck(t, "stop()", "", err)
+
+			if got < tt.want || got > tt.want+slack {
+				t.Fatalf("stop() = %s, want between %s and %s", got, tt.want, tt.want+slack)
+			}
+		})
+	}
+}
+
+func TestSpinner_noTTYFormat(t *testing.T) {
+	format := func(message string) string { return "INFO " + message }
+
+	tests := []struct {
+		name     string
+		termMode TerminalMode
+		format   func(string) string
+		sub      string
+		want     string
+	}{
+		{
+			name:     "no_format",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			want:     "y msg\nlog\ny othermsg\nv done\nv stop\n",
+		},
+		{
+			name:     "format",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			format:   format,
+			want:     "INFO y msg\nINFO log\nINFO y othermsg\nINFO v done\nINFO v stop\n",
+		},
+		{
+			name:     "format_sub_message",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			format:   format,
+			sub:      "sub",
+			want:     "INFO y msg\nINFO   sub\nINFO log\nINFO y othermsg\nINFO   sub\nINFO v done\nINFO v stop\n",
+		},
+		{
+			name:     "tty_ignored",
+			termMode: termModeTTY,
+			format:   format,
+			want:     "\r\033[K\ry msg\r\033[K\rlog\n\r\033[K\ry msg\r\033[K\ry othermsg\r\033[K\rv done\n\r\033[K\ry othermsg\r\033[K\rv stop\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.SubMessage = tt.sub
+			cfg.NoTTYFormat = tt.format
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+			testErrCheck(t, "spinner.LogMessage()", "", spinner.LogMessage("log"))
+			spinner.Message("othermsg")
+			spinner.Render()
+			testErrCheck(t, "spinner.Complete()", "", spinner.Complete("done"))
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_padCharacter(t *testing.T) {
+	tests := []struct {
+		name string
+		pad  string
+		want string
+		err  string
+	}{
+		{
+			name: "default",
+			want: "\r\033[K\ra   msg\r\033[K\rbbb msg\r\033[K\rv   stop\n",
+		},
+		{
+			name: "space",
+			pad:  " ",
+			want: "\r\033[K\ra   msg\r\033[K\rbbb msg\r\033[K\rv   stop\n",
+		},
+		{
+			name: "middle_dot",
+			pad:  "·",
+			want: "\r\033[K\ra·· msg\r\033[K\rbbb msg\r\033[K\rv·· stop\n",
+		},
+		{
+			name: "multiple_characters",
+			pad:  "--",
+			err:  "cfg.PadCharacter must be a single column wide",
+		},
+		{
+			name: "wide_character",
+			pad:  "🌑",
+			err:  "cfg.PadCharacter must be a single column wide",
+		},
+		{
+			name: "zero_width",
+			pad:  "\u200b",
+			err:  "cfg.PadCharacter must be a single column wide",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, termModeTTY)
+			cfg.CharSet = []string{"a", "bbb"}
+			cfg.PadCharacter = tt.pad
+
+			spinner, err := New(cfg)
+
+			if cont := testErrCheck(t, "New()", tt.err, err); !cont {
+				return
+			}
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+			spinner.Render()
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNew_forceTestMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		termMode TerminalMode
+		want     TerminalMode
+		err      string
+	}{
+		{
+			name:     "test_mode",
+			termMode: ForceTestMode,
+			want:     ForceTestMode | ForceTTYMode | ForceDumbTerminalMode,
+		},
+		{
+			name:     "resolved",
+			termMode: ForceTestMode | ForceTTYMode | ForceDumbTerminalMode,
+			want:     ForceTestMode | ForceTTYMode | ForceDumbTerminalMode,
+		},
+		{
+			name:     "no_tty",
+			termMode: ForceTestMode | ForceNoTTYMode,
+			err:      "cfg.TerminalMode cannot have ForceTestMode flag set with ForceNoTTYMode or ForceSmartTerminalMode",
+		},
+		{
+			name:     "smart_terminal",
+			termMode: ForceTestMode | ForceSmartTerminalMode,
+			err:      "cfg.TerminalMode cannot have ForceTestMode flag set with ForceNoTTYMode or ForceSmartTerminalMode",
+		},
+		{
+			name:     "automatic",
+			termMode: ForceTestMode | AutomaticMode,
+			err:      "cfg.TerminalMode cannot have AutomaticMode flag set if others are set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner, err := New(Config{Frequency: time.Second, TerminalMode: tt.termMode})
+			if cont := testErrCheck(t, "New()", tt.err, err); !cont {
+				return
+			}
+
+			if spinner.termMode != tt.want {
+				t.Fatalf("spinner.termMode = %d, want %d", spinner.termMode, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpinner_forceTestMode(t *testing.T) {
+	t.Run("manual", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		cfg := testConfig(buf, ForceTestMode)
+		cfg.ShowCursor = false
+		cfg.CharSet = []string{"a", "b"}
+		cfg.SubMessage = "sub"
+		cfg.Colors = []string{"fgYellow"}
+
+		spinner := newTestSpinner(t, cfg)
+
+		testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+		spinner.Render()
+		spinner.Message("othermsg")
+		spinner.Render()
+		spinner.Render()
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		want := "a msg\n  sub\nb othermsg\n  sub\na othermsg\n  sub\nv stop\n"
+
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		}
+	})
+
+	t.Run("animated", func(t *testing.T) {
+		buf := &safeBuffer{}
+
+		cfg := testConfig(buf, ForceTestMode)
+		cfg.ShowCursor = false
+		cfg.Frequency = 5 * time.Millisecond
+		cfg.CharSet = []string{"a", "b"}
+
+		spinner := newTestSpinner(t, cfg)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		time.Sleep(50 * time.Millisecond)
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+		if len(lines) < 3 {
+			t.Fatalf("len(lines) = %d, want at least 3; output: %q", len(lines), buf.String())
+		}
+
+		frame := regexp.MustCompile(`^[ab] msg$`)
+
+		for i, line := range lines[:len(lines)-1] {
+			if !frame.MatchString(line) {
+				t.Fatalf("lines[%d] = %q is not a plain frame", i, line)
+			}
+		}
+
+		if last := lines[len(lines)-1]; last != "v stop" {
+			t.Fatalf("last line = %q, want %q", last, "v stop")
+		}
+	})
+}
+
+func TestSpinner_heartbeat(t *testing.T) {
+	_, err := New(Config{Frequency: time.Second, HeartbeatInterval: -1})
+	testErrCheck(t, "New()", "cfg.HeartbeatInterval cannot be negative", err)
+
+	newSpinner := func(t *testing.T, buf io.Writer, interval time.Duration, msg string) *Spinner {
+		t.Helper()
+
+		cfg := testConfig(buf, ForceNoTTYMode|ForceDumbTerminalMode)
+		cfg.ShowCursor = false
+		cfg.Frequency = 10 * time.Millisecond
+		cfg.HeartbeatInterval = interval
+		cfg.HeartbeatMessage = msg
+
+		spinner := newTestSpinner(t, cfg)
+
+		return spinner
+	}
+
+	countLines := func(out, line string) int {
+		var n int
+
+		for _, l := range strings.Split(out, "\n") {
+			if l == line {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	t.Run("frame", func(t *testing.T) {
+		buf := &safeBuffer{}
+		spinner := newSpinner(t, buf, 20*time.Millisecond, "")
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+		time.Sleep(110 * time.Millisecond)
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		out := buf.String()
+
+		// the first frame, followed by a heartbeat every 20ms
+		if n := countLines(out, "y msg"); n < 3 || n > 7 {
+			t.Fatalf("output = %q, want 3-7 frames", out)
+		}
+
+		if !strings.HasSuffix(out, "v stop\n") {
+			t.Fatalf("output = %q, want it to end with the stop line", out)
+		}
+	})
+
+	t.Run("message", func(t *testing.T) {
+		buf := &safeBuffer{}
+		spinner := newSpinner(t, buf, 20*time.Millisecond, "still working")
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+		time.Sleep(110 * time.Millisecond)
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		out := buf.String()
+
+		if n := countLines(out, "still working"); n < 3 || n > 6 {
+			t.Fatalf("output = %q, want 3-6 heartbeat lines", out)
+		}
+
+		if n := countLines(out, "y msg"); n != 1 {
+			t.Fatalf("output = %q, want a single frame", out)
+		}
+	})
+
+	t.Run("postponed_by_updates", func(t *testing.T) {
+		buf := &safeBuffer{}
+		spinner := newSpinner(t, buf, 100*time.Millisecond, "still working")
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		for i := 0; i < 25; i++ {
+			spinner.Message(fmt.Sprintf("msg %d", i))
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		if out := buf.String(); strings.Contains(out, "still working") {
+			t.Fatalf("output = %q, want no heartbeat lines", out)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		buf := &safeBuffer{}
+		spinner := newSpinner(t, buf, 0, "still working")
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+		time.Sleep(50 * time.Millisecond)
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		if diff := cmp.Diff("y msg\nv stop\n", buf.String()); diff != "" {
+			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		}
+	})
+}
+
+func TestSpinner_initialDelay(t *testing.T) {
+	_, err := New(Config{Frequency: time.Second, InitialDelay: -1})
+	testErrCheck(t, "New()", "cfg.InitialDelay cannot be negative", err)
+
+	newSpinner := func(t *testing.T, buf io.Writer) *Spinner {
+		t.Helper()
+
+		cfg := testConfig(buf, termModeTTY)
+		cfg.Frequency = 10 * time.Millisecond
+		cfg.InitialDelay = 100 * time.Millisecond
+
+		spinner := newTestSpinner(t, cfg)
+
+		return spinner
+	}
+
+	t.Run("delayed", func(t *testing.T) {
+		buf := &safeBuffer{}
+		spinner := newSpinner(t, buf)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		// neither data updates nor frequency changes paint early
+		spinner.Message("othermsg")
+		testErrCheck(t, "spinner.Frequency()", "", spinner.Frequency(5*time.Millisecond))
+
+		time.Sleep(50 * time.Millisecond)
+
+		if got := buf.String(); got != "" {
+			t.Fatalf("output before InitialDelay = %q, want empty", got)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		if got := buf.String(); !strings.HasPrefix(got, "\r\033[K\ry othermsg") {
+			t.Fatalf("output = %q, want it to start with the first frame", got)
+		}
+	})
+
+	t.Run("stopped_before_delay", func(t *testing.T) {
+		buf := &safeBuffer{}
+		spinner := newSpinner(t, buf)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		time.Sleep(10 * time.Millisecond)
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		if diff := cmp.Diff("\r\033[K\rv stop\n", buf.String()); diff != "" {
+			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		}
+	})
+}
+
+func TestSpinner_postStopDelay(t *testing.T) {
+	_, err := New(Config{Frequency: time.Second, PostStopDelay: -1})
+	testErrCheck(t, "New()", "cfg.PostStopDelay cannot be negative", err)
+
+	const delay = 100 * time.Millisecond
+
+	tests := []struct {
+		name string
+		fail bool
+		want string
+	}{
+		{
+			name: "stop",
+			want: "\r\033[K\ry msg\r\033[K\rv stop\n",
+		},
+		{
+			name: "stop_fail",
+			fail: true,
+			want: "\r\033[K\ry msg\r\033[K\rx fail\n",
 		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, tw := &bytes.Buffer{}, &timedWriter{}
+
+			cfg := testConfig(io.MultiWriter(buf, tw), termModeTTY)
+			cfg.StopFailCharacter = "x"
+			cfg.StopFailMessage = "fail"
+			cfg.PostStopDelay = delay
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+
+			start := time.Now()
+
+			if tt.fail {
+				testErrCheck(t, "spinner.StopFail()", "", spinner.StopFail())
+			} else {
+				testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+			}
+
+			returned := time.Now()
+
+			if d := returned.Sub(start); d < delay || d > 10*delay {
+				t.Fatalf("stopping took %s, want approximately %s", d, delay)
+			}
+
+			// the final line is printed before the delay
+			if d := returned.Sub(tw.writes[len(tw.writes)-1]); d < delay {
+				t.Fatalf("final line printed %s before returning, want at least %s", d, delay)
+			}
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_Snapshot(t *testing.T) {
+	tests := []struct {
+		name     string
+		termMode TerminalMode
+		sub      string
+		silent   bool
+		want     string
+	}{
 		{
-			name: "fail_no_char_no_msg",
-			spinner: &Spinner{
-				buffer:          &bytes.Buffer{},
-				mu:              &sync.Mutex{},
-				prefix:          "a",
-				suffix:          " ",
-				maxWidth:        1,
-				stopFailColorFn: fmt.Sprintf,
-				termMode:        termModeTTY,
-			},
-			want: "\r\033[K\r",
+			name:     "smart_terminal",
+			termMode: termModeTTY,
+			want:     "\r\033[K\ry msg",
+		},
+		{
+			name:     "smart_terminal_sub_message",
+			termMode: termModeTTY,
+			sub:      "sub",
+			want:     "\r\033[K\033[1A\r\033[K\ry msg\n  sub",
+		},
+		{
+			name:     "dumb_terminal",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			want:     "\r     \ry msg",
+		},
+		{
+			name:     "no_tty",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			want:     "y msg\n",
+		},
+		{
+			name:     "silent",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			silent:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &safeBuffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.StopCharacter = ""
+			cfg.StopMessage = ""
+			cfg.Frequency = time.Hour
+			cfg.SubMessage = tt.sub
+			cfg.SilentWhenNotTTY = tt.silent
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+			defer func() { _ = spinner.Stop() }()
+
+			waitForOutput := func(n int) string {
+				t.Helper()
+
+				deadline := time.Now().Add(time.Second)
+
+				for time.Now().Before(deadline) {
+					if out := buf.String(); len(out) > n {
+						return out
+					}
+
+					time.Sleep(time.Millisecond)
+				}
+
+				t.Fatalf("timed out waiting for output after %d bytes", n)
+
+				return ""
+			}
+
+			// wait for the first frame, which the snapshot erases
+			if !tt.silent {
+				waitForOutput(0)
+			}
+
+			snap := spinner.Snapshot()
+
+			if diff := cmp.Diff(tt.want, string(snap)); diff != "" {
+				t.Fatalf("Snapshot() differs: (-want / +got)\n%s", diff)
+			}
+
+			if tt.silent {
+				if snap != nil {
+					t.Fatalf("Snapshot() = %q, want nil", snap)
+				}
+
+				return
+			}
+
+			// the snapshot must match what the painter writes for the same
+			// frame, which a data update renders
+			n := len(buf.String())
+
+			spinner.Message("msg")
+
+			if got := waitForOutput(n)[n:]; got != string(snap) {
+				t.Fatalf("painter output = %q, want %q", got, snap)
+			}
+		})
+	}
+}
+
+func TestSpinner_colorProfile(t *testing.T) {
+	_, err := New(Config{Frequency: time.Second, ColorProfile: ColorProfileNoColor + 1})
+	testErrCheck(t, "New()", "cfg.ColorProfile 5 is not a valid ColorProfile", err)
+
+	_, err = New(Config{Frequency: time.Second, Colors: []string{"#12345"}, TerminalMode: termModeTTY})
+	testErrCheck(t, "New()", "#12345 is not a valid color", err)
+
+	spinner, err := New(Config{
+		Frequency:    time.Second,
+		Colors:       []string{"#ff0000"},
+		ColorProfile: ColorProfileANSI16,
+		TerminalMode: termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
+
+	if spinner.colorProfile != ColorProfileANSI16 {
+		t.Fatalf("spinner.colorProfile = %d, want %d", spinner.colorProfile, ColorProfileANSI16)
+	}
+
+	// without AutomaticMode the profile isn't detected
+	spinner, err = New(Config{Frequency: time.Second, TerminalMode: termModeTTY})
+	testErrCheck(t, "New()", "", err)
+
+	if spinner.colorProfile != ColorProfileTrueColor {
+		t.Fatalf("spinner.colorProfile = %d, want %d", spinner.colorProfile, ColorProfileTrueColor)
+	}
+}
+
+func TestSpinner_Complete(t *testing.T) {
+	tests := []struct {
+		name     string
+		termMode TerminalMode
+		jsonMode bool
+		want     string
+	}{
+		{
+			name:     "smart_terminal",
+			termMode: termModeTTY,
+			want:     "\r\033[K\ry one\r\033[K\rv one\n\r\033[K\ry one\r\033[K\ry two\r\033[K\rv done\n",
+		},
+		{
+			name:     "dumb_terminal",
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			want:     "\r\ry one\r     \rv one\n\r\ry one\r     \ry two\r     \rv done\n",
+		},
+		{
+			name:     "no_tty",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			want:     "y one\nv one\ny two\nv done\n",
+		},
+		{
+			name:     "no_tty_json",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			jsonMode: true,
+			want: `{"status":"running","message":"one","elapsed_ms":0}` + "\n" +
+				`{"status":"completed","message":"one","elapsed_ms":0}` + "\n" +
+				`{"status":"running","message":"two","elapsed_ms":0}` + "\n" +
+				`{"status":"stopped","message":"done","elapsed_ms":0}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, tt.termMode)
+			cfg.Message = "one"
+			cfg.StopMessage = "done"
+			cfg.JSONMode = tt.jsonMode
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+
+			testErrCheck(t, "spinner.Complete()", "", spinner.Complete("one"))
+
+			spinner.Message("two")
+			spinner.Render()
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_Complete_painter(t *testing.T) {
+	buf := &safeBuffer{}
+
+	cfg := testConfig(buf, ForceNoTTYMode|ForceDumbTerminalMode)
+	cfg.Message = ""
+	cfg.Frequency = time.Hour
+	cfg.StopMessage = "three"
+
+	spinner := newTestSpinner(t, cfg)
+
+	testErrCheck(t, "spinner.Complete()", ErrNotRunning.Error(), spinner.Complete("early"))
+
+	testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+	testErrCheck(t, "spinner.Complete()", "", spinner.Complete("one"))
+	testErrCheck(t, "spinner.Complete()", "", spinner.Complete("two"))
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	testErrCheck(t, "spinner.Complete()", ErrNotRunning.Error(), spinner.Complete("late"))
+
+	// the completed lines stay printed, with the running line painted
+	// after each of them
+	var lines []string
+
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if len(line) > 0 && !strings.HasPrefix(line, "y ") {
+			lines = append(lines, line)
+		}
+	}
+
+	if diff := cmp.Diff([]string{"v one\n", "v two\n", "v three\n"}, lines); diff != "" {
+		t.Fatalf("completed lines differ: (-want / +got)\n%s", diff)
+	}
+}
+
+// flushWriter is a Writer counting the calls to Flush()
+type flushWriter struct {
+	bytes.Buffer
+	writes  int
+	flushes int
+	err     error
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func (w *flushWriter) Flush() error {
+	w.flushes++
+	return w.err
+}
+
+// syncWriter is a Writer counting the calls to Sync(), which always fail
+type syncWriter struct {
+	bytes.Buffer
+	syncs int
+}
+
+func (w *syncWriter) Sync() error {
+	w.syncs++
+	return errors.New("sync failed")
+}
+
+func TestSpinner_flushAfterWrite(t *testing.T) {
+	run := func(t *testing.T, w io.Writer, flush bool) *Spinner {
+		t.Helper()
+
+		spinner, err := New(Config{
+			Writer:          w,
+			CharSet:         []string{"y"},
+			StopCharacter:   "v",
+			ShowCursor:      true,
+			FlushAfterWrite: flush,
+			MaxWriteErrors:  5,
+			TerminalMode:    termModeTTY,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+		spinner.Render()
+		spinner.Message("msg")
+		spinner.Render()
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		return spinner
+	}
+
+	t.Run("flush", func(t *testing.T) {
+		w := &flushWriter{}
+
+		run(t, w, true)
+
+		if w.writes != 3 {
+			t.Fatalf("w.writes = %d, want 3", w.writes)
+		}
+
+		if w.flushes != w.writes {
+			t.Fatalf("w.flushes = %d, want %d", w.flushes, w.writes)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		w := &flushWriter{}
+
+		run(t, w, false)
+
+		if w.flushes != 0 {
+			t.Fatalf("w.flushes = %d, want 0", w.flushes)
+		}
+	})
+
+	t.Run("flush_error", func(t *testing.T) {
+		w := &flushWriter{err: errors.New("flush failed")}
+
+		spinner := run(t, w, true)
+
+		testErrCheck(t, "spinner.LastError()", "flush failed", spinner.LastError())
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		w := &syncWriter{}
+
+		spinner := run(t, w, true)
+
+		if w.syncs != 3 {
+			t.Fatalf("w.syncs = %d, want 3", w.syncs)
+		}
+
+		if err := spinner.LastError(); err != nil {
+			t.Fatalf("spinner.LastError() = %v, want nil", err)
+		}
+
+		if want := "\r\033[K\ry\r\033[K\rymsg\r\033[K\rv\n"; w.String() != want {
+			t.Fatalf("output = %q, want %q", w.String(), want)
+		}
+	})
+}
+
+func Test_formatAmount(t *testing.T) {
+	tests := []struct {
+		n    int64
+		unit string
+		want string
+	}{
+		{12, "", "12"},
+		{12, "MB", "12MB"},
+		{100, " files", "100 files"},
+		{0, "bytes", "0B"},
+		{1023, "bytes", "1023B"},
+		{1024, "bytes", "1KB"},
+		{1536, "bytes", "1.5KB"},
+		{5 * 1024 * 1024, "bytes", "5MB"},
+		{3*1024*1024*1024 + 300*1024*1024, "bytes", "3.3GB"},
+		{math.MaxInt64, "bytes", "8EB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatAmount(tt.n, tt.unit); got != tt.want {
+			t.Fatalf("formatAmount(%d, %q) = %q, want %q", tt.n, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestSpinner_SetCurrent(t *testing.T) {
+	tests := []struct {
+		name        string
+		unit        string
+		showPercent bool
+		current     int64
+		total       int64
+		want        string
+	}{
+		{
+			name: "no_total",
+			unit: "MB",
+			want: "\r\033[K\ry msg",
+		},
+		{
+			name:    "unit",
+			unit:    "MB",
+			current: 12,
+			total:   100,
+			want:    "\r\033[K\ry msg 12MB/100MB",
+		},
+		{
+			name:        "unit_percent",
+			unit:        " files",
+			showPercent: true,
+			current:     3,
+			total:       4,
+			want:        "\r\033[K\ry msg 3 files/4 files 75%",
+		},
+		{
+			name:    "bytes",
+			unit:    "bytes",
+			current: 1536,
+			total:   10 * 1024 * 1024,
+			want:    "\r\033[K\ry msg 1.5KB/10MB",
+		},
+		{
+			name:        "past_total",
+			showPercent: true,
+			current:     5,
+			total:       4,
+			want:        "\r\033[K\ry msg 5/4 100%",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			cfg := testConfig(buf, termModeTTY)
+			cfg.StopCharacter = ""
+			cfg.StopMessage = ""
+			cfg.ProgressUnit = tt.unit
+			cfg.ShowPercent = tt.showPercent
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.SetTotal()", "", spinner.SetTotal(tt.total))
+			testErrCheck(t, "spinner.SetCurrent()", "", spinner.SetCurrent(tt.current))
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("negative", func(t *testing.T) {
+		spinner, err := New(Config{Frequency: time.Second})
+		testErrCheck(t, "New()", "", err)
+
+		testErrCheck(t, "spinner.SetTotal()", "total cannot be negative", spinner.SetTotal(-1))
+		testErrCheck(t, "spinner.SetCurrent()", "current cannot be negative", spinner.SetCurrent(-1))
+	})
+}
+
+func TestSpinner_stopLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		termMode TerminalMode
+		stop     func(s *Spinner) error
+		want     string
+	}{
+		{
+			name:     "stop",
+			termMode: termModeTTY,
+			stop:     (*Spinner).Stop,
+			want:     "✓ done",
 		},
 		{
-			name: "fail_no_char_no_msg_dumb_term",
-			spinner: &Spinner{
-				buffer:   &bytes.Buffer{},
-				mu:       &sync.Mutex{},
-				prefix:   "a",
-				suffix:   " ",
-				maxWidth: 1,
-				// TODO(theckman): verify
-				termMode:        ForceDumbTerminalMode,
-				stopFailColorFn: fmt.Sprintf,
-			},
-			want: "\r\r",
+			name:     "stop_fail",
+			termMode: termModeTTY,
+			stop:     (*Spinner).StopFail,
+			want:     "✗ failed",
 		},
 		{
-			name: "fail_colorall",
-			spinner: &Spinner{
-				buffer:   &bytes.Buffer{},
-				mu:       &sync.Mutex{},
-				prefix:   "a",
-				suffix:   " ",
-				maxWidth: 1,
-				stopFailColorFn: func(format string, a ...interface{}) string {
-					return fmt.Sprintf("fullColor: %s", fmt.Sprintf(format, a...))
-				},
-				stopFailChar: character{Value: "y", Size: 1},
-				stopFailMsg:  "stop",
-				colorAll:     true,
-				termMode:     termModeTTY,
-			},
-			want: "\r\033[K\rfullColor: ay stop\n",
+			name:     "stop_fallback",
+			termMode: ForceNoTTYMode | ForceDumbTerminalMode,
+			stop:     (*Spinner).Stop,
+			want:     "v done",
 		},
 		{
-			name: "fail_colorall_spinnerAtEnd",
-			spinner: &Spinner{
-				buffer:   &bytes.Buffer{},
-				mu:       &sync.Mutex{},
-				prefix:   " a",
-				suffix:   " ",
-				maxWidth: 1,
-				stopFailColorFn: func(format string, a ...interface{}) string {
-					return fmt.Sprintf("fullColor: %s", fmt.Sprintf(format, a...))
-				},
-				stopFailChar: character{Value: "y", Size: 1},
-				stopFailMsg:  "stop",
-				colorAll:     true,
-				spinnerAtEnd: true,
-				termMode:     termModeTTY,
+			name:     "stop_and_print",
+			termMode: termModeTTY,
+			stop: func(s *Spinner) error {
+				return s.StopAndPrint("\x1b[31mcustom\x1b[0m\n")
 			},
-			want: "\r\033[K\rfullColor: stop ay \n",
+			want: "custom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lines []string
+
+			cfg := testConfig(&bytes.Buffer{}, tt.termMode)
+			cfg.Message = ""
+			cfg.StopCharacter = "✓"
+			cfg.StopCharacterFallback = "v"
+			cfg.StopMessage = "done"
+			cfg.StopMessageURL = "https://example.org/log"
+			cfg.StopColors = []string{"fgGreen"}
+			cfg.StopFailCharacter = "✗"
+			cfg.StopFailMessage = "failed"
+			cfg.StopFailColors = []string{"fgRed"}
+			cfg.StopLogger = func(line string) { lines = append(lines, line) }
+
+			spinner := newTestSpinner(t, cfg)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+
+			testErrCheck(t, "stop()", "", tt.stop(spinner))
+
+			if diff := cmp.Diff([]string{tt.want}, lines); diff != "" {
+				t.Fatalf("logged lines differ: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_dumbEraseNewline(t *testing.T) {
+	tests := []struct {
+		name         string
+		eraseNewline bool
+		newline      string
+		want         string
+	}{
+		{
+			name: "carriage_return",
+			want: "\r\ry msg\r     \rlog\n\r\ry msg\r     \ry two\r     \rv stop\n",
 		},
 		{
-			name: "fail_colorall_no_char",
-			spinner: &Spinner{
-				buffer:   &bytes.Buffer{},
-				mu:       &sync.Mutex{},
-				prefix:   "a",
-				suffix:   " ",
-				maxWidth: 0,
-				stopFailColorFn: func(format string, a ...interface{}) string {
-					return fmt.Sprintf("fullColor: %s", fmt.Sprintf(format, a...))
-				},
-				stopFailChar: character{Value: "", Size: 0},
-				stopFailMsg:  "stop",
-				colorAll:     true,
-				termMode:     termModeTTY,
-			},
-			want: "\r\033[K\rfullColor: stop\n",
+			name:         "newline",
+			eraseNewline: true,
+			want:         "y msg\nlog\ny msg\ny two\nv stop\n",
+		},
+		{
+			name:         "custom_newline",
+			eraseNewline: true,
+			newline:      "\r\n",
+			want:         "y msg\r\nlog\r\ny msg\r\ny two\r\nv stop\r\n",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			buf := &bytes.Buffer{}
-			tt.spinner.writer = buf
 
-			tt.spinner.paintStop(tt.ok)
+			cfg := testConfig(buf, ForceTTYMode|ForceDumbTerminalMode)
+			cfg.Newline = tt.newline
+			cfg.DumbEraseNewline = tt.eraseNewline
 
-			got := buf.String()
+			spinner := newTestSpinner(t, cfg)
 
-			if diff := cmp.Diff(tt.want, got); diff != "" {
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+
+			testErrCheck(t, "spinner.LogMessage()", "", spinner.LogMessage("log"))
+
+			spinner.Message("two")
+			spinner.Render()
+
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
 				t.Fatalf("output differs: (-want / +got)\n%s", diff)
 			}
 		})
 	}
 }
 
-func Test_handleFrequencyUpdate(t *testing.T) {
+func TestSpinner_WaitRendered(t *testing.T) {
+	newSpinner := func(t *testing.T, cfg Config) *Spinner {
+		t.Helper()
+
+		cfg.CharSet = []string{"y"}
+		cfg.ShowCursor = true
+
+		if cfg.Writer == nil {
+			cfg.Writer = &safeBuffer{}
+		}
+
+		if cfg.TerminalMode == 0 {
+			cfg.TerminalMode = termModeTTY
+		}
+
+		spinner, err := New(cfg)
+		testErrCheck(t, "New()", "", err)
+
+		return spinner
+	}
+
+	t.Run("not_running", func(t *testing.T) {
+		spinner := newSpinner(t, Config{Frequency: time.Second})
+
+		testErrCheck(t, "spinner.WaitRendered()", "timeout must be greater than 0", spinner.WaitRendered(0))
+		testErrCheck(t, "spinner.WaitRendered()", ErrNotRunning.Error(), spinner.WaitRendered(time.Second))
+	})
+
+	t.Run("first_frame", func(t *testing.T) {
+		buf := &safeBuffer{}
+
+		spinner := newSpinner(t, Config{Frequency: time.Hour, Writer: buf})
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		testErrCheck(t, "spinner.WaitRendered()", "", spinner.WaitRendered(time.Second))
+
+		if got := buf.String(); !strings.Contains(got, "y") {
+			t.Fatalf("output = %q, want it to contain the first frame", got)
+		}
+
+		// already rendered, so it returns immediately
+		testErrCheck(t, "spinner.WaitRendered()", "", spinner.WaitRendered(time.Millisecond))
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		testErrCheck(t, "spinner.WaitRendered()", ErrNotRunning.Error(), spinner.WaitRendered(time.Second))
+	})
+
+	t.Run("initial_delay", func(t *testing.T) {
+		spinner := newSpinner(t, Config{Frequency: time.Hour, InitialDelay: time.Hour})
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		err := spinner.WaitRendered(10 * time.Millisecond)
+		testErrCheck(t, "spinner.WaitRendered()", "timed out after 10ms waiting for the spinner to render a frame", err)
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+	})
+
+	t.Run("stopped_before_rendering", func(t *testing.T) {
+		spinner := newSpinner(t, Config{Frequency: time.Hour, InitialDelay: time.Hour})
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		errCh := make(chan error, 1)
+
+		go func() { errCh <- spinner.WaitRendered(time.Minute) }()
+
+		time.Sleep(5 * time.Millisecond)
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		// if the goroutine was slow to start, the spinner was already stopped
+		if err := <-errCh; err != ErrNotRunning {
+			testErrCheck(t, "spinner.WaitRendered()", "spinner stopped before rendering a frame", err)
+		}
+	})
+
+	t.Run("manual", func(t *testing.T) {
+		spinner := newSpinner(t, Config{})
+
+		testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+		testErrCheck(t, "spinner.WaitRendered()", "timed out after 1ms waiting for the spinner to render a frame", spinner.WaitRendered(time.Millisecond))
+
+		spinner.Render()
+
+		testErrCheck(t, "spinner.WaitRendered()", "", spinner.WaitRendered(time.Millisecond))
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+	})
+}
+
+func Test_trimTrailingSpace(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "empty", line: "", want: ""},
+		{name: "no_space", line: "y msg", want: "y msg"},
+		{name: "trailing", line: "y msg \t ", want: "y msg"},
+		{name: "inner", line: "y   msg  ", want: "y   msg"},
+		{name: "colored", line: "\x1b[32my\x1b[0m\x1b[31m \x1b[0m", want: "\x1b[32my\x1b[0m\x1b[31m\x1b[0m"},
+		{name: "colored_all", line: "\x1b[32my msg  \x1b[0m", want: "\x1b[32my msg\x1b[0m"},
+		{name: "only_space", line: "   ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimTrailingSpace(tt.line); got != tt.want {
+				t.Fatalf("trimTrailingSpace(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpinner_trimTrailingSpace(t *testing.T) {
 	tests := []struct {
 		name         string
-		newFrequency time.Duration
-		lastTickAgo  time.Duration
-		shouldTick   time.Duration
+		trim         bool
+		spinnerAtEnd bool
+		termMode     TerminalMode
+		want         string
 	}{
 		{
-			name:         "moreTime",
-			newFrequency: 200 * time.Millisecond,
-			lastTickAgo:  100 * time.Millisecond,
-			shouldTick:   (100 * time.Millisecond) + (500 * time.Microsecond),
+			name:     "disabled",
+			termMode: termModeTTY,
+			want:     "\r\033[K\r y  \r\033[K\r •  msg\r\033[K\r v  \n",
 		},
 		{
-			name:         "lessTime",
-			newFrequency: 100 * time.Millisecond,
-			lastTickAgo:  200 * time.Millisecond,
-			shouldTick:   100 * time.Microsecond,
+			name:     "enabled",
+			trim:     true,
+			termMode: termModeTTY,
+			want:     "\r\033[K\r y\r\033[K\r •  msg\r\033[K\r v\n",
+		},
+		{
+			name:         "spinner_at_end_disabled",
+			spinnerAtEnd: true,
+			termMode:     termModeTTY,
+			want:         "\r\033[K\r y  \r\033[K\rmsg •  \r\033[K\r v  \n",
+		},
+		{
+			name:         "spinner_at_end_enabled",
+			trim:         true,
+			spinnerAtEnd: true,
+			termMode:     termModeTTY,
+			want:         "\r\033[K\r y\r\033[K\rmsg •\r\033[K\r v\n",
+		},
+		{
+			name:     "dumb_terminal_enabled",
+			trim:     true,
+			termMode: ForceTTYMode | ForceDumbTerminalMode,
+			want:     "\r\r y\r  \r •  msg\r         \r v\n",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			timer := time.NewTimer(0)
-			lastTick := time.Now().Add(-tt.lastTickAgo)
+			buf := &bytes.Buffer{}
 
-			time.Sleep(10 * time.Microsecond)
+			spinner, err := New(Config{
+				Writer:            buf,
+				CharSet:           []string{"y", "• "},
+				Prefix:            " ",
+				Suffix:            " ",
+				StopCharacter:     "v",
+				ShowCursor:        true,
+				SpinnerAtEnd:      tt.spinnerAtEnd,
+				TrimTrailingSpace: tt.trim,
+				TerminalMode:      tt.termMode,
+			})
+			testErrCheck(t, "New()", "", err)
 
-			handleFrequencyUpdate(tt.newFrequency, timer, lastTick)
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
 
-			testTimer := time.NewTimer(tt.shouldTick)
+			spinner.Render()
+			spinner.Message("msg")
+			spinner.Render()
 
-			select {
-			case <-timer.C:
-				testTimer.Stop()
-			case <-testTimer.C:
-				timer.Stop()
-				t.Fatal("timer didn't fire when expected")
+			testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
 			}
 		})
 	}
 }
 
-func Test_setToCharSlice(t *testing.T) {
+func Test_marqueeFrames(t *testing.T) {
 	tests := []struct {
-		name      string
-		input     []string
-		wantNil   bool
-		wantChars []character
-		wantSize  int
+		name   string
+		text   string
+		window int
+		want   []string
 	}{
 		{
-			name:    "nil",
-			wantNil: true,
+			name:   "empty",
+			window: 3,
 		},
 		{
-			name:      "full",
-			input:     []string{"x", "zzz"},
-			wantChars: []character{{Value: "x", Size: 1}, {Value: "zzz", Size: 3}},
-			wantSize:  3,
+			name:   "fits_window",
+			text:   "abc",
+			window: 3,
+			want:   []string{"abc"},
+		},
+		{
+			name:   "scrolls",
+			text:   "abcde",
+			window: 3,
+			want:   []string{"abc", "bcd", "cde", "de ", "e a", " ab"},
+		},
+		{
+			name:   "combining_marks",
+			text:   "e\u0301fgh",
+			window: 2,
+			want:   []string{"e\u0301f", "fg", "gh", "h ", " e\u0301"},
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			chars, size := setToCharSlice(tt.input)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, marqueeFrames(tt.text, tt.window)); diff != "" {
+				t.Fatalf("marqueeFrames() differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSpinner_Marquee(t *testing.T) {
+	t.Run("manual", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		cfg := testConfig(buf, termModeTTY)
+		cfg.StopCharacter = ""
+		cfg.StopMessage = ""
+
+		spinner := newTestSpinner(t, cfg)
+
+		spinner.Marquee("abcd", 3, time.Second)
+
+		testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+		for i := 0; i < 6; i++ {
+			spinner.Render()
+		}
+
+		// stopping the marquee renders the Message again
+		spinner.Marquee("abcd", 0, time.Second)
+		spinner.Render()
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		// each frame shifts the window by one character, wrapping around
+		want := "\r\033[K\ry abc\r\033[K\ry bcd\r\033[K\ry cd \r\033[K\ry d a\r\033[K\ry  ab\r\033[K\ry abc\r\033[K\ry msg\r\033[K\r"
+
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		}
+	})
+
+	t.Run("painter", func(t *testing.T) {
+		buf := &safeBuffer{}
+
+		spinner, err := New(Config{
+			Writer:       buf,
+			Frequency:    time.Hour,
+			CharSet:      []string{"y"},
+			Suffix:       " ",
+			ShowCursor:   true,
+			TerminalMode: termModeTTY,
+		})
+		testErrCheck(t, "New()", "", err)
+
+		spinner.Marquee("abcd", 3, 5*time.Millisecond)
+
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+
+		time.Sleep(100 * time.Millisecond)
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		// the marquee advances on its own cadence, as the spinner's Frequency
+		// is too long to animate anything
+		got := buf.String()
+
+		for _, frame := range []string{"y abc", "y bcd", "y cd ", "y d a", "y  ab"} {
+			if !strings.Contains(got, "\r\033[K\r"+frame) {
+				t.Fatalf("output %q does not contain frame %q", got, frame)
+			}
+		}
+	})
+}
+
+func TestSpinner_AnimateMessage(t *testing.T) {
+	t.Run("manual", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		cfg := testConfig(buf, termModeTTY)
+		cfg.StopMessage = "done"
+
+		spinner := newTestSpinner(t, cfg)
+
+		spinner.AnimateMessage([]string{"load", "load.", "load.."}, time.Second)
+
+		testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+		for i := 0; i < 4; i++ {
+			spinner.Render()
+		}
+
+		// stopping the animation renders the Message again
+		spinner.AnimateMessage(nil, time.Second)
+		spinner.Render()
+
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+		want := "\r\033[K\ry load\r\033[K\ry load.\r\033[K\ry load..\r\033[K\ry load\r\033[K\ry msg\r\033[K\rv done\n"
+
+		if diff := cmp.Diff(want, buf.String()); diff != "" {
+			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		}
+	})
+
+	t.Run("painter", func(t *testing.T) {
+		buf := &safeBuffer{}
+
+		spinner, err := New(Config{
+			Writer:       buf,
+			Frequency:    time.Hour, // the spinner character never animates
+			CharSet:      []string{"y"},
+			Suffix:       " ",
+			ShowCursor:   true,
+			TerminalMode: ForceTTYMode | ForceDumbTerminalMode | ForceTestMode,
+		})
+		testErrCheck(t, "New()", "", err)
 
-			if size != tt.wantSize {
-				t.Errorf("size = %d, want %d", size, tt.wantSize)
-			}
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
 
-			if tt.wantNil && chars != nil {
-				t.Fatal("chars not nil")
-			}
+		spinner.AnimateMessage([]string{"a", "b", "c"}, 5*time.Millisecond)
 
-			for i := range chars {
-				if x, y := chars[i], tt.wantChars[i]; x != y {
-					t.Errorf("chars[%d] = %#v, want %#v", i, x, y)
-				}
+		deadline := time.Now().Add(5 * time.Second)
+
+		for strings.Count(buf.String(), "\n") < 7 {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for the message to animate, output = %q", buf.String())
 			}
-		})
-	}
-}
 
-func TestSpinner_painter(t *testing.T) {
-	t.Run("animated", func(t *testing.T) {
-		if testing.Short() {
-			t.Skip("skipping test in short mode.")
+			time.Sleep(time.Millisecond)
 		}
 
-		const want = "\r\033[K\ray msg\r\033[K\ray othermsg\r\033[K\raz msg\r\033[K\ray msg\r\x1b[K\rav stop\n"
-
-		buf := &bytes.Buffer{}
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
 
-		cancel, done, dataUpdate, pause := make(chan struct{}), make(chan struct{}), make(chan struct{}), make(chan struct{})
-		frequencyUpdate := make(chan time.Duration, 1)
+		// the first frame may have been painted before the animation was set,
+		// and the data update for setting it may or may not have been
+		// painted, so start from the first frame with the second message
+		out := buf.String()
 
-		spinner := &Spinner{
-			buffer:            &bytes.Buffer{},
-			mu:                &sync.Mutex{},
-			writer:            buf,
-			prefix:            "a",
-			message:           "msg",
-			suffix:            " ",
-			maxWidth:          1,
-			colorFn:           fmt.Sprintf,
-			chars:             []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
-			stopColorFn:       fmt.Sprintf,
-			stopMsg:           "stop",
-			stopChar:          character{Value: "v", Size: 1},
-			frequency:         2000 * time.Millisecond,
-			cancelCh:          cancel,
-			doneCh:            done,
-			dataUpdateCh:      dataUpdate,
-			frequencyUpdateCh: frequencyUpdate,
-			termMode:          termModeTTY,
+		i := strings.Index(out, "y b\n")
+		if want := "y b\ny c\ny a\ny b\n"; i < 0 || !strings.HasPrefix(out[i:], want) {
+			t.Fatalf("output = %q, want it to contain %q", out, want)
 		}
+	})
 
-		go spinner.painter(cancel, dataUpdate, pause, done, frequencyUpdate)
-
-		time.Sleep(500 * time.Millisecond)
+	t.Run("no_tty", func(t *testing.T) {
+		buf := &safeBuffer{}
 
-		spinner.mu.Lock()
+		cfg := testConfig(buf, ForceNoTTYMode|ForceDumbTerminalMode)
+		cfg.Message = ""
+		cfg.StopCharacter = ""
+		cfg.StopMessage = "done"
 
-		spinner.message = "othermsg"
-		spinner.dataUpdateCh <- struct{}{}
+		spinner := newTestSpinner(t, cfg)
 
-		spinner.mu.Unlock()
+		spinner.AnimateMessage([]string{"a", "b"}, time.Millisecond)
 
-		time.Sleep(500 * time.Millisecond)
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
+		testErrCheck(t, "spinner.WaitRendered()", "", spinner.WaitRendered(time.Second))
 
-		spinner.unpauseCh, spinner.unpausedCh = make(chan struct{}), make(chan struct{})
-		pause <- struct{}{}
+		time.Sleep(20 * time.Millisecond)
 
-		close(spinner.unpauseCh)
-		_, ok := <-spinner.unpausedCh
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
 
-		if ok {
-			t.Fatal("unexpected successful channel receive")
+		if got, want := buf.String(), "y a\ndone\n"; got != want {
+			t.Fatalf("output = %q, want %q", got, want)
 		}
+	})
+}
 
-		spinner.unpauseCh = nil
-		spinner.unpausedCh = nil
+func TestSpinner_AddWriter(t *testing.T) {
+	const want = "\r\033[K\ry msg\r\033[K\rv done\n"
 
-		spinner.mu.Lock()
+	newSpinner := func(t *testing.T, w io.Writer) *Spinner {
+		t.Helper()
 
-		spinner.message = "msg"
-		spinner.frequency = 1000 * time.Millisecond
-		frequencyUpdate <- 1000 * time.Millisecond
+		cfg := testConfig(w, termModeTTY)
+		cfg.Frequency = time.Millisecond
+		cfg.StopMessage = "done"
+		cfg.MaxWriteErrors = 5
 
-		spinner.mu.Unlock()
+		spinner := newTestSpinner(t, cfg)
 
-		time.Sleep(1200 * time.Millisecond)
+		return spinner
+	}
 
-		cancel <- struct{}{}
+	run := func(t *testing.T, spinner *Spinner) {
+		t.Helper()
 
-		<-done
+		testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
 
-		got := buf.String()
+		spinner.Render()
 
-		if diff := cmp.Diff(want, got); diff != "" {
-			t.Fatalf("output differs: (-want / +got)\n%s", diff)
-		}
-	})
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+	}
 
-	t.Run("no_tty", func(t *testing.T) {
-		const want = "ay msg\naz othermsg\nay msg\naz msg\nav stop\n"
+	t.Run("all_writers", func(t *testing.T) {
+		primary, w1, w2 := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
 
-		buf := &bytes.Buffer{}
+		spinner := newSpinner(t, primary)
+		spinner.AddWriter(w1)
+		spinner.AddWriter(w2)
 
-		cancel, done, dataUpdate, pause := make(chan struct{}), make(chan struct{}), make(chan struct{}), make(chan struct{})
-		frequencyUpdate := make(chan time.Duration, 1)
+		run(t, spinner)
 
-		spinner := &Spinner{
-			buffer:            &bytes.Buffer{},
-			mu:                &sync.Mutex{},
-			writer:            buf,
-			prefix:            "a",
-			message:           "msg",
-			suffix:            " ",
-			maxWidth:          1,
-			colorFn:           fmt.Sprintf,
-			chars:             []character{{Value: "y", Size: 1}, {Value: "z", Size: 1}},
-			stopColorFn:       fmt.Sprintf,
-			stopMsg:           "stop",
-			stopChar:          character{Value: "v", Size: 1},
-			frequency:         time.Duration(math.MaxInt64),
-			cancelCh:          cancel,
-			doneCh:            done,
-			dataUpdateCh:      dataUpdate,
-			frequencyUpdateCh: frequencyUpdate,
-			termMode:          ForceDumbTerminalMode | ForceNoTTYMode,
+		for name, buf := range map[string]*bytes.Buffer{"primary": primary, "w1": w1, "w2": w2} {
+			if diff := cmp.Diff(want, buf.String()); diff != "" {
+				t.Fatalf("%s output differs: (-want / +got)\n%s", name, diff)
+			}
 		}
+	})
 
-		go spinner.painter(cancel, dataUpdate, pause, done, frequencyUpdate)
+	t.Run("failing_added_writer", func(t *testing.T) {
+		primary, w := &bytes.Buffer{}, &bytes.Buffer{}
 
-		time.Sleep(100 * time.Millisecond)
+		spinner := newSpinner(t, primary)
+		spinner.AddWriter(&flakyWriter{fail: true})
+		spinner.AddWriter(w)
 
-		spinner.mu.Lock()
+		run(t, spinner)
 
-		spinner.message = "othermsg"
-		spinner.dataUpdateCh <- struct{}{}
+		if err := spinner.LastError(); err != nil {
+			t.Fatalf("spinner.LastError() = %v, want nil", err)
+		}
 
-		spinner.mu.Unlock()
+		for name, buf := range map[string]*bytes.Buffer{"primary": primary, "w": w} {
+			if diff := cmp.Diff(want, buf.String()); diff != "" {
+				t.Fatalf("%s output differs: (-want / +got)\n%s", name, diff)
+			}
+		}
+	})
 
-		time.Sleep(100 * time.Millisecond)
+	t.Run("failing_writer", func(t *testing.T) {
+		w := &bytes.Buffer{}
 
-		spinner.unpauseCh, spinner.unpausedCh = make(chan struct{}), make(chan struct{})
-		pause <- struct{}{}
+		spinner := newSpinner(t, &flakyWriter{fail: true})
+		spinner.AddWriter(w)
 
-		close(spinner.unpauseCh)
-		_, ok := <-spinner.unpausedCh
+		run(t, spinner)
 
-		if ok {
-			t.Fatal("unexpected successful channel receive")
+		if spinner.LastError() == nil {
+			t.Fatal("spinner.LastError() = nil, want an error")
 		}
 
-		spinner.unpauseCh = nil
-		spinner.unpausedCh = nil
+		if diff := cmp.Diff(want, w.String()); diff != "" {
+			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		}
+	})
 
-		spinner.mu.Lock()
+	t.Run("while_running", func(t *testing.T) {
+		w := &safeBuffer{}
 
-		spinner.message = "msg"
-		spinner.dataUpdateCh <- struct{}{}
+		spinner := newSpinner(t, &safeBuffer{})
 
-		spinner.mu.Unlock()
+		testErrCheck(t, "spinner.Start()", "", spinner.Start())
 
-		time.Sleep(100 * time.Millisecond)
+		spinner.AddWriter(w)
 
-		spinner.mu.Lock()
+		testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
 
-		spinner.message = "msg"
-		spinner.dataUpdateCh <- struct{}{}
+		if got := w.String(); !strings.HasSuffix(got, "\r\033[K\rv done\n") {
+			t.Fatalf("output = %q, want it to end with the stop line", got)
+		}
+	})
 
-		spinner.mu.Unlock()
+	t.Run("plain", func(t *testing.T) {
+		defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+		color.NoColor = false
 
-		time.Sleep(100 * time.Millisecond)
+		primary, w := &bytes.Buffer{}, &bytes.Buffer{}
 
-		cancel <- struct{}{}
+		spinner := newSpinner(t, primary)
+		testErrCheck(t, "spinner.Colors()", "", spinner.Colors("fgRed"))
+		spinner.AddPlainWriter(w)
 
-		<-done
+		run(t, spinner)
 
-		got := buf.String()
+		if !strings.Contains(primary.String(), "\033[31m") {
+			t.Fatalf("primary output = %q, want it to be colored", primary.String())
+		}
 
-		if diff := cmp.Diff(want, got); diff != "" {
-			t.Fatalf("output differs: (-want / +got)\n%s", diff)
+		if diff := cmp.Diff("y msg\nv done\n", w.String()); diff != "" {
+			t.Fatalf("plain output differs: (-want / +got)\n%s", diff)
 		}
 	})
 }
 
-func TestSpinnerStatus_String(t *testing.T) {
+func Test_plainOutput(t *testing.T) {
 	tests := []struct {
 		name string
-		ss   SpinnerStatus
+		in   string
 		want string
 	}{
 		{
-			name: "stopped",
-			ss:   SpinnerStopped,
-			want: "stopped",
+			name: "smart_frames",
+			in:   "\r\033[K\r\033[31my\033[0m msg\r\033[K\rv done\n",
+			want: "y msg\nv done\n",
 		},
 		{
-			name: "starting",
-			ss:   SpinnerStarting,
-			want: "starting",
+			name: "dumb_erase",
+			in:   "\r     \ry msg",
+			want: "y msg\n",
 		},
 		{
-			name: "running",
-			ss:   SpinnerRunning,
-			want: "running",
+			name: "cursor_and_osc",
+			in:   "\033[?25l\033]9;4;1;50\007\033]8;;https://example.com\033\\y msg\033]8;;\033\\\033[1A\0337\0338",
+			want: "y msg\n",
 		},
 		{
-			name: "stopping",
-			ss:   SpinnerStopping,
-			want: "stopping",
+			name: "crlf",
+			in:   "log\r\n",
+			want: "log\n",
 		},
 		{
-			name: "pausing",
-			ss:   SpinnerPausing,
-			want: "pausing",
+			name: "only_escapes",
+			in:   "\r\033[K\r",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, string(plainOutput([]byte(tt.in)))); diff != "" {
+				t.Fatalf("plainOutput() differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNew_ttyDetection(t *testing.T) {
+	defer func(fn func(uintptr) bool) { isTerminal = fn }(isTerminal)
+
+	// only stderr is a terminal
+	isTerminal = func(fd uintptr) bool { return fd == os.Stderr.Fd() }
+
+	tests := []struct {
+		name    string
+		writer  io.Writer
+		termEnv string
+		want    TerminalMode
+	}{
+		{
+			name:    "not_a_file",
+			writer:  &bytes.Buffer{},
+			termEnv: "xterm",
+			want:    ForceNoTTYMode | ForceDumbTerminalMode,
 		},
 		{
-			name: "paused",
-			ss:   SpinnerPaused,
-			want: "paused",
+			name:    "stdout_default",
+			termEnv: "xterm",
+			want:    ForceNoTTYMode | ForceDumbTerminalMode,
 		},
 		{
-			name: "unpausing",
-			ss:   SpinnerUnpausing,
-			want: "unpausing",
+			name:    "stdout",
+			writer:  os.Stdout,
+			termEnv: "xterm",
+			want:    ForceNoTTYMode | ForceDumbTerminalMode,
 		},
 		{
-			name: "unknown",
-			ss:   42,
-			want: "unknown (42)",
+			name:    "stderr",
+			writer:  os.Stderr,
+			termEnv: "xterm",
+			want:    ForceTTYMode | ForceSmartTerminalMode,
+		},
+		{
+			name:    "stderr_dumb",
+			writer:  os.Stderr,
+			termEnv: "dumb",
+			want:    ForceTTYMode | ForceDumbTerminalMode,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.ss.String(); got != tt.want {
-				t.Errorf("got = %#v, got %#v", got, tt.want)
+			spinner, err := New(Config{
+				Frequency: time.Second,
+				Writer:    tt.writer,
+				TermEnv:   tt.termEnv,
+			})
+			testErrCheck(t, "New()", "", err)
+
+			if spinner.termMode != tt.want {
+				t.Fatalf("spinner.termMode = %d, want %d", spinner.termMode, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpinner_SetMessageWidth(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	spinner, err := New(Config{
+		Writer:       buf,
+		CharSet:      []string{"y"},
+		Message:      "msg",
+		MessageWidth: 5,
+		SpinnerAtEnd: true,
+		ShowCursor:   true,
+		TerminalMode: termModeTTY,
+	})
+	testErrCheck(t, "New()", "", err)
+
+	testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+	spinner.Render()
+	spinner.Message("message")
+	spinner.Render()
+
+	testErrCheck(t, "spinner.SetMessageWidth()", "width cannot be negative", spinner.SetMessageWidth(-1))
+	testErrCheck(t, "spinner.SetMessageWidth()", "", spinner.SetMessageWidth(0))
+
+	spinner.Render()
+
+	testErrCheck(t, "spinner.Stop()", "", spinner.Stop())
+
+	want := "\r\033[K\rmsg  y" +
+		"\r\033[K\rmess…y" +
+		"\r\033[K\rmessagey" +
+		"\r\033[K\r"
+
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Fatalf("output differs: (-want / +got)\n%s", diff)
+	}
+}
+
+func TestSpinner_SetMessageWidthFromTerm(t *testing.T) {
+	defer func(fn func(uintptr) (int, error)) { terminalWidth = fn }(terminalWidth)
+
+	tests := []struct {
+		name     string
+		spinner  *Spinner
+		fraction float64
+		cols     int
+		colsErr  error
+		want     int
+		err      string
+	}{
+		{
+			name:     "half",
+			spinner:  &Spinner{mu: &sync.Mutex{}, hasFd: true, termMode: termModeTTY},
+			fraction: 0.5,
+			cols:     80,
+			want:     40,
+		},
+		{
+			name:     "full",
+			spinner:  &Spinner{mu: &sync.Mutex{}, hasFd: true, termMode: termModeTTY},
+			fraction: 1,
+			cols:     80,
+			want:     80,
+		},
+		{
+			name:     "at_least_one_column",
+			spinner:  &Spinner{mu: &sync.Mutex{}, hasFd: true, termMode: termModeTTY},
+			fraction: 0.01,
+			cols:     10,
+			want:     1,
+		},
+		{
+			name:     "zero_fraction",
+			spinner:  &Spinner{mu: &sync.Mutex{}, hasFd: true, termMode: termModeTTY},
+			fraction: 0,
+			cols:     80,
+			err:      "fraction must be greater than 0 and at most 1",
+		},
+		{
+			name:     "fraction_too_large",
+			spinner:  &Spinner{mu: &sync.Mutex{}, hasFd: true, termMode: termModeTTY},
+			fraction: 1.5,
+			cols:     80,
+			err:      "fraction must be greater than 0 and at most 1",
+		},
+		{
+			name:     "fraction_NaN",
+			spinner:  &Spinner{mu: &sync.Mutex{}, hasFd: true, termMode: termModeTTY},
+			fraction: math.NaN(),
+			cols:     80,
+			err:      "fraction must be greater than 0 and at most 1",
+		},
+		{
+			name:     "not_a_tty",
+			spinner:  &Spinner{mu: &sync.Mutex{}, hasFd: true, termMode: ForceNoTTYMode | ForceDumbTerminalMode},
+			fraction: 0.5,
+			cols:     80,
+			err:      "spinner is not writing to a TTY",
+		},
+		{
+			name:     "no_fd",
+			spinner:  &Spinner{mu: &sync.Mutex{}, termMode: termModeTTY},
+			fraction: 0.5,
+			cols:     80,
+			err:      "spinner is not writing to a TTY",
+		},
+		{
+			name:     "width_error",
+			spinner:  &Spinner{mu: &sync.Mutex{}, hasFd: true, termMode: termModeTTY},
+			fraction: 0.5,
+			colsErr:  errors.New("inappropriate ioctl for device"),
+			err:      "failed to get terminal width: inappropriate ioctl for device",
+		},
+		{
+			name:     "width_unknown",
+			spinner:  &Spinner{mu: &sync.Mutex{}, hasFd: true, termMode: termModeTTY},
+			fraction: 0.5,
+			cols:     0,
+			err:      "terminal width is unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terminalWidth = func(uintptr) (int, error) { return tt.cols, tt.colsErr }
+
+			err := tt.spinner.SetMessageWidthFromTerm(tt.fraction)
+			if cont := testErrCheck(t, "SetMessageWidthFromTerm()", tt.err, err); !cont {
+				return
+			}
+
+			if tt.spinner.messageWidth != tt.want {
+				t.Fatalf("tt.spinner.messageWidth = %d, want %d", tt.spinner.messageWidth, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_ttyDetector(t *testing.T) {
+	defer func(fn func(uintptr) bool) { isTerminal = fn }(isTerminal)
+
+	// only stderr is a terminal
+	isTerminal = func(fd uintptr) bool { return fd == os.Stderr.Fd() }
+
+	tests := []struct {
+		name     string
+		writer   io.Writer
+		detector func() bool
+		termEnv  string
+		termMode TerminalMode
+		want     TerminalMode
+	}{
+		{
+			name:     "tty_without_fd",
+			writer:   &bytes.Buffer{},
+			detector: func() bool { return true },
+			termEnv:  "xterm",
+			want:     ForceTTYMode | ForceSmartTerminalMode,
+		},
+		{
+			name:     "tty_dumb",
+			writer:   &bytes.Buffer{},
+			detector: func() bool { return true },
+			termEnv:  "dumb",
+			want:     ForceTTYMode | ForceDumbTerminalMode,
+		},
+		{
+			name:     "not_a_tty",
+			writer:   os.Stderr,
+			detector: func() bool { return false },
+			termEnv:  "xterm",
+			want:     ForceNoTTYMode | ForceDumbTerminalMode,
+		},
+		{
+			name:     "ignored_when_forced",
+			writer:   &bytes.Buffer{},
+			detector: func() bool { return false },
+			termEnv:  "xterm",
+			termMode: termModeTTY,
+			want:     termModeTTY,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spinner, err := New(Config{
+				Frequency:    time.Second,
+				Writer:       tt.writer,
+				TermEnv:      tt.termEnv,
+				TTYDetector:  tt.detector,
+				TerminalMode: tt.termMode,
+			})
+			testErrCheck(t, "New()", "", err)
+
+			if spinner.termMode != tt.want {
+				t.Fatalf("spinner.termMode = %d, want %d", spinner.termMode, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,105 @@
+package yacspin
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// templatePlaceholders are the placeholders supported within a template, like
+// the one provided via the Template field of the Config struct.
+var templatePlaceholders = map[string]struct{}{
+	"label":     {},
+	"spinner":   {},
+	"message":   {},
+	"prefix":    {},
+	"suffix":    {},
+	"fraction":  {},
+	"percent":   {},
+	"elapsed":   {},
+	"remaining": {},
+}
+
+var templatePlaceholderRegexp = regexp.MustCompile(`\{([A-Za-z_]+)\}`)
+
+// validateTemplate makes sure the template only contains known placeholders
+func validateTemplate(tmpl string) error {
+	for _, m := range templatePlaceholderRegexp.FindAllStringSubmatch(tmpl, -1) {
+		if _, ok := templatePlaceholders[m[1]]; !ok {
+			return fmt.Errorf("template placeholder %s is not valid", m[0])
+		}
+	}
+
+	return nil
+}
+
+// renderTemplate renders the line using the template in the paintOp, instead
+// of the default layout
+func renderTemplate(op paintOp) string {
+	c := op.paddedChar()
+	suf := op.suffix
+	msg := op.message
+	pct := op.percent
+
+	if !op.colorAll {
+		c = op.segments.colorChar(op.colorFn, c)
+		suf = op.segments.colorSuffix(op.suffixColorFn, suf)
+		msg = op.segments.colorMessage(op.messageColorFn, op.messageState, msg)
+		pct = colorSegment(op.percentColorFn, pct)
+	}
+
+	r := strings.NewReplacer(
+		"{label}", op.label,
+		"{spinner}", c,
+		"{message}", msg,
+		"{prefix}", op.prefix,
+		"{suffix}", suf,
+		"{fraction}", op.fraction,
+		"{percent}", pct,
+		"{elapsed}", op.elapsed,
+		"{remaining}", op.remaining,
+	)
+
+	output := r.Replace(op.template)
+
+	if op.colorAll {
+		return op.colorFn("%s", output)
+	}
+
+	return output
+}
+
+// Template updates the template used to render the line, overriding the
+// default layout. See the Template field of the Config struct for the supported
+// placeholders. An empty template restores the default layout. An error is
+// returned if the template contains an unknown placeholder.
+func (s *Spinner) Template(tmpl string) error {
+	if err := validateTemplate(tmpl); err != nil {
+		return fmt.Errorf("template is invalid: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.template = tmpl
+
+	s.notifyDataChange()
+
+	return nil
+}
+
+// LoadTemplateFile reads the template from the file at path, and uses it to
+// render the line like the Template() method does. A trailing newline in the
+// file is ignored. This can be called while the spinner is running, which
+// allows theming the spinner without recompiling.
+func (s *Spinner) LoadTemplateFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read template file: %w", err)
+	}
+
+	tmpl := strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r")
+
+	return s.Template(tmpl)
+}
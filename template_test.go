@@ -0,0 +1,215 @@
+package yacspin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_validateTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		err  string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "all_placeholders",
+			tmpl: "{prefix}[{spinner}]{suffix} {message} {fraction} {percent} {elapsed}",
+		},
+		{
+			name: "literal_braces",
+			tmpl: "{ {spinner} } {} {42}",
+		},
+		{
+			name: "unknown_placeholder",
+			tmpl: "[{spinner}] {msg}",
+			err:  "template placeholder {msg} is not valid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testErrCheck(t, "validateTemplate()", tt.err, validateTemplate(tt.tmpl))
+		})
+	}
+}
+
+func Test_renderTemplate(t *testing.T) {
+	tag := func(format string, a ...interface{}) string { return "<" + fmt.Sprintf(format, a...) + ">" }
+
+	tests := []struct {
+		name string
+		op   paintOp
+		want string
+	}{
+		{
+			name: "bracket",
+			op: paintOp{
+				template: "[{spinner}] {message}",
+				maxWidth: 1,
+				char:     character{Value: "x", Size: 1},
+				message:  "msg",
+				colorFn:  fmt.Sprintf,
+			},
+			want: "[x] msg",
+		},
+		{
+			name: "padded_char",
+			op: paintOp{
+				template: "[{spinner}] {message}",
+				maxWidth: 3,
+				char:     character{Value: "x", Size: 1},
+				message:  "msg",
+				colorFn:  fmt.Sprintf,
+			},
+			want: "[x  ] msg",
+		},
+		{
+			name: "all_placeholders",
+			op: paintOp{
+				template:  "{prefix}{spinner}{suffix}: {message} ({fraction}, {percent}, {elapsed}, {remaining})",
+				maxWidth:  1,
+				char:      character{Value: "x", Size: 1},
+				prefix:    "p ",
+				suffix:    " s",
+				message:   "msg",
+				fraction:  "42MB/100MB",
+				percent:   "42%",
+				elapsed:   "3s",
+				remaining: "7s",
+				colorFn:   fmt.Sprintf,
+			},
+			want: "p x s: msg (42MB/100MB, 42%, 3s, 7s)",
+		},
+		{
+			name: "colors",
+			op: paintOp{
+				template:      "[{spinner}]{suffix} {message}",
+				maxWidth:      1,
+				char:          character{Value: "x", Size: 1},
+				suffix:        " s",
+				message:       "msg",
+				colorFn:       tag,
+				suffixColorFn: tag,
+			},
+			want: "[<x>]< s> msg",
+		},
+		{
+			name: "color_all",
+			op: paintOp{
+				template:      "[{spinner}]{suffix} {message}",
+				maxWidth:      1,
+				char:          character{Value: "x", Size: 1},
+				suffix:        " s",
+				message:       "msg",
+				colorAll:      true,
+				colorFn:       tag,
+				suffixColorFn: tag,
+			},
+			want: "<[x] s msg>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderTemplate(tt.op); got != tt.want {
+				t.Fatalf("renderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_template(t *testing.T) {
+	_, err := New(Config{Frequency: time.Second, Template: "[{spinner}] {msg}"})
+	testErrCheck(t, "New()", "cfg.Template is invalid: template placeholder {msg} is not valid", err)
+
+	spinner, err := New(Config{Frequency: time.Second, Template: "[{spinner}] {message}"})
+	testErrCheck(t, "New()", "", err)
+
+	if spinner.template != "[{spinner}] {message}" {
+		t.Fatalf("spinner.template = %q, want %q", spinner.template, "[{spinner}] {message}")
+	}
+}
+
+func TestSpinner_LoadTemplateFile(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		contents string
+		missing  bool
+		want     string
+		err      string
+	}{
+		{
+			name:     "valid",
+			contents: "[{spinner}] {message}\n",
+			want:     "\r\033[K\ry msg\r\033[K\r[y] msg",
+		},
+		{
+			name:     "valid_crlf",
+			contents: "[{spinner}] {message}\r\n",
+			want:     "\r\033[K\ry msg\r\033[K\r[y] msg",
+		},
+		{
+			name:     "invalid_placeholder",
+			contents: "[{spinner}] {msg}",
+			err:      "template is invalid: template placeholder {msg} is not valid",
+		},
+		{
+			name:    "missing_file",
+			missing: true,
+			err:     "failed to read template file: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".tmpl")
+
+			if !tt.missing {
+				if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+					t.Fatalf("failed to write template file: %v", err)
+				}
+			}
+
+			buf := &bytes.Buffer{}
+
+			spinner, err := New(Config{
+				Writer:       buf,
+				CharSet:      []string{"y"},
+				Suffix:       " ",
+				Message:      "msg",
+				ShowCursor:   true,
+				TerminalMode: termModeTTY,
+			})
+			testErrCheck(t, "New()", "", err)
+
+			testErrCheck(t, "spinner.StartManual()", "", spinner.StartManual())
+
+			spinner.Render()
+
+			if cont := testErrCheck(t, "spinner.LoadTemplateFile()", tt.err, spinner.LoadTemplateFile(path)); !cont {
+				if spinner.template != "" {
+					t.Fatalf("spinner.template = %q, want empty", spinner.template)
+				}
+
+				return
+			}
+
+			spinner.Render()
+
+			if diff := cmp.Diff(tt.want, buf.String()); diff != "" {
+				t.Fatalf("output differs: (-want / +got)\n%s", diff)
+			}
+		})
+	}
+}